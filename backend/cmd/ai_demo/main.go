@@ -33,6 +33,7 @@ func main() {
 		{"Block Detection Test", demoBlockDetection},
 		{"Difficulty Comparison", demoDifficultyComparison},
 		{"Performance Test", demoPerformance},
+		{"Engine vs Engine", demoEngineVsEngine},
 		{"Statistics Overview", demoStatistics},
 	}
 
@@ -251,6 +252,41 @@ func demoPerformance(ai *service.EnhancedAIService) {
 	fmt.Printf("Transposition table entries: %v\n", stats["table_entries"])
 }
 
+func demoEngineVsEngine(ai *service.EnhancedAIService) {
+	// Medium plays black and moves first; Easy plays white
+	opponent := service.NewEnhancedAIService()
+	engines := map[int]struct {
+		ai         *service.EnhancedAIService
+		difficulty service.Difficulty
+	}{
+		1: {ai, service.Medium},
+		2: {opponent, service.Easy},
+	}
+
+	board := createEmptyBoard()
+	lastMove := model.Move{X: -1, Y: -1}
+	player := 1
+	start := time.Now()
+
+	for moveNumber := 1; moveNumber <= 15*15; moveNumber++ {
+		engine := engines[player]
+		move := engine.ai.GetMoveForPlayer(board, lastMove, player, engine.difficulty)
+		board[move.Y][move.X] = player
+
+		gameBoard := &model.Board{Grid: board, Size: 15, MoveCount: moveNumber}
+		if gameBoard.CheckWin(move.X, move.Y, player) {
+			fmt.Printf("  %s (%s) wins after %d moves in %v\n",
+				[]string{"", "Black", "White"}[player], difficultyString(engine.difficulty), moveNumber, time.Since(start))
+			return
+		}
+
+		lastMove = model.Move{X: move.X, Y: move.Y, Player: player}
+		player = 3 - player
+	}
+
+	fmt.Printf("  Draw after %v\n", time.Since(start))
+}
+
 func demoStatistics(ai *service.EnhancedAIService) {
 	fmt.Println("Current AI Statistics:")
 
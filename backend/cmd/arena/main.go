@@ -0,0 +1,308 @@
+// Arena - plays engine-vs-engine matches and reports Elo and SPRT results
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomoku-backend/internal/service"
+)
+
+func main() {
+	first := flag.String("first", "minimax,difficulty=hard", "first player spec")
+	second := flag.String("second", "minimax,difficulty=medium", "second player spec")
+	games := flag.Int("games", 100, "number of games, rounded up to an even number")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "games played in parallel")
+	openingsFile := flag.String("openings", "", "opening suite of Gomocup lines (default: the 26 standard openings)")
+	maxMoves := flag.Int("maxmoves", 0, "stones after which a game is drawn (default: full board)")
+	sprt := flag.String("sprt", "", "run an SPRT with hypotheses elo0,elo1, e.g. 0,10")
+	alpha := flag.Float64("alpha", 0.05, "SPRT false positive rate")
+	beta := flag.Float64("beta", 0.05, "SPRT false negative rate")
+	quiet := flag.Bool("quiet", false, "only print the final result")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	config := service.MatchConfig{
+		Games:       *games,
+		Concurrency: *concurrency,
+		MaxMoves:    *maxMoves,
+	}
+
+	var err error
+	if config.First, err = parsePlayer(*first); err != nil {
+		fail(err)
+	}
+	if config.Second, err = parsePlayer(*second); err != nil {
+		fail(err)
+	}
+
+	if *openingsFile != "" {
+		file, err := os.Open(*openingsFile)
+		if err != nil {
+			fail(err)
+		}
+		config.Openings, err = service.LoadArenaOpenings(file)
+		file.Close()
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *openingsFile, err))
+		}
+	}
+
+	if *sprt != "" {
+		elo0, elo1, found := strings.Cut(*sprt, ",")
+		config.SPRT = &service.SPRTConfig{Alpha: *alpha, Beta: *beta}
+		var err0, err1 error
+		config.SPRT.Elo0, err0 = strconv.ParseFloat(strings.TrimSpace(elo0), 64)
+		config.SPRT.Elo1, err1 = strconv.ParseFloat(strings.TrimSpace(elo1), 64)
+		if !found || err0 != nil || err1 != nil || config.SPRT.Elo1 <= config.SPRT.Elo0 {
+			fail(fmt.Errorf("invalid -sprt %q: want elo0,elo1 with elo0 < elo1", *sprt))
+		}
+	}
+
+	if !*quiet {
+		config.OnGame = func(game service.GameResult, result service.MatchResult) {
+			outcome := map[int]string{0: "draw", 1: config.First.Name + " wins", 2: config.Second.Name + " wins"}[game.Winner]
+			colour := "black"
+			if !game.FirstIsBlack {
+				colour = "white"
+			}
+			line := fmt.Sprintf("Game %3d  opening %2d  %s plays %s  %3d stones  %-20s  score +%d =%d -%d",
+				game.Number+1, game.Opening+1, config.First.Name, colour, len(game.Moves), outcome,
+				result.Wins, result.Draws, result.Losses)
+			if game.Err != nil {
+				line += "  (forfeit: " + game.Err.Error() + ")"
+			}
+			if config.SPRT != nil {
+				line += fmt.Sprintf("  LLR %.2f", result.LLR)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	// Ctrl-C stops the match and still prints the result so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("🏟️  %s vs %s: %d games, %d in parallel\n", config.First.Name, config.Second.Name, *games+*games%2, max(1, *concurrency))
+	start := time.Now()
+	result, err := service.RunMatch(ctx, config)
+	if err != nil {
+		fail(err)
+	}
+	printResult(config, result, time.Since(start))
+}
+
+// parsePlayer parses "engine[,key=value...]". Keys: name, difficulty, depth, nodes, time,
+// weights (a registered set or a JSON file), threads, book, network (a network file), level
+// (a human-like strength level) and personality (a playing style) for minimax, and path for
+// external brains (engine "external").
+func parsePlayer(spec string) (service.ArenaPlayer, error) {
+	fields := strings.Split(spec, ",")
+	engineName := strings.TrimSpace(fields[0])
+	player := service.ArenaPlayer{Name: engineName}
+	options := make(map[string]string)
+
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(field), "=")
+		if !found {
+			return player, fmt.Errorf("%s: option %q is not key=value", spec, field)
+		}
+		options[key] = value
+	}
+
+	for key, value := range options {
+		var err error
+		switch key {
+		case "name":
+			player.Name = value
+		case "difficulty":
+			player.Limits.Difficulty, err = parseDifficulty(value)
+			if player.Name == engineName {
+				player.Name += "-" + value
+			}
+		case "depth":
+			player.Limits.Depth, err = strconv.Atoi(value)
+		case "nodes":
+			player.Limits.Nodes, err = strconv.ParseInt(value, 10, 64)
+		case "time":
+			player.Limits.Time, err = time.ParseDuration(value)
+		case "weights":
+			weights, ok := service.GetEvalWeights(value)
+			if !ok {
+				weights, err = service.LoadEvalWeights(value)
+			}
+			player.Limits.Weights = &weights
+		case "threads", "book", "path", "network", "level", "personality":
+		default:
+			err = fmt.Errorf("unknown option")
+		}
+		if err != nil {
+			return player, fmt.Errorf("%s: %s: %w", spec, key, err)
+		}
+	}
+
+	threads := 1
+	if value, ok := options["threads"]; ok {
+		var err error
+		if threads, err = strconv.Atoi(value); err != nil || threads < 1 {
+			return player, fmt.Errorf("%s: threads must be a positive integer", spec)
+		}
+	}
+
+	switch engineName {
+	case "minimax":
+		// Fresh instances keep transposition tables apart; one thread per game by default
+		// because games already run in parallel
+		book := options["book"] == "true"
+		var level *service.StrengthLevel
+		if value, ok := options["level"]; ok {
+			number, err := strconv.Atoi(value)
+			strength, found := service.GetStrengthLevel(number)
+			if err != nil || !found {
+				return player, fmt.Errorf("%s: level must be between 1 and %d", spec, len(service.StrengthLevels()))
+			}
+			level = &strength
+			if _, ok := options["name"]; !ok {
+				player.Name = fmt.Sprintf("level-%d", number)
+			}
+		}
+		var personality *service.Personality
+		if name, ok := options["personality"]; ok {
+			style, found := service.GetPersonality(name)
+			if !found {
+				return player, fmt.Errorf("%s: unknown personality %q", spec, name)
+			}
+			personality = &style
+			if _, ok := options["name"]; !ok && level == nil {
+				player.Name = name
+			}
+		}
+		var network *service.NeuralNetwork
+		if path, ok := options["network"]; ok {
+			var err error
+			if network, err = service.LoadNeuralNetwork(path); err != nil {
+				return player, err
+			}
+		}
+		player.NewEngine = func() (service.Engine, error) {
+			ai := service.NewEnhancedAIService()
+			for _, difficulty := range []service.Difficulty{service.Easy, service.Medium, service.Hard, service.Expert} {
+				ai.SetSearchThreads(difficulty, threads)
+				ai.SetNeuralNetwork(difficulty, network)
+			}
+			if book {
+				ai.SetOpeningBook(service.NewDefaultOpeningBook())
+			}
+			if personality != nil {
+				// The personality's repertoire replaces the default book
+				if err := ai.SetPersonality(personality); err != nil {
+					return nil, err
+				}
+				ai.SetOpeningBook(personality.OpeningBook())
+			}
+			if level != nil {
+				return service.NewHumanEngine(ai, *level, rand.Int63())
+			}
+			return ai, nil
+		}
+	case "mcts":
+		player.NewEngine = func() (service.Engine, error) {
+			return service.NewMCTSService(), nil
+		}
+	case "external":
+		path := options["path"]
+		if path == "" {
+			return player, fmt.Errorf("%s: external engines need path=<brain executable>", spec)
+		}
+		if _, ok := options["name"]; !ok {
+			player.Name = strings.TrimSuffix(path[strings.LastIndexAny(path, `/\`)+1:], ".exe")
+		}
+		player.NewEngine = func() (service.Engine, error) {
+			return service.NewExternalEngine(service.ExternalEngineConfig{Name: player.Name, Path: path})
+		}
+	default:
+		engine, ok := service.GetEngine(engineName)
+		if !ok {
+			return player, fmt.Errorf("unknown engine %q", engineName)
+		}
+		player.NewEngine = func() (service.Engine, error) {
+			return engine, nil
+		}
+	}
+
+	// Reject limits the engine cannot honour before any game starts
+	engine, err := player.NewEngine()
+	if err != nil {
+		return player, err
+	}
+	if err := player.Limits.Validate(engine.Capabilities()); err != nil {
+		return player, fmt.Errorf("%s: %w", spec, err)
+	}
+	return player, nil
+}
+
+// parseDifficulty converts a difficulty name
+func parseDifficulty(name string) (service.Difficulty, error) {
+	switch name {
+	case "easy":
+		return service.Easy, nil
+	case "medium":
+		return service.Medium, nil
+	case "hard":
+		return service.Hard, nil
+	case "expert":
+		return service.Expert, nil
+	}
+	return 0, fmt.Errorf("unknown difficulty %q", name)
+}
+
+// printResult prints the final score, Elo estimate and SPRT verdict
+func printResult(config service.MatchConfig, result service.MatchResult, elapsed time.Duration) {
+	elo, margin := result.Elo()
+
+	fmt.Println()
+	fmt.Printf("📊 %s vs %s after %d games (%v)\n", config.First.Name, config.Second.Name, result.Games(), elapsed.Round(time.Second))
+	fmt.Printf("   W/D/L:  %d / %d / %d", result.Wins, result.Draws, result.Losses)
+	if result.Forfeits > 0 {
+		fmt.Printf("  (%d forfeits)", result.Forfeits)
+	}
+	fmt.Println()
+	fmt.Printf("   Score:  %.1f%%\n", result.Score()*100)
+	fmt.Printf("   Elo:    %+.1f ± %.1f (95%%)\n", elo, margin)
+
+	if config.SPRT != nil {
+		lower, upper := config.SPRT.Bounds()
+		fmt.Printf("   SPRT:   elo0=%g elo1=%g alpha=%g beta=%g\n", config.SPRT.Elo0, config.SPRT.Elo1, config.SPRT.Alpha, config.SPRT.Beta)
+		fmt.Printf("           LLR %.2f [%.2f, %.2f]: %s\n", result.LLR, lower, upper, result.SPRT)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "arena:", err)
+	os.Exit(1)
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run cmd/arena/main.go [flags]")
+	fmt.Println()
+	fmt.Println("Player specs are engine[,key=value...], for example:")
+	fmt.Println("  minimax,difficulty=hard")
+	fmt.Println("  minimax,depth=6,threads=2,book=true,name=deep")
+	fmt.Println("  mcts,nodes=5000")
+	fmt.Println("  minimax,depth=4,weights=tuned_weights.json")
+	fmt.Println("  minimax,depth=4,network=network.nnue")
+	fmt.Println("  minimax,level=5")
+	fmt.Println("  minimax,depth=4,personality=attacker")
+	fmt.Println("  external,path=/opt/brains/pbrain-rapfi,time=1s")
+	fmt.Println()
+	fmt.Println("Flags:")
+	flag.PrintDefaults()
+}
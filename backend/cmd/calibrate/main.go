@@ -0,0 +1,98 @@
+// Calibrate - measures the Elo ratings of the human-like strength levels
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"gomoku-backend/internal/service"
+)
+
+func main() {
+	games := flag.Int("games", 100, "games between each pair of neighbouring levels")
+	from := flag.Int("from", 1, "weakest level to calibrate")
+	to := flag.Int("to", len(service.StrengthLevels()), "strongest level to calibrate")
+	anchor := flag.Float64("anchor", 600, "rating of the weakest level")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "games played in parallel")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	flag.Parse()
+
+	levels := service.StrengthLevels()
+	if *from < 1 || *to > len(levels) || *from >= *to {
+		fail(fmt.Errorf("levels must satisfy 1 <= from < to <= %d", len(levels)))
+	}
+
+	// Ctrl-C stops the calibration after the current match
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rng := rand.New(rand.NewSource(*seed))
+	ratings := map[int]float64{*from: *anchor}
+	fmt.Printf("📏 Calibrating levels %d-%d, %d games per pair\n", *from, *to, *games)
+	for number := *from; number < *to; number++ {
+		weaker, stronger := levels[number-1], levels[number]
+		start := time.Now()
+		result, err := service.RunMatch(ctx, service.MatchConfig{
+			First:       levelPlayer(stronger, rng),
+			Second:      levelPlayer(weaker, rng),
+			Games:       *games,
+			Openings:    service.RandomOpenings(*games/2+1, 1, rng),
+			Concurrency: *concurrency,
+		})
+		if err != nil {
+			fail(err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		elo, margin := eloDifference(result)
+		ratings[number+1] = ratings[number] + elo
+		fmt.Printf("   level %2d vs %2d  +%d =%d -%d  %+6.0f ± %3.0f  (%v)\n", stronger.Level, weaker.Level,
+			result.Wins, result.Draws, result.Losses, elo, margin, time.Since(start).Round(time.Second))
+	}
+
+	fmt.Println()
+	fmt.Println("📊 Ratings")
+	for number := *from; number <= *to; number++ {
+		if rating, ok := ratings[number]; ok {
+			fmt.Printf("   level %2d  %-12s %5.0f\n", number, levels[number-1].Name, rating)
+		}
+	}
+}
+
+// levelPlayer plays a strength level with its own engine and random choices per game slot
+func levelPlayer(level service.StrengthLevel, rng *rand.Rand) service.ArenaPlayer {
+	seed := rng.Int63()
+	var slots atomic.Int64
+	return service.ArenaPlayer{
+		Name: fmt.Sprintf("level-%d", level.Level),
+		NewEngine: func() (service.Engine, error) {
+			return service.NewHumanEngine(service.NewEnhancedAIService(), level, seed+slots.Add(1))
+		},
+	}
+}
+
+// eloDifference estimates the first player's advantage. Half a win and half a loss are added
+// so that a clean sweep still gives a finite rating.
+func eloDifference(result service.MatchResult) (float64, float64) {
+	games := float64(result.Games()) + 1
+	score := (float64(result.Wins) + float64(result.Draws)/2 + 0.5) / games
+	elo := -400 * math.Log10(1/score-1)
+	margin := 1.959964 * math.Sqrt(score*(1-score)/games)
+	upper, lower := math.Min(score+margin, 1-1e-6), math.Max(score-margin, 1e-6)
+	return elo, (-400*math.Log10(1/upper-1) + 400*math.Log10(1/lower-1)) / 2
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "calibrate:", err)
+	os.Exit(1)
+}
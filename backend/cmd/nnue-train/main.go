@@ -0,0 +1,167 @@
+// NNUE Train - trains the neural evaluation on engine self-play games it generates
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+func main() {
+	generations := flag.Int("generations", 1, "self-play and training rounds; later rounds play with the network")
+	selfPlay := flag.Int("selfplay", 200, "self-play games per generation")
+	depth := flag.Int("depth", 2, "search depth of the self-play engine")
+	randomMoves := flag.Int("random", 2, "random stones added to each self-play opening")
+	gamesFile := flag.String("games", "", "extra training games (.psq or Gomocup lines)")
+	save := flag.String("save", "", "append the self-play games to this file")
+	startFile := flag.String("start", "", "network to continue training (default: a new network)")
+	hidden := flag.Int("hidden", 64, "accumulator size of a new network")
+	hidden2 := flag.Int("hidden2", 16, "second layer size of a new network")
+	skip := flag.Int("skip", 4, "opening positions skipped in every game")
+	epochs := flag.Int("epochs", 20, "training passes per generation")
+	batch := flag.Int("batch", 256, "samples per update")
+	rate := flag.Float64("lr", 0.001, "learning rate")
+	lambda := flag.Float64("lambda", 0.5, "share of the target taken from the pattern evaluation")
+	validation := flag.Float64("validation", 0.1, "fraction of samples held out")
+	out := flag.String("out", "network.nnue", "output network file")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	flag.Parse()
+
+	var network *service.NeuralNetwork
+	var err error
+	if *startFile != "" {
+		network, err = service.LoadNeuralNetwork(*startFile)
+	} else {
+		network, err = service.NewNeuralNetwork(*hidden, *hidden2, *seed)
+	}
+	if err != nil {
+		fail(err)
+	}
+	first, second := network.Size()
+	fmt.Printf("🧠 Network %dx%d\n", first, second)
+
+	var games [][]model.Move
+	if *gamesFile != "" {
+		if games, err = service.LoadGameRecords(*gamesFile); err != nil {
+			fail(err)
+		}
+		fmt.Printf("📂 Loaded %d games\n", len(games))
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	for generation := 1; generation <= *generations; generation++ {
+		// The first generation of a new network plays with the pattern evaluation
+		player := network
+		if generation == 1 && *startFile == "" {
+			player = nil
+		}
+		generated, err := generateGames(*selfPlay, *depth, *randomMoves, rng, player)
+		if err != nil {
+			fail(err)
+		}
+		games = append(games, generated...)
+		if *save != "" {
+			if err := appendGames(*save, generated); err != nil {
+				fail(err)
+			}
+		}
+
+		var samples []service.NetworkSample
+		for i, game := range games {
+			extracted, err := service.ExtractNetworkSamples(game, *skip)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping game %d: %v\n", i+1, err)
+				continue
+			}
+			samples = append(samples, extracted...)
+		}
+		fmt.Printf("🧮 Generation %d: training on %d positions from %d games\n", generation, len(samples), len(games))
+
+		result, err := service.TrainNeuralNetwork(network, samples, service.NetworkTrainOptions{
+			Epochs:       *epochs,
+			BatchSize:    *batch,
+			LearningRate: *rate,
+			Lambda:       *lambda,
+			Validation:   *validation,
+			Seed:         rng.Int63(),
+			Progress: func(epoch int, trainLoss, validationLoss float64) {
+				fmt.Printf("   epoch %3d  loss %.6f  validation %.6f\n", epoch, trainLoss, validationLoss)
+			},
+		})
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("   loss %.6f -> %.6f\n", result.InitialLoss, result.TrainLoss)
+
+		if err := network.Save(*out); err != nil {
+			fail(err)
+		}
+	}
+
+	fmt.Printf("\n✅ Wrote %s; enable it with NNUE_NETWORK=%s\n", *out, *out)
+}
+
+// generateGames plays self-play games from randomised openings, evaluating with the network
+// when one is given and with the patterns otherwise
+func generateGames(count, depth, randomMoves int, rng *rand.Rand, network *service.NeuralNetwork) ([][]model.Move, error) {
+	evaluation := "pattern"
+	if network != nil {
+		evaluation = "network"
+	}
+	fmt.Printf("🎲 Playing %d self-play games at depth %d with the %s evaluation\n", count, depth, evaluation)
+	player := service.ArenaPlayer{
+		Name: "self",
+		NewEngine: func() (service.Engine, error) {
+			ai := service.NewEnhancedAIService()
+			ai.SetNeuralNetwork(service.Medium, network)
+			return ai, nil
+		},
+		Limits: service.SearchLimits{Difficulty: service.Medium, Depth: depth},
+	}
+
+	var games [][]model.Move
+	_, err := service.RunMatch(context.Background(), service.MatchConfig{
+		First:       player,
+		Second:      player,
+		Games:       count,
+		Openings:    service.RandomOpenings(count/2+1, randomMoves, rng),
+		Concurrency: runtime.NumCPU(),
+		OnGame: func(game service.GameResult, result service.MatchResult) {
+			games = append(games, game.Moves)
+			if len(games)%50 == 0 {
+				fmt.Printf("   %d games\n", len(games))
+			}
+		},
+	})
+	return games, err
+}
+
+// appendGames adds one game per line in the Gomocup lines format
+func appendGames(path string, games [][]model.Move) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, game := range games {
+		fmt.Fprintln(writer, service.FormatGameRecord(game))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "nnue-train:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,333 @@
+// pbrain-arya - Gomocup brain speaking the Piskvork protocol on stdin/stdout
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+const (
+	boardSize = 15
+
+	// Stones as the manager sees them
+	ownStone      = 1
+	opponentStone = 2
+
+	// Time kept back from every move for process and pipe overhead
+	safetyMargin = 50 * time.Millisecond
+	// Minimum thinking time, also used when the manager asks for instant moves
+	minMoveTime = 20 * time.Millisecond
+	// Moves the remaining match time is shared between
+	movesToGo = 25
+	// Fraction of max_memory given to the transposition table
+	tableMemoryShare = 2
+	// Transposition table size when the manager sets no memory limit
+	defaultTableMemory = 64 << 20
+)
+
+// brain holds the game state between protocol commands
+type brain struct {
+	ai     *service.EnhancedAIService
+	out    *bufio.Writer
+	board  [][]int // 0 empty, ownStone or opponentStone, indexed [y][x]
+	last   model.Move
+	active bool // START received
+
+	timeoutTurn  time.Duration // 0 means play as fast as possible
+	timeoutMatch time.Duration // 0 means no match limit
+	timeLeft     time.Duration // 0 means unknown
+	maxMemory    int64         // 0 means no limit
+}
+
+func main() {
+	ai := service.NewEnhancedAIService()
+	ai.SetOpeningBook(service.NewDefaultOpeningBook())
+
+	b := newBrain(ai, os.Stdout)
+	b.run(os.Stdin)
+}
+
+// newBrain creates a brain writing responses to w
+func newBrain(ai *service.EnhancedAIService, w io.Writer) *brain {
+	b := &brain{
+		ai:          ai,
+		out:         bufio.NewWriter(w),
+		last:        model.Move{X: -1, Y: -1},
+		timeoutTurn: 5 * time.Second,
+	}
+	b.applyMemoryLimit()
+	return b
+}
+
+// run handles commands until END or the end of input
+func (b *brain) run(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !b.handle(line, scanner) {
+			return
+		}
+	}
+}
+
+// handle executes one command; it returns false when the brain should exit
+func (b *brain) handle(line string, scanner *bufio.Scanner) bool {
+	command, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+
+	switch strings.ToUpper(command) {
+	case "START":
+		size, err := strconv.Atoi(args)
+		if err != nil || size != boardSize {
+			b.reply("ERROR only %dx%d boards are supported", boardSize, boardSize)
+			return true
+		}
+		b.reset()
+		b.reply("OK")
+
+	case "RESTART":
+		b.reset()
+		b.reply("OK")
+
+	case "BEGIN":
+		if !b.ready() {
+			return true
+		}
+		b.think()
+
+	case "TURN":
+		if !b.ready() {
+			return true
+		}
+		move, err := parseMove(args)
+		if err != nil || b.board[move.Y][move.X] != 0 {
+			b.reply("ERROR invalid move %q", args)
+			return true
+		}
+		b.board[move.Y][move.X] = opponentStone
+		b.last = move
+		b.think()
+
+	case "BOARD":
+		if !b.ready() {
+			return true
+		}
+		if err := b.readBoard(scanner); err != nil {
+			b.reply("ERROR %v", err)
+			return true
+		}
+		b.think()
+
+	case "TAKEBACK":
+		move, err := parseMove(args)
+		if !b.active || err != nil || b.board[move.Y][move.X] == 0 {
+			b.reply("ERROR invalid takeback %q", args)
+			return true
+		}
+		b.board[move.Y][move.X] = 0
+		b.last = model.Move{X: -1, Y: -1}
+		b.reply("OK")
+
+	case "INFO":
+		b.info(args)
+
+	case "ABOUT":
+		b.reply(`name="Arya", version="1.0", author="Arya-Gomoku", country="Unknown"`)
+
+	case "END":
+		return false
+
+	default:
+		b.reply("UNKNOWN command %s", command)
+	}
+	return true
+}
+
+// reset clears the board for a new game
+func (b *brain) reset() {
+	b.board = newGrid()
+	b.last = model.Move{X: -1, Y: -1}
+	b.active = true
+	b.ai.ClearTranspositionTable()
+}
+
+// ready reports an error unless a game has been started
+func (b *brain) ready() bool {
+	if !b.active {
+		b.reply("ERROR game not started")
+	}
+	return b.active
+}
+
+// readBoard reads x,y,field lines until DONE. Field 1 is our stone, 2 the opponent's
+// and 3 a stone of a continuous game, which is treated as the opponent's.
+func (b *brain) readBoard(scanner *bufio.Scanner) error {
+	b.board = newGrid()
+	b.last = model.Move{X: -1, Y: -1}
+
+	var err error
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.EqualFold(line, "DONE") {
+			return err
+		}
+
+		parts := strings.Split(line, ",")
+		if len(parts) != 3 {
+			err = fmt.Errorf("invalid board line %q", line)
+			continue
+		}
+		move, moveErr := parseMove(parts[0] + "," + parts[1])
+		field, fieldErr := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if moveErr != nil || fieldErr != nil || field < 1 || field > 3 {
+			err = fmt.Errorf("invalid board line %q", line)
+			continue
+		}
+
+		stone := opponentStone
+		if field == ownStone {
+			stone = ownStone
+		}
+		b.board[move.Y][move.X] = stone
+		b.last = move
+	}
+	return fmt.Errorf("board not terminated by DONE")
+}
+
+// info stores a tournament setting; unknown keys are ignored as the protocol requires
+func (b *brain) info(args string) {
+	key, value, _ := strings.Cut(args, " ")
+	number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return
+	}
+
+	switch strings.ToLower(key) {
+	case "timeout_turn":
+		b.timeoutTurn = time.Duration(number) * time.Millisecond
+	case "timeout_match":
+		b.timeoutMatch = time.Duration(number) * time.Millisecond
+	case "time_left":
+		b.timeLeft = time.Duration(number) * time.Millisecond
+	case "max_memory":
+		b.maxMemory = number
+		b.applyMemoryLimit()
+	}
+}
+
+// applyMemoryLimit sizes the transposition table and search threads to fit max_memory
+func (b *brain) applyMemoryLimit() {
+	tableMemory := int64(defaultTableMemory)
+	if b.maxMemory > 0 {
+		tableMemory = min(tableMemory, b.maxMemory/tableMemoryShare)
+	}
+	b.ai.SetTranspositionTableMemory(tableMemory)
+
+	// Every search thread keeps its own board and tables; stay single-threaded on tight limits
+	threads := runtime.NumCPU()
+	if b.maxMemory > 0 && b.maxMemory < 64<<20 {
+		threads = 1
+	}
+	b.ai.SetSearchThreads(service.Expert, threads)
+}
+
+// moveTime returns the thinking time for the next move
+func (b *brain) moveTime() time.Duration {
+	budget := b.timeoutTurn
+	if budget <= 0 {
+		return minMoveTime
+	}
+	if b.timeoutMatch > 0 && b.timeLeft > 0 {
+		budget = min(budget, b.timeLeft/movesToGo)
+	}
+	return max(budget-safetyMargin, minMoveTime)
+}
+
+// think searches the current position, plays the move and reports it
+func (b *brain) think() {
+	// The brain is always the side to move: it plays black when both sides have as many stones
+	own, opponent := 0, 0
+	for _, row := range b.board {
+		for _, cell := range row {
+			switch cell {
+			case ownStone:
+				own++
+			case opponentStone:
+				opponent++
+			}
+		}
+	}
+	player, other := 1, 2
+	if opponent > own {
+		player, other = 2, 1
+	}
+
+	// Translate to engine colours
+	board := newGrid()
+	for y, row := range b.board {
+		for x, cell := range row {
+			switch cell {
+			case ownStone:
+				board[y][x] = player
+			case opponentStone:
+				board[y][x] = other
+			}
+		}
+	}
+
+	move, err := b.ai.BestMove(context.Background(),
+		service.Position{Board: board, LastMove: b.last, ToMove: player},
+		service.SearchLimits{Difficulty: service.Expert, Depth: 60, Time: b.moveTime()})
+	if err != nil || move.X < 0 || b.board[move.Y][move.X] != 0 {
+		b.reply("ERROR no move found")
+		return
+	}
+
+	b.board[move.Y][move.X] = ownStone
+	b.last = model.Move{X: move.X, Y: move.Y}
+	b.reply("MESSAGE score %d", move.Score)
+	b.reply("%d,%d", move.X, move.Y)
+}
+
+// reply writes one response line and flushes it to the manager
+func (b *brain) reply(format string, args ...interface{}) {
+	fmt.Fprintf(b.out, format+"\n", args...)
+	b.out.Flush()
+}
+
+// parseMove parses an "x,y" coordinate pair inside the board
+func parseMove(s string) (model.Move, error) {
+	xs, ys, found := strings.Cut(s, ",")
+	if !found {
+		return model.Move{}, fmt.Errorf("invalid coordinates %q", s)
+	}
+	x, errX := strconv.Atoi(strings.TrimSpace(xs))
+	y, errY := strconv.Atoi(strings.TrimSpace(ys))
+	if errX != nil || errY != nil || x < 0 || x >= boardSize || y < 0 || y >= boardSize {
+		return model.Move{}, fmt.Errorf("invalid coordinates %q", s)
+	}
+	return model.Move{X: x, Y: y}, nil
+}
+
+// newGrid returns an empty board grid
+func newGrid() [][]int {
+	grid := make([][]int, boardSize)
+	for i := range grid {
+		grid[i] = make([]int, boardSize)
+	}
+	return grid
+}
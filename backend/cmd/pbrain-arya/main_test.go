@@ -0,0 +1,133 @@
+// Tests for the Piskvork protocol handler, feeding scripted manager input to the brain
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/service"
+)
+
+// runBrain plays a manager script, one command per line, and returns the brain and the
+// lines it wrote. Moves are searched as fast as the brain allows.
+func runBrain(t *testing.T, script ...string) (*brain, []string) {
+	t.Helper()
+	var out bytes.Buffer
+	b := newBrain(service.NewEnhancedAIService(), &out)
+	b.run(strings.NewReader("INFO timeout_turn 0\n" + strings.Join(script, "\n") + "\n"))
+	return b, strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestBrainCommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		script []string
+		want   []string
+	}{
+		{"start", []string{"START 15"}, []string{"OK"}},
+		{"other sizes", []string{"START 20"}, []string{"ERROR only 15x15 boards are supported"}},
+		{"not started", []string{"BEGIN", "TURN 7,7", "BOARD"}, []string{"ERROR game not started", "ERROR game not started", "ERROR game not started"}},
+		{"about", []string{"ABOUT"}, []string{`name="Arya", version="1.0", author="Arya-Gomoku", country="Unknown"`}},
+		{"unknown", []string{"PLAY 7,7", "INFO rule 1"}, []string{"UNKNOWN command PLAY"}},
+		{"off the board", []string{"START 15", "TURN 15,0"}, []string{"OK", `ERROR invalid move "15,0"`}},
+		{"end", []string{"START 15", "END", "START 15"}, []string{"OK"}},
+		{"restart", []string{"RESTART"}, []string{"OK"}},
+		{"unterminated board", []string{"START 15", "BOARD", "7,7,1"}, []string{"OK", "ERROR board not terminated by DONE"}},
+		{"bad board line", []string{"START 15", "BOARD", "7,7,4", "DONE"}, []string{"OK", `ERROR invalid board line "7,7,4"`}},
+	}
+	for _, tt := range tests {
+		_, lines := runBrain(t, tt.script...)
+		if strings.Join(lines, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, lines)
+		}
+	}
+}
+
+func TestBrainBoardFields(t *testing.T) {
+	// Both sides have four in a row; the brain is to move and completes its own, so the row
+	// it plays on shows which field it took for its stones
+	tests := []struct {
+		name  string
+		own   string // Field of the stones on row 7
+		other string // Field of the stones on row 9
+		row   int
+	}{
+		{"own on row 7", "1", "2", 7},
+		{"own on row 9", "2", "1", 9},
+		{"continuous game stones are the opponent's", "3", "1", 9},
+	}
+	for _, tt := range tests {
+		script := []string{"START 15", "BOARD"}
+		for x := 3; x <= 6; x++ {
+			script = append(script, strconv.Itoa(x)+",7,"+tt.own, strconv.Itoa(x)+",9,"+tt.other)
+		}
+		script = append(script, "DONE")
+
+		b, lines := runBrain(t, script...)
+		move, err := parseMove(lines[len(lines)-1])
+		if err != nil {
+			t.Fatalf("%s: expected a move, got %q", tt.name, lines)
+		}
+		if move.Y != tt.row || (move.X != 2 && move.X != 7) {
+			t.Errorf("%s: expected five on row %d, got %d,%d", tt.name, tt.row, move.X, move.Y)
+		}
+		if b.board[move.Y][move.X] != ownStone {
+			t.Errorf("%s: expected the move kept as the brain's stone", tt.name)
+		}
+	}
+}
+
+func TestBrainTakeback(t *testing.T) {
+	b, lines := runBrain(t, "START 15", "TURN 7,7", "TAKEBACK 7,7", "TAKEBACK 7,7", "TAKEBACK 7")
+	if len(lines) != 6 {
+		t.Fatalf("Expected OK, a move, OK and two errors, got %q", lines)
+	}
+	reply, err := parseMove(lines[2])
+	if err != nil {
+		t.Fatalf("Expected the reply to 7,7, got %q", lines[2])
+	}
+	if lines[3] != "OK" || lines[4] != `ERROR invalid takeback "7,7"` || lines[5] != `ERROR invalid takeback "7"` {
+		t.Errorf("Expected the first takeback accepted and the others rejected, got %q", lines[3:])
+	}
+	if b.board[7][7] != 0 || b.board[reply.Y][reply.X] != ownStone || b.last.X != -1 {
+		t.Error("Expected only the opponent's stone taken back")
+	}
+
+	// The square can be played again
+	_, lines = runBrain(t, "START 15", "TURN 7,7", "TAKEBACK 7,7", "TURN 7,7")
+	if _, err := parseMove(lines[len(lines)-1]); err != nil {
+		t.Errorf("Expected a move after replaying 7,7, got %q", lines)
+	}
+}
+
+func TestBrainMoveTime(t *testing.T) {
+	tests := []struct {
+		name string
+		info []string
+		want time.Duration
+	}{
+		{"default", nil, 5*time.Second - safetyMargin},
+		{"turn limit", []string{"INFO timeout_turn 1000"}, 950 * time.Millisecond},
+		{"instant", []string{"INFO timeout_turn 0"}, minMoveTime},
+		{"margin larger than the turn", []string{"INFO timeout_turn 30"}, minMoveTime},
+		{"match time shared", []string{"INFO timeout_turn 1000", "INFO timeout_match 100000", "INFO time_left 10000"}, 350 * time.Millisecond},
+		{"turn limit below the share", []string{"INFO timeout_turn 200", "INFO timeout_match 100000", "INFO time_left 90000"}, 150 * time.Millisecond},
+		{"match nearly over", []string{"INFO timeout_turn 1000", "INFO timeout_match 100000", "INFO time_left 500"}, minMoveTime},
+		{"time left without a match limit", []string{"INFO timeout_turn 1000", "INFO time_left 500"}, 950 * time.Millisecond},
+		{"unreadable value", []string{"INFO timeout_turn 1000", "INFO timeout_turn soon"}, 950 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		b := newBrain(service.NewEnhancedAIService(), &out)
+		b.run(strings.NewReader(strings.Join(tt.info, "\n")))
+		if got := b.moveTime(); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: expected INFO to be answered with nothing, got %q", tt.name, out.String())
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Tune - fits the evaluation weights to game outcomes (Texel tuning)
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+func main() {
+	gamesFlag := flag.String("games", "", "comma-separated game record files or directories (.psq or Gomocup lines)")
+	selfPlay := flag.Int("selfplay", 0, "number of self-play games to generate")
+	depth := flag.Int("depth", 2, "search depth of the self-play engine")
+	randomMoves := flag.Int("random", 2, "random stones added to each self-play opening")
+	save := flag.String("save", "", "write the self-play games to this file")
+	startFile := flag.String("start", "", "starting weight set (default: the built-in weights)")
+	out := flag.String("out", "tuned_weights.json", "output weight file")
+	name := flag.String("name", "tuned", "name of the tuned weight set")
+	skip := flag.Int("skip", 4, "opening positions skipped in every game")
+	passes := flag.Int("passes", 100, "maximum passes over the parameters")
+	fixed := flag.String("fixed", "", "comma-separated parameters to keep, e.g. tempo,center")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed for self-play openings")
+	flag.Parse()
+
+	var games [][]model.Move
+	if *gamesFlag != "" {
+		loaded, err := loadGames(strings.Split(*gamesFlag, ","))
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("📂 Loaded %d games\n", len(loaded))
+		games = append(games, loaded...)
+	}
+
+	start := service.DefaultEvalWeights()
+	if *startFile != "" {
+		var err error
+		if start, err = service.LoadEvalWeights(*startFile); err != nil {
+			fail(err)
+		}
+	}
+
+	if *selfPlay > 0 {
+		generated, err := generateGames(*selfPlay, *depth, *randomMoves, *seed, start)
+		if err != nil {
+			fail(err)
+		}
+		games = append(games, generated...)
+		if *save != "" {
+			if err := saveGames(*save, generated); err != nil {
+				fail(err)
+			}
+			fmt.Printf("💾 Saved %d games to %s\n", len(generated), *save)
+		}
+	}
+	if len(games) == 0 {
+		fail(fmt.Errorf("no games: use -games and/or -selfplay"))
+	}
+
+	var positions []service.TuningPosition
+	for i, game := range games {
+		extracted, err := service.ExtractTuningPositions(game, *skip)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping game %d: %v\n", i+1, err)
+			continue
+		}
+		positions = append(positions, extracted...)
+	}
+	fmt.Printf("🧮 Tuning on %d quiet positions from %d games\n", len(positions), len(games))
+
+	options := service.TuneOptions{
+		Passes: *passes,
+		Fixed:  make(map[string]bool),
+		Progress: func(pass int, mse float64, w service.EvalWeights) {
+			fmt.Printf("   pass %3d  error %.6f\n", pass, mse)
+		},
+	}
+	for _, param := range strings.Split(*fixed, ",") {
+		if param = strings.TrimSpace(param); param != "" {
+			options.Fixed[param] = true
+		}
+	}
+
+	result, err := service.TuneEvalWeights(positions, start, options)
+	if err != nil {
+		fail(err)
+	}
+	result.Weights.Name = *name
+
+	fmt.Printf("\n📊 Error %.6f -> %.6f after %d passes (scale %.6f)\n", result.InitialError, result.FinalError, result.Passes, result.Scale)
+	printWeights(start, result.Weights)
+
+	data, err := json.MarshalIndent(result.Weights, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("\n✅ Wrote %s; select it with EVAL_WEIGHTS=%s and weights=%s\n", *out, *out, result.Weights.Name)
+}
+
+// loadGames reads every record file, descending into directories
+func loadGames(paths []string) ([][]model.Move, error) {
+	var games [][]model.Move
+	for _, path := range paths {
+		err := filepath.Walk(strings.TrimSpace(path), func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			loaded, err := service.LoadGameRecords(file)
+			if err != nil {
+				return err
+			}
+			games = append(games, loaded...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return games, nil
+}
+
+// generateGames plays self-play games from randomised openings with the starting weights
+func generateGames(count, depth, randomMoves int, seed int64, weights service.EvalWeights) ([][]model.Move, error) {
+	fmt.Printf("🎲 Playing %d self-play games at depth %d\n", count, depth)
+	player := service.ArenaPlayer{
+		Name: "self",
+		NewEngine: func() (service.Engine, error) {
+			ai := service.NewEnhancedAIService()
+			return ai, ai.SetEvalWeights(weights)
+		},
+		Limits: service.SearchLimits{Depth: depth},
+	}
+
+	var games [][]model.Move
+	_, err := service.RunMatch(context.Background(), service.MatchConfig{
+		First:       player,
+		Second:      player,
+		Games:       count,
+		Openings:    service.RandomOpenings(count/2+1, randomMoves, rand.New(rand.NewSource(seed))),
+		Concurrency: runtime.NumCPU(),
+		OnGame: func(game service.GameResult, result service.MatchResult) {
+			games = append(games, game.Moves)
+			if len(games)%50 == 0 {
+				fmt.Printf("   %d games\n", len(games))
+			}
+		},
+	})
+	return games, err
+}
+
+// saveGames writes one game per line in the Gomocup lines format
+func saveGames(path string, games [][]model.Move) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, game := range games {
+		fmt.Fprintln(writer, service.FormatGameRecord(game))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// printWeights shows the starting and tuned value of every parameter
+func printWeights(start, tuned service.EvalWeights) {
+	var before, after map[string]interface{}
+	startJSON, _ := json.Marshal(start)
+	tunedJSON, _ := json.Marshal(tuned)
+	json.Unmarshal(startJSON, &before)
+	json.Unmarshal(tunedJSON, &after)
+
+	for _, key := range []string{"closed_two", "open_two", "closed_three", "broken_three", "open_three",
+		"broken_four", "four", "open_four", "center", "tempo", "three_threat"} {
+		fmt.Printf("   %-13s %6v -> %6v\n", key, before[key], after[key])
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "tune:", err)
+	os.Exit(1)
+}
@@ -3,8 +3,11 @@
 package controller
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,15 +18,22 @@ import (
 
 // AIController handles AI-related HTTP requests
 type AIController struct {
-	aiService         *service.AIService
 	enhancedAIService *service.EnhancedAIService
+	llmService        *service.LLMService
+	aiGameService     *service.AIGameService
 }
 
-// NewAIController creates a new AI controller instance
-func NewAIController() *AIController {
+// NewAIController creates a new AI controller instance.
+// Moves are served by the registered engines; the minimax engine also backs the
+// statistics, cache, benchmark, opening book, analysis and hint endpoints. Hints in LLM
+// and AI games are counted on the game.
+func NewAIController(llmService *service.LLMService, aiGameService *service.AIGameService) *AIController {
+	engine, _ := service.GetEngine("minimax")
+
 	return &AIController{
-		aiService:         service.NewAIService(),
-		enhancedAIService: service.NewEnhancedAIService(),
+		enhancedAIService: engine.(*service.EnhancedAIService),
+		llmService:        llmService,
+		aiGameService:     aiGameService,
 	}
 }
 
@@ -43,70 +53,107 @@ func (ac *AIController) GetAIMove(c *gin.Context) {
 
 	// Get difficulty level from query parameter (default: Medium)
 	difficultyStr := c.DefaultQuery("difficulty", "medium")
-	useEnhanced := c.DefaultQuery("enhanced", "true") == "true"
-
-	var difficulty service.Difficulty
-	switch difficultyStr {
-	case "easy":
-		difficulty = service.Easy
-	case "medium":
-		difficulty = service.Medium
-	case "hard":
-		difficulty = service.Hard
-	case "expert":
-		difficulty = service.Expert
-	default:
-		difficulty = service.Medium
+	difficulty := parseDifficulty(difficultyStr)
+
+	// Select the engine by name; enhanced=false is kept as an alias for the heuristic engine
+	engineName := c.Query("engine")
+	if engineName == "" {
+		engineName = "minimax"
+		if c.DefaultQuery("enhanced", "true") != "true" {
+			engineName = "heuristic"
+		}
 	}
-
-	// Validate board dimensions
-	if len(request.Board) != 15 {
+	engine, exists := service.GetEngine(engineName)
+	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Board must be 15x15",
+			"error": "Unknown engine: " + engineName,
 		})
 		return
 	}
 
-	for i, row := range request.Board {
-		if len(row) != 15 {
+	// A personality plays the minimax engine in its own style
+	searcher := ac.enhancedAIService
+	personality := c.Query("personality")
+	if personality != "" {
+		ai, exists := service.PersonalityAI(personality)
+		if !exists || engineName != "minimax" {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Board row " + string(rune(i+1)) + " must have 15 columns",
+				"error": "Unknown minimax personality: " + personality,
 			})
 			return
 		}
+		searcher, engine = ai, ai
 	}
 
-	// Validate player
-	if request.Player != 1 && request.Player != 2 {
+	// A strength level plays human-like moves on top of the minimax engine
+	if value := c.Query("level"); value != "" {
+		level, err := parseStrengthLevel(value)
+		if err == nil && engineName != "minimax" {
+			err = errors.New("strength levels are played by the minimax engine")
+		}
+		if err == nil {
+			engine, err = service.NewHumanEngine(searcher, level, time.Now().UnixNano())
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	// Search limits override the difficulty defaults
+	limits, err := parseSearchLimits(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Player must be 1 (human) or 2 (AI)",
+			"error": err.Error(),
 		})
 		return
 	}
+	limits.Difficulty = difficulty
 
-	// Validate last move coordinates
-	if request.LastMove.X < 0 || request.LastMove.X >= 15 ||
-	   request.LastMove.Y < 0 || request.LastMove.Y >= 15 {
+	// A named weight set replaces the engine's evaluation weights
+	if limits.Weights, err = parseEvalWeights(c); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Last move coordinates must be within 0-14 range",
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Additional board state validation
-	if err := ac.validateBoardState(request.Board); err != nil {
+	if err := ac.validateGameRequest(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	// Get AI move using enhanced or regular AI
-	var aiMove model.AIMove
-	if useEnhanced {
-		aiMove = ac.enhancedAIService.GetAIMove(request.Board, request.LastMove, difficulty)
-	} else {
-		aiMove = ac.aiService.GetAIMove(request.Board, request.LastMove)
+	// Get AI move from the selected engine; a closed connection cancels the search
+	position := service.Position{Board: request.Board, LastMove: request.LastMove, ToMove: request.Player}
+	aiMove, err := engine.BestMove(c.Request.Context(), position, limits)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrBrainTimeout) {
+			status = http.StatusGatewayTimeout
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Engines return a placeholder when the board has no move left, and an external brain
+	// may answer anything; never play onto a stone
+	if aiMove.X < 0 || aiMove.X >= 15 || aiMove.Y < 0 || aiMove.Y >= 15 ||
+		request.Board[aiMove.Y][aiMove.X] != 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("No legal move: engine answered (%d, %d), which is not an empty square", aiMove.X, aiMove.Y),
+		})
+		return
+	}
+
+	aiEngine := engine.Name()
+	if aiMove.FromBook {
+		aiEngine = "opening_book"
 	}
 	
 	// Create a temporary board to check game state after AI move
@@ -117,19 +164,23 @@ func (ac *AIController) GetAIMove(c *gin.Context) {
 	}
 	
 	// Apply AI move to temporary board
-	tempBoard[aiMove.Y][aiMove.X] = 2
+	tempBoard[aiMove.Y][aiMove.X] = request.Player
 	
 	// Create board model to check win condition
 	board := &model.Board{
 		Grid:          tempBoard,
 		Size:          15,
-		CurrentPlayer: 1, // Next turn would be human
-		MoveCount:     ac.countMoves(tempBoard) + 1,
+		CurrentPlayer: 3 - request.Player, // Next turn would be human
+		MoveCount:     ac.countMoves(tempBoard),
 	}
 	
 	// Check game state after AI move
-	aiMoveModel := &model.Move{X: aiMove.X, Y: aiMove.Y, Player: 2}
+	aiMoveModel := &model.Move{X: aiMove.X, Y: aiMove.Y, Player: request.Player}
 	gameState := board.GetGameState(aiMoveModel)
+	if gameState.Winner == request.Player {
+		// Status is reported from the human's point of view
+		gameState.Status = "lose"
+	}
 	
 	// Prepare response
 	response := model.GameResponse{
@@ -138,43 +189,228 @@ func (ac *AIController) GetAIMove(c *gin.Context) {
 		Winner:     gameState.Winner,
 	}
 
-	// Add enhanced AI stats if using enhanced AI
-	if useEnhanced {
-		stats := ac.enhancedAIService.GetStats()
-		c.JSON(http.StatusOK, gin.H{
-			"aiMove":       response.AIMove,
-			"gameStatus":   response.GameStatus,
-			"winner":       response.Winner,
-			"difficulty":   difficultyStr,
-			"aiEngine":     "enhanced_minimax",
-			"stats":        stats,
+	c.JSON(http.StatusOK, gin.H{
+		"aiMove":       response.AIMove,
+		"gameStatus":   response.GameStatus,
+		"winner":       response.Winner,
+		"difficulty":   difficultyStr,
+		"aiEngine":     aiEngine,
+		"personality":  personality,
+		"pv":           aiMove.PV,
+		"stats":        aiMove.Stats,
+	})
+}
+
+// AnalyzePosition handles POST /api/ai/analyze requests
+// Returns the best candidate moves with their lines and win probabilities, plus the
+// fours and open threes of both sides. Player is the side to move.
+func (ac *AIController) AnalyzePosition(c *gin.Context) {
+	var request model.AnalysisRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if request.MultiPV == 0 {
+		request.MultiPV = 3
+	}
+
+	limits, err := parseSearchLimits(c)
+	if err == nil {
+		limits.Weights, err = parseEvalWeights(c)
+	}
+	if err == nil {
+		err = ac.validateGameRequest(&request.GameRequest)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	limits.Difficulty = parseDifficulty(c.DefaultQuery("difficulty", "medium"))
+
+	position := service.Position{Board: request.Board, LastMove: request.LastMove, ToMove: request.Player}
+	analysis, err := ac.enhancedAIService.Analyze(c.Request.Context(), position, limits, request.MultiPV)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"analysis": analysis,
+	})
+}
+
+// GetHint handles POST /api/ai/hint requests
+// Recommends a move for the side to move with a short explanation. With a gameId the
+// position is taken from that LLM or AI game and the hint is counted on it.
+func (ac *AIController) GetHint(c *gin.Context) {
+	var request model.HintRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	limits, err := parseSearchLimits(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
+		return
+	}
+	limits.Difficulty = parseDifficulty(c.DefaultQuery("difficulty", "medium"))
+
+	var position service.Position
+	hints := 0
+	if request.GameID != "" {
+		position, hints, err = ac.llmService.RecordHint(request.GameID)
+		if errors.Is(err, service.ErrGameNotFound) {
+			position, hints, err = ac.aiGameService.RecordHint(request.GameID)
+		}
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, service.ErrGameNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 	} else {
-		c.JSON(http.StatusOK, response)
+		if err := ac.validateGameRequest(&request.GameRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		position = service.Position{Board: request.Board, LastMove: request.LastMove, ToMove: request.Player}
+	}
+
+	hint, err := ac.enhancedAIService.Hint(c.Request.Context(), position, limits)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response := gin.H{
+		"status": "success",
+		"hint":   hint,
+	}
+	if request.GameID != "" {
+		response["hintsUsed"] = hints
 	}
+	c.JSON(http.StatusOK, response)
 }
 
-// GetGameStatus handles GET /api/game/status requests (reserved for future use)
-func (ac *AIController) GetGameStatus(c *gin.Context) {
-	// This endpoint is reserved for future game state management
+// GetEngines handles GET /api/ai/engines requests
+// Lists the registered engines and the limits each one supports
+func (ac *AIController) GetEngines(c *gin.Context) {
+	engines := []gin.H{}
+	for _, engine := range service.Engines() {
+		engines = append(engines, gin.H{
+			"name":         engine.Name(),
+			"capabilities": engine.Capabilities(),
+		})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "available",
-		"message": "AI service is running",
-		"version": "1.0.0",
+		"status":  "success",
+		"engines": engines,
 	})
 }
 
-// ResetGame handles POST /api/game/reset requests (reserved for future use)
-func (ac *AIController) ResetGame(c *gin.Context) {
-	// This endpoint is reserved for future game session management
-	newBoard := model.NewBoard()
-	
+// GetEvalWeights lists the evaluation weight sets selectable with the weights parameter
+func (ac *AIController) GetEvalWeights(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"weights": service.EvalWeightSets(),
+	})
+}
+
+// GetPersonalities handles GET /api/ai/personalities requests
+// Lists the playing styles selectable with the personality parameter and for AI seats in rooms
+func (ac *AIController) GetPersonalities(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Game reset successfully",
-		"board":   newBoard,
+		"status":        "success",
+		"personalities": service.Personalities(),
 	})
 }
 
+// parseDifficulty converts a difficulty name, defaulting to Medium
+func parseDifficulty(name string) service.Difficulty {
+	switch name {
+	case "easy":
+		return service.Easy
+	case "hard":
+		return service.Hard
+	case "expert":
+		return service.Expert
+	default:
+		return service.Medium
+	}
+}
+
+// parseStrengthLevel returns the strength level with the given number
+func parseStrengthLevel(value string) (service.StrengthLevel, error) {
+	number, err := strconv.Atoi(value)
+	level, exists := service.GetStrengthLevel(number)
+	if err != nil || !exists {
+		return service.StrengthLevel{}, fmt.Errorf("level must be between 1 and %d", len(service.StrengthLevels()))
+	}
+	return level, nil
+}
+
+// parseEvalWeights returns the weight set named by the weights query parameter, or nil
+func parseEvalWeights(c *gin.Context) (*service.EvalWeights, error) {
+	name := c.Query("weights")
+	if name == "" {
+		return nil, nil
+	}
+	weights, exists := service.GetEvalWeights(name)
+	if !exists {
+		return nil, errors.New("Unknown weight set: " + name)
+	}
+	return &weights, nil
+}
+
+// parseSearchLimits reads the depth, nodes and timeMs query parameters.
+// iterations is accepted as an alias for nodes.
+func parseSearchLimits(c *gin.Context) (service.SearchLimits, error) {
+	var limits service.SearchLimits
+	values := map[string]*int64{"depth": new(int64), "nodes": new(int64), "iterations": new(int64), "timeMs": new(int64)}
+	for name, value := range values {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			return limits, errors.New(name + " must be a non-negative integer")
+		}
+		*value = parsed
+	}
+
+	limits.Depth = int(*values["depth"])
+	limits.Nodes = *values["nodes"]
+	if limits.Nodes == 0 {
+		limits.Nodes = *values["iterations"]
+	}
+	limits.Time = time.Duration(*values["timeMs"]) * time.Millisecond
+	return limits, nil
+}
+
 // GetAIStats handles GET /api/ai/stats requests
 // Returns performance statistics of the enhanced AI
 func (ac *AIController) GetAIStats(c *gin.Context) {
@@ -197,8 +433,161 @@ func (ac *AIController) ClearCache(c *gin.Context) {
 	})
 }
 
+// BookEntryRequest identifies an opening book position by the moves leading to it
+type BookEntryRequest struct {
+	Moves  []model.Move `json:"moves"`  // Moves played so far, alternately from black
+	Move   *model.Move  `json:"move"`   // Candidate move (optional when deleting a whole position)
+	Weight int          `json:"weight"` // Weight to add (default: 1)
+}
+
+// GetOpeningBook handles GET /api/ai/book requests
+// Lists book positions in canonical orientation, fewest stones first
+func (ac *AIController) GetOpeningBook(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be a non-negative integer",
+		})
+		return
+	}
+
+	book := ac.enhancedAIService.OpeningBook()
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"positions": book.Len(),
+		"entries":   book.Positions(limit),
+	})
+}
+
+// LookupOpeningBook handles POST /api/ai/book/lookup requests
+// Returns the book moves for a board, in the board's own orientation
+func (ac *AIController) LookupOpeningBook(c *gin.Context) {
+	var request model.GameRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	moves := ac.enhancedAIService.OpeningBook().Lookup(request.Board)
+	if moves == nil {
+		moves = []service.BookMove{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"moves":  moves,
+	})
+}
+
+// AddOpeningBookMove handles POST /api/ai/book requests
+// Adds weight to a candidate move for the position reached by the given moves
+func (ac *AIController) AddOpeningBookMove(c *gin.Context) {
+	var request BookEntryRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Move == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Request must contain moves and move",
+		})
+		return
+	}
+	if request.Weight == 0 {
+		request.Weight = 1
+	}
+
+	book := ac.enhancedAIService.OpeningBook()
+	board, err := book.BoardFromMoves(request.Moves)
+	if err == nil {
+		err = book.Add(board, *request.Move, request.Weight)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"moves":  book.Lookup(board),
+	})
+}
+
+// DeleteOpeningBookMove handles DELETE /api/ai/book requests
+// Removes a candidate move, or the whole position when no move is given
+func (ac *AIController) DeleteOpeningBookMove(c *gin.Context) {
+	var request BookEntryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	book := ac.enhancedAIService.OpeningBook()
+	board, err := book.BoardFromMoves(request.Moves)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	move := model.Move{X: -1, Y: -1}
+	if request.Move != nil {
+		move = *request.Move
+	}
+	if !book.Remove(board, move) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Position or move not found in opening book",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Opening book entry removed",
+	})
+}
+
+// ImportOpeningBook handles POST /api/ai/book/import requests
+// The request body is the raw file; format is gomocup (default) or renlib, and
+// replace=true clears the book first
+func (ac *AIController) ImportOpeningBook(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Request body must contain the opening file",
+		})
+		return
+	}
+
+	book := ac.enhancedAIService.OpeningBook()
+	if c.Query("replace") == "true" {
+		book.Clear()
+	}
+
+	lines, err := book.Load(bytes.NewReader(data), c.DefaultQuery("format", service.BookFormatGomocup))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to import opening book",
+			"details": err.Error(),
+			"lines":   lines,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"lines":     lines,
+		"positions": book.Len(),
+	})
+}
+
 // GetDifficultyLevels handles GET /api/ai/difficulties requests
-// Returns available difficulty levels
+// Returns available difficulty levels and the calibrated human-like strength levels
 func (ac *AIController) GetDifficultyLevels(c *gin.Context) {
 	difficulties := []map[string]interface{}{
 		{
@@ -227,9 +616,19 @@ func (ac *AIController) GetDifficultyLevels(c *gin.Context) {
 		},
 	}
 
+	// Report which evaluation each level searches with
+	for _, difficulty := range difficulties {
+		difficulty["evaluation"] = "pattern"
+		if ac.enhancedAIService.NeuralNetwork(parseDifficulty(difficulty["level"].(string))) != nil {
+			difficulty["evaluation"] = "neural"
+		}
+	}
+
+	// Human-like levels from weakest to strongest, selected with level=<n> on /api/ai/move
 	c.JSON(http.StatusOK, gin.H{
 		"status":       "success",
 		"difficulties": difficulties,
+		"levels":       service.StrengthLevels(),
 	})
 }
 
@@ -257,19 +656,7 @@ func (ac *AIController) BenchmarkAI(c *gin.Context) {
 		req.MoveCount = 10
 	}
 
-	var difficulty service.Difficulty
-	switch req.Difficulty {
-	case "easy":
-		difficulty = service.Easy
-	case "medium":
-		difficulty = service.Medium
-	case "hard":
-		difficulty = service.Hard
-	case "expert":
-		difficulty = service.Expert
-	default:
-		difficulty = service.Medium
-	}
+	difficulty := parseDifficulty(req.Difficulty)
 
 	// Create test board
 	board := make([][]int, 15)
@@ -289,10 +676,9 @@ func (ac *AIController) BenchmarkAI(c *gin.Context) {
 
 	for i := 0; i < req.MoveCount; i++ {
 		aiMove := ac.enhancedAIService.GetAIMove(board, lastMove, difficulty)
-		stats := ac.enhancedAIService.GetStats()
 
-		totalNodes += stats["nodes_searched"].(uint64)
-		totalCutoffs += stats["cutoffs"].(uint64)
+		totalNodes += aiMove.Stats["nodes_searched"].(uint64)
+		totalCutoffs += aiMove.Stats["cutoffs"].(uint64)
 
 		// Apply move to continue game
 		board[aiMove.Y][aiMove.X] = 2
@@ -332,18 +718,45 @@ func (ac *AIController) countMoves(board [][]int) int {
 	return count
 }
 
-// validateBoardState performs additional validation on the board state
-func (ac *AIController) validateBoardState(board [][]int) error {
-	playerCount := 0
-	aiCount := 0
+// validateGameRequest checks the board, the side to move and the last move. The last move
+// is cleared on an empty board, where there is none.
+func (ac *AIController) validateGameRequest(request *model.GameRequest) error {
+	// Validate board dimensions
+	if len(request.Board) != 15 {
+		return errors.New("Board must be 15x15")
+	}
+	for i, row := range request.Board {
+		if len(row) != 15 {
+			return errors.New("Board row " + strconv.Itoa(i+1) + " must have 15 columns")
+		}
+	}
+
+	// Validate player (the side to move)
+	if request.Player != 1 && request.Player != 2 {
+		return errors.New("Player must be 1 (black) or 2 (white)")
+	}
+
+	if ac.countMoves(request.Board) == 0 {
+		request.LastMove = model.Move{X: -1, Y: -1}
+	} else if request.LastMove.X < 0 || request.LastMove.X >= 15 ||
+		request.LastMove.Y < 0 || request.LastMove.Y >= 15 {
+		return errors.New("Last move coordinates must be within 0-14 range")
+	}
+
+	// Additional board state validation
+	return ac.validateBoardState(request.Board, request.Player)
+}
+
+// validateBoardState performs additional validation on the board state.
+// Either colour may have opened, but the side to move must not be ahead on stones.
+func (ac *AIController) validateBoardState(board [][]int, toMove int) error {
+	counts := [3]int{}
 
 	for _, row := range board {
 		for _, cell := range row {
 			switch cell {
-			case 1:
-				playerCount++
-			case 2:
-				aiCount++
+			case 1, 2:
+				counts[cell]++
 			case 0:
 				// Empty cell, valid
 			default:
@@ -352,8 +765,9 @@ func (ac *AIController) validateBoardState(board [][]int) error {
 		}
 	}
 
-	// Check if move counts are reasonable (AI should not have more pieces than player + 1)
-	if aiCount > playerCount || playerCount > aiCount+1 {
+	// Check if move counts are reasonable (the side to move has as many pieces as the opponent, or one fewer)
+	moverCount, opponentCount := counts[toMove], counts[3-toMove]
+	if moverCount > opponentCount || opponentCount > moverCount+1 {
 		return errors.New("Invalid board state: unrealistic piece distribution")
 	}
 
@@ -0,0 +1,230 @@
+// Package controller handles HTTP requests and responses for the Gomoku API
+// This file contains the endpoints of AI game sessions kept on the server
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+// AIGameController handles AI game session requests
+type AIGameController struct {
+	aiGameService *service.AIGameService
+}
+
+// NewAIGameController creates a new AI game controller instance
+func NewAIGameController(aiGameService *service.AIGameService) *AIGameController {
+	return &AIGameController{
+		aiGameService: aiGameService,
+	}
+}
+
+// CreateGame handles POST /api/ai/games requests.
+// The engine (minimax by default), personality and difficulty are kept on the game. When the
+// engine plays black its first move is returned along with the game.
+func (gc *AIGameController) CreateGame(c *gin.Context) {
+	var request model.AIGameRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if request.AIPlayer == 0 {
+		request.AIPlayer = 2
+	}
+
+	turn, err := gc.aiGameService.CreateGame(request.Engine, request.Personality, parseDifficulty(request.Difficulty), request.AIPlayer)
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	gc.respond(c, turn)
+}
+
+// MakeMove handles POST /api/ai/games/:id/move requests.
+// Plays the human's move and returns the engine's reply; ponderHit tells whether the reply
+// was found while the human was thinking.
+func (gc *AIGameController) MakeMove(c *gin.Context) {
+	var move model.Move
+	if err := c.ShouldBindJSON(&move); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	turn, err := gc.aiGameService.MakeMove(c.Param("id"), move)
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	gc.respond(c, turn)
+}
+
+// Undo handles POST /api/ai/games/:id/undo requests.
+// Takes back the human's last move and the engine's reply; a game decided by five in a row
+// is reopened.
+func (gc *AIGameController) Undo(c *gin.Context) {
+	game, err := gc.aiGameService.Undo(c.Param("id"))
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"game":   game,
+	})
+}
+
+// Resign handles POST /api/ai/games/:id/resign requests
+func (gc *AIGameController) Resign(c *gin.Context) {
+	game, err := gc.aiGameService.Resign(c.Param("id"))
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"game":   game,
+	})
+}
+
+// ResetGame handles POST /api/ai/reset requests.
+// Starts the game named by gameId again with the same settings.
+func (gc *AIGameController) ResetGame(c *gin.Context) {
+	var request struct {
+		GameID string `json:"gameId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	turn, err := gc.aiGameService.ResetGame(request.GameID)
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	gc.respond(c, turn)
+}
+
+// GetGameStatus handles GET /api/ai/status requests.
+// With gameId it returns the state of that game, otherwise the state of the AI service.
+func (gc *AIGameController) GetGameStatus(c *gin.Context) {
+	gameID := c.Query("gameId")
+	if gameID == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":      "available",
+			"message":     "AI service is running",
+			"version":     "1.0.0",
+			"activeGames": gc.aiGameService.ActiveGames(),
+		})
+		return
+	}
+
+	game, err := gc.aiGameService.GetGame(gameID)
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"gameId":        game.ID,
+		"gameStatus":    game.Status,
+		"winner":        game.Winner,
+		"endReason":     game.EndReason,
+		"currentPlayer": game.Board.CurrentPlayer,
+		"moveCount":     len(game.Moves),
+		"lastMove":      game.LastMove(),
+	})
+}
+
+// GetGameHistory handles GET /api/ai/games/:id/history requests.
+// limit returns only the most recent moves (default 50).
+func (gc *AIGameController) GetGameHistory(c *gin.Context) {
+	limit := 50
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	game, err := gc.aiGameService.GetGame(c.Param("id"))
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	moves := game.Moves
+	if len(moves) > limit {
+		moves = moves[len(moves)-limit:]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"gameId":     game.ID,
+		"engine":     game.Engine,
+		"difficulty": game.Difficulty,
+		"moves":      moves,
+		"total":      len(game.Moves),
+		"gameStatus": game.Status,
+	})
+}
+
+// GetGame handles GET /api/ai/games/:id requests
+func (gc *AIGameController) GetGame(c *gin.Context) {
+	game, err := gc.aiGameService.GetGame(c.Param("id"))
+	if err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"game":   game,
+	})
+}
+
+// DeleteGame handles DELETE /api/ai/games/:id requests.
+// Stops the engine's pondering and frees the session.
+func (gc *AIGameController) DeleteGame(c *gin.Context) {
+	if err := gc.aiGameService.DeleteGame(c.Param("id")); err != nil {
+		gc.gameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Game deleted",
+	})
+}
+
+// respond returns the game with the engine's reply
+func (gc *AIGameController) respond(c *gin.Context, turn service.AIGameTurn) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"game":      turn.Game,
+		"aiMove":    turn.AIMove,
+		"ponderHit": turn.PonderHit,
+	})
+}
+
+// gameError maps AI game errors to status codes
+func (gc *AIGameController) gameError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTooManyAIGames):
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
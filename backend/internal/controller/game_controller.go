@@ -24,9 +24,8 @@ type GameController struct {
 	hub         *service.Hub
 }
 
-// NewGameController creates a new game controller instance
-func NewGameController() *GameController {
-	gameService := service.NewGameService()
+// NewGameController creates a new game controller instance on the shared game service
+func NewGameController(gameService *service.GameService) *GameController {
 	hub := service.NewHub(gameService)
 	go hub.Run()
 	
@@ -106,6 +105,48 @@ func (gc *GameController) JoinRoom(c *gin.Context) {
 	}()
 }
 
+// AddAIPlayer handles POST /api/rooms/:id/ai requests
+// Seats an AI that plays in the requested personality's style
+func (gc *GameController) AddAIPlayer(c *gin.Context) {
+	roomID := c.Param("id")
+	var request model.AddAIPlayerRequest
+	
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if request.Difficulty == "" {
+		request.Difficulty = "medium"
+	}
+	
+	room, player, err := gc.gameService.AddAIPlayer(roomID, request.Personality, parseDifficulty(request.Difficulty))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to add AI player",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"room":   room,
+		"player": player,
+	})
+	
+	// Broadcast room update to all clients in the room (async to avoid deadlock)
+	go func() {
+		gc.hub.BroadcastToRoom(roomID, model.WSMessage{
+			Type: "room_update",
+			Data: model.RoomUpdateData{
+				Room: room,
+			},
+		})
+	}()
+}
+
 // GetRoom handles GET /api/rooms/:id requests
 func (gc *GameController) GetRoom(c *gin.Context) {
 	roomID := c.Param("id")
@@ -151,6 +192,7 @@ func (gc *GameController) StartGame(c *gin.Context) {
 			Game: room.Game,
 		},
 	})
+	go gc.hub.PlayAIMoves(roomID)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"room": room,
@@ -253,6 +295,7 @@ func (gc *GameController) MakeMove(c *gin.Context) {
 			LastMove: move,
 		},
 	})
+	go gc.hub.PlayAIMoves(roomID)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"room": room,
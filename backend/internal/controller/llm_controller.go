@@ -3,14 +3,20 @@
 package controller
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gomoku-backend/internal/model"
 	"gomoku-backend/internal/service"
 )
 
+// streamKeepalive is how often an idle event stream sends a comment, so proxies keep it open
+const streamKeepalive = 15 * time.Second
+
 // LLMController handles LLM game related HTTP requests
 type LLMController struct {
 	llmService *service.LLMService
@@ -78,7 +84,17 @@ func (c *LLMController) MakeMove(ctx *gin.Context) {
 
 	response, err := c.llmService.MakeMove(request.GameID, request.Move)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+		// The provider turning the request away is not the client's fault
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrLLMRateLimited):
+			status = http.StatusTooManyRequests
+		case errors.Is(err, service.ErrLLMOverloaded):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, service.ErrLLMThinking):
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{
 			"error":   "Failed to make move",
 			"details": err.Error(),
 		})
@@ -118,6 +134,53 @@ func (c *LLMController) GetGame(ctx *gin.Context) {
 	})
 }
 
+// StreamGame handles GET /api/llm/game/:id/stream
+// It sends the model's answer as server-sent events while it is written, ending with the
+// move or the error of the turn.
+func (c *LLMController) StreamGame(ctx *gin.Context) {
+	gameID := ctx.Param("id")
+	if gameID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Game ID is required",
+		})
+		return
+	}
+
+	events, cancel, err := c.llmService.Subscribe(gameID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Game not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer cancel()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the events
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, open := <-events:
+			if !open {
+				return false // The game was deleted or the client fell too far behind
+			}
+			ctx.SSEvent(event.Type, event)
+			return event.Type != service.StreamMove && event.Type != service.StreamError
+		case <-keepalive.C:
+			io.WriteString(w, ": keepalive\n\n")
+			return true
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
+
 // GetModels handles GET /api/llm/models
 func (c *LLMController) GetModels(ctx *gin.Context) {
 	models := c.llmService.GetAvailableModels()
@@ -279,6 +342,8 @@ func (c *LLMController) GetGameStats(ctx *gin.Context) {
 			"ai_wins":       0,
 			"draws":         0,
 			"popular_model": "deepseek",
+			"usage":         c.llmService.GetUsage(),
+			"moveStats":     c.llmService.GetMoveStats(),
 		},
 		"message": "Statistics retrieved successfully",
 	})
@@ -0,0 +1,112 @@
+// Package controller handles HTTP requests and responses for the Gomoku API
+// This file contains the "find the win" puzzle endpoints
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+// PuzzleController handles puzzle requests
+type PuzzleController struct {
+	puzzleService *service.PuzzleService
+}
+
+// NewPuzzleController creates a new puzzle controller instance
+func NewPuzzleController(puzzleService *service.PuzzleService) *PuzzleController {
+	return &PuzzleController{
+		puzzleService: puzzleService,
+	}
+}
+
+// GetNextPuzzle handles GET /api/puzzles/next requests.
+// Puzzles come in order of rating; after is the ID of the last puzzle seen. kind (vcf or
+// vct), minLength and maxLength narrow the choice.
+func (pc *PuzzleController) GetNextPuzzle(c *gin.Context) {
+	filter := service.PuzzleFilter{Kind: c.Query("kind")}
+	if filter.Kind != "" && filter.Kind != service.ThreatVCF && filter.Kind != service.ThreatVCT {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "kind must be vcf or vct",
+		})
+		return
+	}
+	for name, value := range map[string]*int{"minLength": &filter.MinLength, "maxLength": &filter.MaxLength} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be a non-negative integer",
+			})
+			return
+		}
+		*value = parsed
+	}
+
+	puzzle, err := pc.puzzleService.Next(filter, c.Query("after"))
+	if err != nil {
+		pc.puzzleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"puzzle": puzzle,
+		"total":  pc.puzzleService.Count(),
+	})
+}
+
+// GetPuzzle handles GET /api/puzzles/:id requests
+func (pc *PuzzleController) GetPuzzle(c *gin.Context) {
+	puzzle, err := pc.puzzleService.GetPuzzle(c.Param("id"))
+	if err != nil {
+		pc.puzzleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"puzzle": puzzle,
+	})
+}
+
+// AttemptPuzzle handles POST /api/puzzles/:id/attempt requests.
+// The moves are checked one by one, each answered by the engine's most stubborn defence;
+// the response carries the engine's reply to the last move while the puzzle goes on.
+func (pc *PuzzleController) AttemptPuzzle(c *gin.Context) {
+	var request model.PuzzleAttemptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	attempt, err := pc.puzzleService.Attempt(c.Param("id"), request.Moves)
+	if err != nil {
+		pc.puzzleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"attempt": attempt,
+	})
+}
+
+// puzzleError maps puzzle errors to status codes
+func (pc *PuzzleController) puzzleError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrPuzzleNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,86 @@
+// Package controller handles HTTP requests and responses for the Gomoku API
+// This file contains the post-game analysis endpoints
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gomoku-backend/internal/model"
+	"gomoku-backend/internal/service"
+)
+
+// ReviewController handles post-game analysis requests
+type ReviewController struct {
+	reviewService *service.GameReviewService
+}
+
+// NewReviewController creates a new review controller instance
+func NewReviewController(reviewService *service.GameReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// GetGameAnalysis handles GET /api/games/:id/analysis requests.
+// The first request for a finished game queues its analysis; until it is done the response
+// is 202 Accepted with the progress so far.
+func (rc *ReviewController) GetGameAnalysis(c *gin.Context) {
+	review, err := rc.reviewService.Review(c.Param("id"))
+	if err != nil {
+		rc.reviewError(c, err)
+		return
+	}
+	rc.respond(c, review)
+}
+
+// SubmitGame handles POST /api/games/analysis requests.
+// Games the server did not record, such as AI games played in the browser, are submitted
+// here; the returned game ID is then polled on GET /api/games/:id/analysis.
+func (rc *ReviewController) SubmitGame(c *gin.Context) {
+	var request model.ReviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	review, err := rc.reviewService.Submit(service.ReviewedGame{Mode: "ai", Moves: request.Moves, Winner: request.Winner})
+	if err != nil {
+		rc.reviewError(c, err)
+		return
+	}
+	rc.respond(c, review)
+}
+
+// respond returns a finished review with 200 and a pending one with 202
+func (rc *ReviewController) respond(c *gin.Context, review service.GameReview) {
+	status := http.StatusOK
+	if review.Status == service.ReviewQueued || review.Status == service.ReviewRunning {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, gin.H{
+		"status":   "success",
+		"analysis": review,
+	})
+}
+
+// reviewError maps review errors to status codes
+func (rc *ReviewController) reviewError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrGameNotFinished):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrReviewQueueFull):
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
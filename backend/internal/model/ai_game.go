@@ -0,0 +1,84 @@
+// Package model defines the core data structures for AI game sessions
+// This file contains the server-side state of a game against an engine
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AIGame represents a game session against an engine kept on the server
+type AIGame struct {
+	ID           string       `json:"id"`                    // Unique game identifier
+	Engine       string       `json:"engine"`                // Registered engine name
+	Personality  string       `json:"personality,omitempty"` // Playing style of the minimax engine
+	Difficulty   string       `json:"difficulty"`            // easy, medium, hard or expert
+	AIPlayer     int          `json:"aiPlayer"`              // Side the engine plays: 1=black, 2=white
+	Status       string       `json:"status"`                // playing, human_win, ai_win or draw
+	Winner       int          `json:"winner"`                // Winning side: 0=none, 1=black, 2=white
+	EndReason    string       `json:"endReason,omitempty"`   // five, resign or board_full
+	Board        *Board       `json:"board"`                 // Current board state
+	Moves        []AIGameMove `json:"moves"`                 // Move history, black first
+	Undos        int          `json:"undos"`                 // Times the human took moves back
+	Hints        int          `json:"hints"`                 // Hints the human has asked for
+	PonderMove   *Move        `json:"ponderMove,omitempty"`  // Human reply the engine is thinking about
+	PonderHits   int          `json:"ponderHits"`            // Human moves the engine had predicted
+	PonderMisses int          `json:"ponderMisses"`          // Human moves it had not
+	CreatedAt    time.Time    `json:"createdAt"`             // Game creation timestamp
+	UpdatedAt    time.Time    `json:"updatedAt"`             // Last update timestamp
+	EndedAt      *time.Time   `json:"endedAt,omitempty"`     // Time the game was decided
+}
+
+// AIGameMove represents a move in an AI game; engine moves carry the search result
+type AIGameMove struct {
+	Move
+	Number    int       `json:"number"`              // 1-based move number
+	Score     int       `json:"score,omitempty"`     // Engine's evaluation of its move
+	PV        []Move    `json:"pv,omitempty"`        // Line the engine expected
+	FromBook  bool      `json:"fromBook,omitempty"`  // Engine move taken from the opening book
+	PonderHit bool      `json:"ponderHit,omitempty"` // Engine move found while the human was thinking
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AIGameRequest represents request to start an AI game
+type AIGameRequest struct {
+	Engine      string `json:"engine"`      // Registered engine name; minimax by default
+	Personality string `json:"personality"` // Playing style, minimax only
+	Difficulty  string `json:"difficulty"`  // easy, medium, hard or expert; medium by default
+	AIPlayer    int    `json:"aiPlayer"`    // Side the engine plays; white by default
+}
+
+// NewAIGame creates a new AI game; black moves first
+func NewAIGame(engine, personality, difficulty string, aiPlayer int) *AIGame {
+	return &AIGame{
+		ID:          "ai_" + uuid.New().String(),
+		Engine:      engine,
+		Personality: personality,
+		Difficulty:  difficulty,
+		AIPlayer:    aiPlayer,
+		Status:      "playing",
+		Board:       NewBoard(),
+		Moves:       make([]AIGameMove, 0),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// LastMove returns the last move played, or {-1, -1} before the first move
+func (g *AIGame) LastMove() Move {
+	if len(g.Moves) == 0 {
+		return Move{X: -1, Y: -1}
+	}
+	return g.Moves[len(g.Moves)-1].Move
+}
+
+// Restart clears the board and history, keeping the game's settings
+func (g *AIGame) Restart() {
+	g.Status, g.Winner, g.EndReason, g.EndedAt = "playing", 0, "", nil
+	g.Board = NewBoard()
+	g.Moves = make([]AIGameMove, 0)
+	g.Undos, g.Hints = 0, 0
+	g.PonderMove = nil
+	g.UpdatedAt = time.Now()
+}
@@ -36,9 +36,15 @@ type GameState struct {
 
 // AIMove represents an AI's move decision
 type AIMove struct {
-	X     int `json:"x"`     // X coordinate
-	Y     int `json:"y"`     // Y coordinate
-	Score int `json:"score"` // Move evaluation score
+	X        int    `json:"x"`                  // X coordinate
+	Y        int    `json:"y"`                  // Y coordinate
+	Score    int    `json:"score"`              // Move evaluation score
+	PV       []Move `json:"pv,omitempty"`       // Expected line starting with this move
+	FromBook bool   `json:"fromBook,omitempty"` // Move was taken from the opening book
+
+	// Stats describes the search that chose this move; engines sharing one instance
+	// across requests report it here rather than through the last-search statistics
+	Stats map[string]interface{} `json:"-"`
 }
 
 // GameRequest represents the request payload for AI move
@@ -48,6 +54,31 @@ type GameRequest struct {
 	LastMove Move    `json:"lastMove"` // Last move made
 }
 
+// AnalysisRequest represents the request payload for position analysis
+type AnalysisRequest struct {
+	GameRequest
+	MultiPV int `json:"multiPV"` // Number of candidate moves to return (default: 3)
+}
+
+// HintRequest represents the request payload for a hint. With a game ID the position
+// is taken from that game instead of the board.
+type HintRequest struct {
+	GameRequest
+	GameID string `json:"gameId"` // LLM game the hint is counted on
+}
+
+// ReviewRequest represents a finished game submitted for analysis, such as an AI game
+// played in the browser
+type ReviewRequest struct {
+	Moves  []Move `json:"moves" binding:"required"` // Black first, alternating
+	Winner int    `json:"winner"`                   // 1 or 2, 0 for a draw
+}
+
+// PuzzleAttemptRequest represents a player's moves in a puzzle, without the engine's replies
+type PuzzleAttemptRequest struct {
+	Moves []Move `json:"moves" binding:"required"` // The solving side's moves so far
+}
+
 // GameResponse represents the response from AI move endpoint
 type GameResponse struct {
 	AIMove     AIMove `json:"aiMove"`     // AI's chosen move
@@ -9,12 +9,14 @@ import (
 // LLMGame represents a game session with LLM
 type LLMGame struct {
 	ID            string    `json:"id"`            // Unique game identifier
-	ModelName     string    `json:"modelName"`     // LLM model name (deepseek, chatgpt, ollama)
+	ModelName     string    `json:"modelName"`     // LLM model name (deepseek, chatgpt, claude, ollama)
 	Difficulty    string    `json:"difficulty"`    // Difficulty level: easy, medium, hard
 	Status        string    `json:"status"`        // Game status: playing, finished, error
 	CurrentPlayer int       `json:"currentPlayer"` // Current player: 1=human, 2=LLM
 	Board         *Board    `json:"board"`         // Current board state
 	Moves         []LLMMove `json:"moves"`         // Move history
+	Hints         int       `json:"hints"`         // Hints the human has asked for
+	Usage         LLMUsage  `json:"usage"`         // Tokens the provider billed for this game
 	CreatedAt     time.Time `json:"createdAt"`     // Game creation timestamp
 	UpdatedAt     time.Time `json:"updatedAt"`     // Last update timestamp
 }
@@ -28,9 +30,57 @@ type LLMMove struct {
 	Player     int       `json:"player"`               // Player who made the move
 	Reasoning  string    `json:"reasoning,omitempty"`  // LLM's reasoning process
 	Confidence float64   `json:"confidence"`           // Move confidence score (0-1)
+	Usage      *LLMUsage `json:"usage,omitempty"`      // Tokens billed for this move, if reported
+	Attempts   int       `json:"attempts,omitempty"`   // Times the model was asked this turn
+	Fallback   bool      `json:"fallback,omitempty"`   // The engine moved after the model never gave a legal move
 	Timestamp  time.Time `json:"timestamp"`            // Move timestamp
 }
 
+// LLMUsage counts the requests made to an LLM provider and the tokens it billed
+type LLMUsage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// Add adds another usage count to this one
+func (u *LLMUsage) Add(other LLMUsage) {
+	u.Requests += other.Requests
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// LLMMoveStats counts how well a model answers when asked for moves
+type LLMMoveStats struct {
+	Moves         int `json:"moves"`         // Turns the model was asked to move
+	Attempts      int `json:"attempts"`      // Requests, re-prompts included
+	IllegalMoves  int `json:"illegalMoves"`  // Answers on an occupied or off-board square
+	ParseFailures int `json:"parseFailures"` // Answers no move could be read from
+	Fallbacks     int `json:"fallbacks"`     // Turns the engine moved instead
+}
+
+// Add adds another model's or turn's counts to these
+func (s *LLMMoveStats) Add(other LLMMoveStats) {
+	s.Moves += other.Moves
+	s.Attempts += other.Attempts
+	s.IllegalMoves += other.IllegalMoves
+	s.ParseFailures += other.ParseFailures
+	s.Fallbacks += other.Fallbacks
+}
+
+// LLMStreamEvent is one server-sent event of a game's live stream: a model turn starting,
+// a piece of the model's text, or the terminal move or error
+type LLMStreamEvent struct {
+	Type       string   `json:"type"`                 // start, token, move or error
+	Attempt    int      `json:"attempt,omitempty"`    // Request number within the turn (start)
+	Text       string   `json:"text,omitempty"`       // Text the model wrote (token)
+	Move       *LLMMove `json:"move,omitempty"`       // The move played (move)
+	GameStatus string   `json:"gameStatus,omitempty"` // Game status after the turn (move)
+	Error      string   `json:"error,omitempty"`      // Why the turn failed (error)
+}
+
 // LLMConfig represents LLM model configuration
 type LLMConfig struct {
 	ModelName  string                 `json:"modelName"`            // Model identifier
@@ -44,7 +94,7 @@ type LLMConfig struct {
 type LLMModel struct {
 	Name           string                 `json:"name"`           // Model identifier
 	DisplayName    string                 `json:"displayName"`    // Human-readable name
-	Provider       string                 `json:"provider"`       // Provider name (deepseek, openai, ollama)
+	Provider       string                 `json:"provider"`       // Provider name (deepseek, openai, anthropic, ollama)
 	RequiresAPIKey bool                   `json:"requiresApiKey"` // Whether API key is required
 	DefaultParams  map[string]interface{} `json:"defaultParams"`  // Default parameters
 	Status         string                 `json:"status"`         // Model status: available, unavailable, error
@@ -28,6 +28,8 @@ type PVPPlayer struct {
 	IsOnline     bool      `json:"isOnline"`
 	JoinedAt     time.Time `json:"joinedAt"`
 	IsCreator    bool      `json:"isCreator"`
+	IsAI         bool      `json:"isAI"`
+	Personality  string    `json:"personality,omitempty"` // Playing style of an AI seat
 }
 
 // PVPGame represents a PVP game instance
@@ -88,6 +90,12 @@ type CreateRoomRequest struct {
 	MaxPlayers int    `json:"maxPlayers"`
 }
 
+// AddAIPlayerRequest represents request to seat an AI in a room
+type AddAIPlayerRequest struct {
+	Personality string `json:"personality" binding:"required"`
+	Difficulty  string `json:"difficulty"` // easy, medium, hard or expert; medium by default
+}
+
 // JoinRoomRequest represents request to join a room
 type JoinRoomRequest struct {
 	PlayerName string `json:"playerName" binding:"required"`
@@ -205,6 +213,29 @@ func (r *Room) RemovePlayer(playerID string) bool {
 	return false
 }
 
+// AddAIPlayer seats an AI playing in the given style; it is always ready
+func (r *Room) AddAIPlayer(name, personality string) *PVPPlayer {
+	player := r.AddPlayer(name)
+	if player == nil {
+		return nil
+	}
+	
+	player.IsAI = true
+	player.IsReady = true
+	player.Personality = personality
+	return player
+}
+
+// HasHumanPlayers checks if anyone other than AI seats is in the room
+func (r *Room) HasHumanPlayers() bool {
+	for _, player := range r.Players {
+		if !player.IsAI {
+			return true
+		}
+	}
+	return false
+}
+
 // GetPlayer gets a player by ID
 func (r *Room) GetPlayer(playerID string) *PVPPlayer {
 	for _, player := range r.Players {
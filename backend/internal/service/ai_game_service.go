@@ -0,0 +1,525 @@
+// Package service contains server-side AI game sessions
+// This file keeps games against an engine on the server, so every move is checked against
+// the game so far and the history supports undo. Against the minimax engine the session
+// has an engine of its own that, after each reply, ponders the move it expects from the
+// human: a correct prediction is answered from the finished search and a wrong one costs
+// only the cancelled work.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+const (
+	maxAIGames        = 64               // Sessions kept at once
+	aiGameIdleTimeout = 30 * time.Minute // Sessions untouched this long are closed
+	aiGameTableBytes  = 4 << 20          // Transposition table memory per session
+)
+
+// ErrTooManyAIGames is returned when every session slot is taken
+var ErrTooManyAIGames = errors.New("too many AI games in progress")
+
+// AIGameTurn is the outcome of a move in an AI game
+type AIGameTurn struct {
+	Game      model.AIGame  `json:"game"`
+	AIMove    *model.AIMove `json:"aiMove,omitempty"` // Engine's reply; nil when the human's move ended the game
+	PonderHit bool          `json:"ponderHit"`        // The reply was found while the human was thinking
+}
+
+// aiGameSession is one game with its engine. A minimax session has an engine of its own,
+// so the transposition table carries over from move to move; other engines are shared.
+type aiGameSession struct {
+	mutex    sync.Mutex // Serialises moves
+	game     *model.AIGame
+	engine   Engine             // Released when the game ends
+	ai       *EnhancedAIService // The session's own minimax engine, which ponders; nil otherwise
+	limits   SearchLimits
+	ponder   *ponderSearch
+	lastUsed time.Time
+}
+
+// ponderSearch searches the engine's reply to the predicted human move in the background
+type ponderSearch struct {
+	move   model.Move // Predicted human move
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when the search has returned
+	result model.AIMove
+	err    error
+}
+
+// AIGameService manages AI game sessions
+type AIGameService struct {
+	games map[string]*aiGameSession
+	mutex sync.Mutex
+	// ponderSlots bounds how many sessions ponder at once; a session that finds no free
+	// slot simply does not ponder
+	ponderSlots chan struct{}
+}
+
+// NewAIGameService creates a new AI game service instance
+func NewAIGameService() *AIGameService {
+	return &AIGameService{
+		games:       make(map[string]*aiGameSession),
+		ponderSlots: make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+// CreateGame starts a game with the named engine playing aiPlayer; personality is optional
+// and selects a minimax playing style. When the engine plays black its first move is
+// already on the board.
+func (s *AIGameService) CreateGame(engineName, personality string, difficulty Difficulty, aiPlayer int) (AIGameTurn, error) {
+	if aiPlayer != 1 && aiPlayer != 2 {
+		return AIGameTurn{}, errors.New("aiPlayer must be 1 or 2")
+	}
+	if engineName == "" {
+		engineName = "minimax"
+	}
+	session := &aiGameSession{
+		game:     model.NewAIGame(engineName, personality, difficulty.String(), aiPlayer),
+		limits:   SearchLimits{Difficulty: difficulty},
+		lastUsed: time.Now(),
+	}
+	if err := s.open(session); err != nil {
+		return AIGameTurn{}, err
+	}
+
+	s.mutex.Lock()
+	s.closeIdle()
+	if len(s.games) >= maxAIGames {
+		s.mutex.Unlock()
+		return AIGameTurn{}, ErrTooManyAIGames
+	}
+	s.games[session.game.ID] = session
+	session.mutex.Lock()
+	s.mutex.Unlock()
+	defer session.mutex.Unlock()
+
+	return s.start(session)
+}
+
+// MakeMove plays the human's move and the engine's reply
+func (s *AIGameService) MakeMove(gameID string, move model.Move) (AIGameTurn, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return AIGameTurn{}, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	game := session.game
+	human := 3 - game.AIPlayer
+	if game.Status != "playing" {
+		return AIGameTurn{}, errors.New("game is not in playing state")
+	}
+	if game.Board.CurrentPlayer != human {
+		return AIGameTurn{}, errors.New("not your turn")
+	}
+	if !game.Board.IsValidMove(move.X, move.Y) {
+		return AIGameTurn{}, errors.New("invalid move")
+	}
+
+	// Take over the ponder search before the board changes
+	ponder := session.ponder
+	session.ponder, game.PonderMove = nil, nil
+	hit := ponder != nil && ponder.move.X == move.X && ponder.move.Y == move.Y
+	if ponder != nil && !hit {
+		ponder.stop()
+		game.PonderMisses++
+	}
+
+	move.Player = human
+	s.play(session, model.AIGameMove{Move: move})
+	if game.Status != "playing" {
+		if hit {
+			ponder.stop()
+		}
+		s.release(session)
+		return AIGameTurn{Game: copyAIGame(game)}, nil
+	}
+
+	turn := AIGameTurn{}
+	if hit {
+		// The ponder search has been answering exactly this position
+		<-ponder.done
+		game.PonderHits++
+		turn.PonderHit = ponder.err == nil
+	}
+	var found *model.AIMove
+	if turn.PonderHit {
+		found = &ponder.result
+	}
+	aiMove, err := s.reply(session, found)
+	if err != nil {
+		return AIGameTurn{}, err
+	}
+	turn.AIMove = &aiMove
+	turn.Game = copyAIGame(game)
+	return turn, nil
+}
+
+// Undo takes back the human's last move together with the engine's reply, reopening a game
+// decided by five in a row or a full board. A resigned game cannot be taken back.
+func (s *AIGameService) Undo(gameID string) (model.AIGame, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return model.AIGame{}, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	game := session.game
+	if game.EndReason == "resign" {
+		return model.AIGame{}, errors.New("cannot undo a resigned game")
+	}
+	human := 3 - game.AIPlayer
+	last := len(game.Moves) - 1
+	for last >= 0 && game.Moves[last].Player != human {
+		last--
+	}
+	if last < 0 {
+		return model.AIGame{}, errors.New("no move to undo")
+	}
+	if game.Status != "playing" {
+		if err := s.open(session); err != nil {
+			return model.AIGame{}, err
+		}
+	}
+	s.stopPonder(session)
+
+	game.Moves = game.Moves[:last]
+	game.Board = model.NewBoard()
+	for _, move := range game.Moves {
+		game.Board.MakeMove(move.X, move.Y, move.Player)
+	}
+	game.Status, game.Winner, game.EndReason, game.EndedAt = "playing", 0, "", nil
+	game.Undos++
+	game.UpdatedAt = time.Now()
+	return copyAIGame(game), nil
+}
+
+// Resign ends the game as a win for the engine
+func (s *AIGameService) Resign(gameID string) (model.AIGame, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return model.AIGame{}, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	game := session.game
+	if game.Status != "playing" {
+		return model.AIGame{}, errors.New("game is not in playing state")
+	}
+	s.finish(game, game.AIPlayer, "resign")
+	s.release(session)
+	return copyAIGame(game), nil
+}
+
+// ResetGame starts the game again with the same engine, difficulty and sides
+func (s *AIGameService) ResetGame(gameID string) (AIGameTurn, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return AIGameTurn{}, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	s.stopPonder(session)
+	if session.engine == nil {
+		if err := s.open(session); err != nil {
+			return AIGameTurn{}, err
+		}
+	}
+	session.game.Restart()
+	return s.start(session)
+}
+
+// RecordHint counts a hint for the human in a game in progress and returns the position to
+// give the hint for, along with the number of hints used so far
+func (s *AIGameService) RecordHint(gameID string) (Position, int, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return Position{}, 0, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	game := session.game
+	if game.Status != "playing" {
+		return Position{}, 0, errors.New("game is not in playing state")
+	}
+
+	position := Position{Board: copyBoard(game.Board.Grid), LastMove: game.LastMove(), ToMove: game.Board.CurrentPlayer}
+	game.Hints++
+	game.UpdatedAt = time.Now()
+	return position, game.Hints, nil
+}
+
+// ActiveGames returns the number of sessions kept
+func (s *AIGameService) ActiveGames() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.games)
+}
+
+// GetGame returns a copy of the game
+func (s *AIGameService) GetGame(gameID string) (model.AIGame, error) {
+	session, err := s.session(gameID)
+	if err != nil {
+		return model.AIGame{}, err
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return copyAIGame(session.game), nil
+}
+
+// DeleteGame stops the game's pondering and forgets it
+func (s *AIGameService) DeleteGame(gameID string) error {
+	s.mutex.Lock()
+	session, exists := s.games[gameID]
+	delete(s.games, gameID)
+	s.mutex.Unlock()
+	if !exists {
+		return ErrGameNotFound
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	s.release(session)
+	return nil
+}
+
+// FinishedGame returns a finished game for review
+func (s *AIGameService) FinishedGame(gameID string) (ReviewedGame, error) {
+	game, err := s.GetGame(gameID)
+	if err != nil {
+		return ReviewedGame{}, err
+	}
+	if game.Status == "playing" {
+		return ReviewedGame{}, ErrGameNotFinished
+	}
+
+	reviewed := ReviewedGame{ID: game.ID, Mode: "ai", Moves: make([]model.Move, len(game.Moves)), Winner: game.Winner}
+	for i, move := range game.Moves {
+		reviewed.Moves[i] = move.Move
+	}
+	return reviewed, nil
+}
+
+// session looks a game up and marks it as used
+func (s *AIGameService) session(gameID string) (*aiGameSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, exists := s.games[gameID]
+	if !exists {
+		return nil, ErrGameNotFound
+	}
+	session.lastUsed = time.Now()
+	return session, nil
+}
+
+// open creates the session's engine: a minimax engine of its own, or the shared registered
+// engine
+func (s *AIGameService) open(session *aiGameSession) error {
+	game := session.game
+	if game.Engine != "minimax" {
+		if game.Personality != "" {
+			return errors.New("personalities are played by the minimax engine")
+		}
+		engine, exists := GetEngine(game.Engine)
+		if !exists {
+			return fmt.Errorf("unknown engine: %s", game.Engine)
+		}
+		session.engine, session.ai = engine, nil
+		return nil
+	}
+
+	ai := NewEnhancedAIService()
+	ai.SetOpeningBook(NewDefaultOpeningBook())
+	if game.Personality != "" {
+		personality, exists := GetPersonality(game.Personality)
+		if !exists {
+			return fmt.Errorf("unknown personality: %s", game.Personality)
+		}
+		var err error
+		if ai, err = NewPersonalityAI(personality); err != nil {
+			return err
+		}
+	}
+	ai.SetTranspositionTableMemory(aiGameTableBytes)
+	session.engine, session.ai = ai, ai
+	return nil
+}
+
+// start plays the engine's first move when it has black. The caller holds the session's mutex.
+func (s *AIGameService) start(session *aiGameSession) (AIGameTurn, error) {
+	turn := AIGameTurn{}
+	if session.game.AIPlayer == 1 {
+		aiMove, err := s.reply(session, nil)
+		if err != nil {
+			return AIGameTurn{}, err
+		}
+		turn.AIMove = &aiMove
+	}
+	turn.Game = copyAIGame(session.game)
+	return turn, nil
+}
+
+// reply plays the engine's move, searching unless the ponder search already found it, and
+// starts pondering the predicted answer. The caller holds the session's mutex.
+func (s *AIGameService) reply(session *aiGameSession, found *model.AIMove) (model.AIMove, error) {
+	game := session.game
+	var aiMove model.AIMove
+	if found != nil {
+		aiMove = *found
+	} else {
+		position := Position{Board: copyBoard(game.Board.Grid), LastMove: game.LastMove(), ToMove: game.AIPlayer}
+		var err error
+		if aiMove, err = session.engine.BestMove(context.Background(), position, session.limits); err != nil {
+			return model.AIMove{}, err
+		}
+	}
+
+	s.play(session, model.AIGameMove{
+		Move:      model.Move{X: aiMove.X, Y: aiMove.Y, Player: game.AIPlayer},
+		Score:     aiMove.Score,
+		PV:        aiMove.PV,
+		FromBook:  aiMove.FromBook,
+		PonderHit: found != nil,
+	})
+	if game.Status != "playing" {
+		s.release(session)
+		return aiMove, nil
+	}
+	if session.ai != nil && len(aiMove.PV) > 1 {
+		s.startPonder(session, aiMove.PV[1])
+	}
+	return aiMove, nil
+}
+
+// play puts a move on the board and updates the result. The caller holds the session's mutex.
+func (s *AIGameService) play(session *aiGameSession, move model.AIGameMove) {
+	game := session.game
+	game.Board.MakeMove(move.X, move.Y, move.Player)
+	move.Number = len(game.Moves) + 1
+	move.Timestamp = time.Now()
+	game.Moves = append(game.Moves, move)
+	game.UpdatedAt = move.Timestamp
+
+	switch state := game.Board.GetGameState(&move.Move); {
+	case state.Winner != 0:
+		s.finish(game, state.Winner, "five")
+	case state.Status == "draw":
+		s.finish(game, 0, "board_full")
+	}
+}
+
+// finish records the result of a decided game
+func (s *AIGameService) finish(game *model.AIGame, winner int, reason string) {
+	switch winner {
+	case 0:
+		game.Status = "draw"
+	case game.AIPlayer:
+		game.Status = "ai_win"
+	default:
+		game.Status = "human_win"
+	}
+	now := time.Now()
+	game.Winner, game.EndReason, game.EndedAt = winner, reason, &now
+	game.UpdatedAt = now
+}
+
+// startPonder searches the engine's reply to the predicted human move in the background, if
+// a ponder slot is free. The caller holds the session's mutex.
+func (s *AIGameService) startPonder(session *aiGameSession, predicted model.Move) {
+	game := session.game
+	if !game.Board.IsValidMove(predicted.X, predicted.Y) {
+		return
+	}
+	select {
+	case s.ponderSlots <- struct{}{}:
+	default:
+		return
+	}
+
+	predicted.Player = 3 - game.AIPlayer
+	board := copyBoard(game.Board.Grid)
+	board[predicted.Y][predicted.X] = predicted.Player
+	position := Position{Board: board, LastMove: predicted, ToMove: game.AIPlayer}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ponder := &ponderSearch{move: predicted, cancel: cancel, done: make(chan struct{})}
+	ai, limits := session.ai, session.limits
+	go func() {
+		defer close(ponder.done)
+		defer func() { <-s.ponderSlots }()
+		ponder.result, ponder.err = ai.BestMove(ctx, position, limits)
+	}()
+	session.ponder = ponder
+	game.PonderMove = &predicted
+}
+
+// stop cancels the ponder search and waits for it to return, so the session's engine is
+// free for the next search
+func (p *ponderSearch) stop() {
+	p.cancel()
+	<-p.done
+}
+
+// stopPonder stops the session's ponder search, if any. The caller holds the session's mutex.
+func (s *AIGameService) stopPonder(session *aiGameSession) {
+	if session.ponder != nil {
+		session.ponder.stop()
+		session.ponder, session.game.PonderMove = nil, nil
+	}
+}
+
+// release stops pondering and frees the engine of a finished or deleted game. The caller
+// holds the session's mutex.
+func (s *AIGameService) release(session *aiGameSession) {
+	s.stopPonder(session)
+	session.engine, session.ai = nil, nil
+}
+
+// closeIdle closes sessions that have not been used for a while; the caller holds the lock
+func (s *AIGameService) closeIdle() {
+	for id, session := range s.games {
+		if time.Since(session.lastUsed) < aiGameIdleTimeout {
+			continue
+		}
+		delete(s.games, id)
+		go func(session *aiGameSession) {
+			session.mutex.Lock()
+			defer session.mutex.Unlock()
+			s.release(session)
+		}(session)
+	}
+}
+
+// copyAIGame copies a game so it can be returned while the session goes on
+func copyAIGame(game *model.AIGame) model.AIGame {
+	result := *game
+	board := *game.Board
+	board.Grid = copyBoard(game.Board.Grid)
+	result.Board = &board
+	result.Moves = append([]model.AIGameMove{}, game.Moves...)
+	if game.PonderMove != nil {
+		predicted := *game.PonderMove
+		result.PonderMove = &predicted
+	}
+	if game.EndedAt != nil {
+		ended := *game.EndedAt
+		result.EndedAt = &ended
+	}
+	return result
+}
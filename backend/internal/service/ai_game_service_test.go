@@ -0,0 +1,196 @@
+// Unit tests for AI game sessions and pondering
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+// newTestAIGame starts a game with a shallow search to keep the tests fast
+func newTestAIGame(t *testing.T, s *AIGameService, aiPlayer int) AIGameTurn {
+	t.Helper()
+	turn, err := s.CreateGame("minimax", "", Medium, aiPlayer)
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	s.games[turn.Game.ID].limits = SearchLimits{Difficulty: Medium, Depth: 2}
+	return turn
+}
+
+func TestAIGamePonderHitAndMiss(t *testing.T) {
+	s := NewAIGameService()
+	game := newTestAIGame(t, s, 2).Game
+
+	// Play away from the engine until it ponders a prediction, then play what it expects
+	var turn AIGameTurn
+	var err error
+	for _, move := range []model.Move{{X: 7, Y: 7}, {X: 2, Y: 2}, {X: 12, Y: 2}} {
+		if turn, err = s.MakeMove(game.ID, move); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+		if turn.Game.PonderMove != nil {
+			break
+		}
+	}
+	predicted := turn.Game.PonderMove
+	if predicted == nil {
+		t.Fatal("Expected the engine to ponder a predicted reply")
+	}
+	if predicted.Player != 1 || turn.Game.Board.Grid[predicted.Y][predicted.X] != 0 {
+		t.Fatalf("Expected a free cell for black, got %+v", *predicted)
+	}
+
+	turn, err = s.MakeMove(game.ID, *predicted)
+	if err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	if !turn.PonderHit || turn.Game.PonderHits != 1 || turn.AIMove == nil {
+		t.Errorf("Expected a ponder hit with a reply, got hit=%v hits=%d", turn.PonderHit, turn.Game.PonderHits)
+	}
+	if last := turn.Game.LastMove(); last.X != turn.AIMove.X || last.Y != turn.AIMove.Y || last.Player != 2 {
+		t.Errorf("Expected the reply on the board, got %+v", last)
+	}
+
+	// Any other move is a miss: the ponder search is stopped and a fresh one replies
+	if turn.Game.PonderMove != nil {
+		miss := model.Move{X: 0, Y: 14}
+		if turn, err = s.MakeMove(game.ID, miss); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+		if turn.PonderHit || turn.Game.PonderMisses != 1 || turn.AIMove == nil {
+			t.Errorf("Expected a ponder miss with a reply, got hit=%v misses=%d", turn.PonderHit, turn.Game.PonderMisses)
+		}
+	}
+
+	// Deleting the game stops pondering and frees the engine
+	session := s.games[game.ID]
+	if err := s.DeleteGame(game.ID); err != nil {
+		t.Fatalf("DeleteGame failed: %v", err)
+	}
+	if session.ponder != nil || session.ai != nil {
+		t.Error("Expected the session's search and engine to be released")
+	}
+	if _, err := s.GetGame(game.ID); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("Expected ErrGameNotFound, got %v", err)
+	}
+	if len(s.ponderSlots) != 0 {
+		t.Errorf("Expected every ponder slot back, %d still taken", len(s.ponderSlots))
+	}
+}
+
+func TestAIGameRules(t *testing.T) {
+	s := NewAIGameService()
+	turn := newTestAIGame(t, s, 1)
+	if turn.AIMove == nil || len(turn.Game.Moves) != 1 || turn.Game.Board.CurrentPlayer != 2 {
+		t.Fatalf("Expected the engine to open as black, got %+v", turn.Game.Moves)
+	}
+
+	id := turn.Game.ID
+	first := turn.Game.Moves[0]
+	if _, err := s.MakeMove(id, model.Move{X: first.X, Y: first.Y}); err == nil {
+		t.Error("Expected a move on an occupied cell to be rejected")
+	}
+	if _, err := s.MakeMove(id, model.Move{X: 15, Y: 0}); err == nil {
+		t.Error("Expected a move off the board to be rejected")
+	}
+	if _, err := s.FinishedGame(id); !errors.Is(err, ErrGameNotFinished) {
+		t.Errorf("Expected ErrGameNotFinished, got %v", err)
+	}
+	if _, err := s.CreateGame("minimax", "", Medium, 3); err == nil {
+		t.Error("Expected an invalid side to be rejected")
+	}
+	if _, err := s.CreateGame("alphabeta", "", Medium, 2); err == nil {
+		t.Error("Expected an unknown engine to be rejected")
+	}
+	if _, err := s.CreateGame("heuristic", "attacker", Medium, 2); err == nil {
+		t.Error("Expected a personality outside the minimax engine to be rejected")
+	}
+
+	// The human's five ends the game without a reply
+	session := s.games[id]
+	session.game.Board.Grid = createEmptyBoard()
+	session.game.Board.CurrentPlayer = 2
+	for x := 0; x < 4; x++ {
+		session.game.Board.Grid[14][x] = 2
+	}
+	turn, err := s.MakeMove(id, model.Move{X: 4, Y: 14})
+	if err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	if turn.Game.Status != "human_win" || turn.Game.EndReason != "five" || turn.AIMove != nil || session.ai != nil {
+		t.Errorf("Expected a human win that releases the engine, got %s", turn.Game.Status)
+	}
+	if reviewed, err := s.FinishedGame(id); err != nil || reviewed.Winner != 2 {
+		t.Errorf("Expected white to win the reviewed game, got %d (%v)", reviewed.Winner, err)
+	}
+}
+
+func TestAIGameUndoResignReset(t *testing.T) {
+	s := NewAIGameService()
+	turn, err := s.CreateGame("heuristic", "", Medium, 2)
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	id := turn.Game.ID
+	if turn.Game.Engine != "heuristic" || turn.Game.Difficulty != "medium" {
+		t.Errorf("Expected the engine and difficulty on the game, got %s/%s", turn.Game.Engine, turn.Game.Difficulty)
+	}
+	if _, err := s.Undo(id); err == nil {
+		t.Error("Expected nothing to undo before the first move")
+	}
+
+	s.MakeMove(id, model.Move{X: 7, Y: 7})
+	turn, _ = s.MakeMove(id, model.Move{X: 0, Y: 0})
+	if len(turn.Game.Moves) != 4 || turn.Game.Moves[3].Number != 4 || turn.Game.Moves[3].Player != 2 {
+		t.Fatalf("Expected four numbered moves, got %+v", turn.Game.Moves)
+	}
+
+	// Undo takes back the human's move and the reply
+	game, err := s.Undo(id)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if len(game.Moves) != 2 || game.Board.Grid[0][0] != 0 || game.Board.CurrentPlayer != 1 || game.Undos != 1 {
+		t.Errorf("Expected two moves left with black to play, got %d moves", len(game.Moves))
+	}
+
+	// A game won by five is reopened by undo
+	session := s.games[id]
+	for x := 0; x < 4; x++ {
+		session.game.Board.Grid[14][x] = 1
+		session.game.Moves = append(session.game.Moves, model.AIGameMove{Move: model.Move{X: x, Y: 14, Player: 1}})
+		session.game.Board.Grid[13][x+10] = 2
+		session.game.Moves = append(session.game.Moves, model.AIGameMove{Move: model.Move{X: x + 10, Y: 13, Player: 2}})
+	}
+	if turn, _ = s.MakeMove(id, model.Move{X: 4, Y: 14}); turn.Game.Status != "human_win" || turn.Game.Winner != 1 {
+		t.Fatalf("Expected black to win, got %s", turn.Game.Status)
+	}
+	if game, err = s.Undo(id); err != nil || game.Status != "playing" || game.EndedAt != nil || session.engine == nil {
+		t.Fatalf("Expected undo to reopen the game, got %s (%v)", game.Status, err)
+	}
+
+	// Resigning gives the engine the game for good
+	if game, err = s.Resign(id); err != nil || game.Status != "ai_win" || game.Winner != 2 || game.EndReason != "resign" {
+		t.Fatalf("Expected a win for the engine by resignation, got %s (%v)", game.Status, err)
+	}
+	if _, err := s.Undo(id); err == nil {
+		t.Error("Expected a resigned game to stay decided")
+	}
+	if _, err := s.MakeMove(id, model.Move{X: 1, Y: 1}); err == nil {
+		t.Error("Expected no moves after resigning")
+	}
+
+	// Reset starts again with the same settings
+	turn, err = s.ResetGame(id)
+	if err != nil {
+		t.Fatalf("ResetGame failed: %v", err)
+	}
+	if turn.Game.ID != id || turn.Game.Status != "playing" || len(turn.Game.Moves) != 0 || turn.Game.Engine != "heuristic" {
+		t.Errorf("Expected a fresh game with the same settings, got %+v", turn.Game)
+	}
+	if s.ActiveGames() != 1 {
+		t.Errorf("Expected one active game, got %d", s.ActiveGames())
+	}
+}
@@ -21,33 +21,46 @@ func NewAIService() *AIService {
 	}
 }
 
-// GetAIMove generates the best move for the AI using heuristic algorithm
-// Priority: Win > Block opponent's four-in-a-row > Create three-in-a-row > Random
+// GetAIMove generates the best move for the AI playing white (2)
 func (ai *AIService) GetAIMove(board [][]int, lastMove model.Move) model.AIMove {
+	return ai.GetMoveForPlayer(board, lastMove, 2)
+}
+
+// GetMoveForPlayer generates the best move for the given side to move using heuristic algorithm
+// Priority: Win > Block opponent's four-in-a-row > Create three-in-a-row > Random
+func (ai *AIService) GetMoveForPlayer(board [][]int, lastMove model.Move, player int) model.AIMove {
 	size := len(board)
+	opponent := 3 - player
 	
-	// Priority 1: Check if AI can win in one move
-	if move := ai.findWinningMove(board, 2, size); move != nil {
+	// Priority 1: Check if we can win in one move
+	if move := ai.findWinningMove(board, player, size); move != nil {
 		return *move
 	}
 	
 	// Priority 2: Block opponent's winning move
-	if move := ai.findWinningMove(board, 1, size); move != nil {
+	if move := ai.findWinningMove(board, opponent, size); move != nil {
 		return *move
 	}
 	
 	// Priority 3: Create threats (three-in-a-row)
-	if move := ai.findThreateningMove(board, 2, size); move != nil {
+	if move := ai.findThreateningMove(board, player, size); move != nil {
 		return *move
 	}
 	
 	// Priority 4: Block opponent's threats
-	if move := ai.findThreateningMove(board, 1, size); move != nil {
+	if move := ai.findThreateningMove(board, opponent, size); move != nil {
 		return *move
 	}
 	
+	// Opening move: nothing to respond to, so take the center
+	if lastMove.X < 0 || lastMove.Y < 0 {
+		if center := size / 2; board[center][center] == 0 {
+			return model.AIMove{X: center, Y: center, Score: 1}
+		}
+	}
+	
 	// Priority 5: Strategic positioning near last move
-	if move := ai.findStrategicMove(board, lastMove, size); move != nil {
+	if move := ai.findStrategicMove(board, lastMove, player, size); move != nil {
 		return *move
 	}
 	
@@ -97,7 +110,7 @@ func (ai *AIService) findThreateningMove(board [][]int, player, size int) *model
 }
 
 // findStrategicMove finds a good move near the last opponent move
-func (ai *AIService) findStrategicMove(board [][]int, lastMove model.Move, size int) *model.AIMove {
+func (ai *AIService) findStrategicMove(board [][]int, lastMove model.Move, player, size int) *model.AIMove {
 	// Search in a 3x3 area around the last move
 	directions := [][]int{
 		{-1, -1}, {-1, 0}, {-1, 1},
@@ -112,7 +125,7 @@ func (ai *AIService) findStrategicMove(board [][]int, lastMove model.Move, size
 		y := lastMove.Y + dir[1]
 		
 		if x >= 0 && x < size && y >= 0 && y < size && board[y][x] == 0 {
-			score := ai.evaluatePosition(board, x, y, 2, size)
+			score := ai.evaluatePosition(board, x, y, player, size)
 			if score > bestMove.Score {
 				bestMove.X = x
 				bestMove.Y = y
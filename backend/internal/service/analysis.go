@@ -0,0 +1,234 @@
+// Package service contains position analysis
+// This file searches the best few moves of a position (multi-PV), converts scores to win
+// probabilities and lists the fours and open threes on the board
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// MaxMultiPV bounds how many candidate moves an analysis returns
+const MaxMultiPV = 10
+
+// CandidateMove is one of the best moves of an analysed position
+type CandidateMove struct {
+	X              int          `json:"x"`
+	Y              int          `json:"y"`
+	Score          int          `json:"score"`          // From the side to move's point of view
+	WinProbability float64      `json:"winProbability"` // For the side to move
+	PV             []model.Move `json:"pv"`             // Expected line starting with this move
+}
+
+// Threat is a four or an open three on the board
+type Threat struct {
+	Shape  string       `json:"shape"`  // open_four, four, broken_four, open_three or broken_three
+	Stones []model.Move `json:"stones"` // Stones forming the shape
+	Points []model.Move `json:"points"` // Where the player makes five (fours) or an open four (threes)
+}
+
+// SideThreats are one player's threats
+type SideThreats struct {
+	Player     int      `json:"player"`
+	Fours      []Threat `json:"fours"`
+	OpenThrees []Threat `json:"openThrees"`
+}
+
+// PositionAnalysis is the result of analysing a position
+type PositionAnalysis struct {
+	ToMove         int             `json:"toMove"`
+	Score          int             `json:"score"`          // Best candidate's score
+	WinProbability float64         `json:"winProbability"` // For the side to move
+	Depth          int             `json:"depth"`          // Deepest iteration completed for every candidate
+	Nodes          uint64          `json:"nodes"`
+	TimeMs         int64           `json:"timeMs"`
+	Candidates     []CandidateMove `json:"candidates"`
+	Threats        [2]SideThreats  `json:"threats"` // Black, then white
+	GameOver       bool            `json:"gameOver"`
+}
+
+// WinProbability converts a search score to the side to move's chance of winning. Proven
+// wins and losses are certain; other scores use the scale the neural evaluation is trained to.
+func WinProbability(score int) float64 {
+	switch {
+	case score >= winScore:
+		return 1
+	case score <= -winScore:
+		return 0
+	}
+	return sigmoid(float64(score) / nnueScoreScale)
+}
+
+// Analyze searches the multiPV best moves of the position with iterative deepening. At each
+// depth the candidates are found one after the other, each search leaving out the moves
+// already chosen. Only fully completed depths are reported.
+func (ai *EnhancedAIService) Analyze(ctx context.Context, position Position, limits SearchLimits, multiPV int) (PositionAnalysis, error) {
+	if err := position.Validate(); err != nil {
+		return PositionAnalysis{}, err
+	}
+	if err := limits.Validate(ai.Capabilities()); err != nil {
+		return PositionAnalysis{}, err
+	}
+	if multiPV < 1 || multiPV > MaxMultiPV {
+		return PositionAnalysis{}, errors.New("multiPV must be between 1 and 10")
+	}
+
+	board, player := position.Board, position.ToMove
+	analysis := PositionAnalysis{ToMove: player, Candidates: []CandidateMove{}, Threats: findThreats(board)}
+	s := ai.newSearch(ctx, player, limits)
+	w := ai.newSearchWorker(s, board, 0)
+	finish := func() (PositionAnalysis, error) {
+		ai.recordStats(s, []*searchWorker{w})
+		analysis.Nodes = w.nodes
+		analysis.TimeMs = time.Since(s.startTime).Milliseconds()
+		return analysis, nil
+	}
+
+	// A finished game has nothing left to analyse
+	if w.pb.counts[1][ShapeFive]+w.pb.counts[2][ShapeFive] > 0 || w.pb.isFull() {
+		analysis.GameOver = true
+		return finish()
+	}
+
+	var lines [][]model.Move
+	var scores []int
+	for depth := 1; depth <= ai.searchDepth(limits); depth++ {
+		if s.shouldStop() {
+			break
+		}
+
+		var depthLines [][]model.Move
+		var depthScores []int
+		w.excluded = nil
+		for k := 0; k < multiPV; k++ {
+			previous, prevPV := 0, []model.Move(nil)
+			if k < len(lines) {
+				previous, prevPV = scores[k], lines[k]
+			}
+			score, line := w.searchRoot(depth, previous, position.LastMove, prevPV)
+			if s.stopped.Load() || len(line) == 0 {
+				break
+			}
+			depthLines = append(depthLines, line)
+			depthScores = append(depthScores, score)
+			w.excluded = append(w.excluded, line[0])
+		}
+		w.excluded = nil
+		if s.stopped.Load() || len(depthLines) == 0 {
+			break
+		}
+		lines, scores = depthLines, depthScores
+		analysis.Depth = depth
+
+		// Searching deeper cannot change a proven result for every candidate
+		if abs(scores[len(scores)-1]) >= winScore && abs(scores[0]) >= winScore {
+			break
+		}
+	}
+
+	// Fall back to the ordered moves if not even the first depth completed
+	if len(lines) == 0 {
+		for _, move := range w.orderMoves(position.LastMove, player, model.Move{X: -1, Y: -1}, 0) {
+			if len(lines) == multiPV {
+				break
+			}
+			lines = append(lines, []model.Move{move})
+			scores = append(scores, 0)
+		}
+	}
+
+	// Later candidates were searched without the earlier ones, so order them by score
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	for _, i := range order {
+		side := player
+		for j := range lines[i] {
+			lines[i][j].Player = side
+			side = 3 - side
+		}
+		analysis.Candidates = append(analysis.Candidates, CandidateMove{
+			X:              lines[i][0].X,
+			Y:              lines[i][0].Y,
+			Score:          scores[i],
+			WinProbability: WinProbability(scores[i]),
+			PV:             lines[i],
+		})
+	}
+	if len(analysis.Candidates) > 0 {
+		analysis.Score = analysis.Candidates[0].Score
+		analysis.WinProbability = analysis.Candidates[0].WinProbability
+	}
+	return finish()
+}
+
+// findThreats lists both players' fours and open threes. Stones on one line that share a
+// shape and its completion points form a single threat.
+func findThreats(board [][]int) [2]SideThreats {
+	pb := newPatternBoard(board)
+	threats := [2]SideThreats{
+		{Player: 1, Fours: []Threat{}, OpenThrees: []Threat{}},
+		{Player: 2, Fours: []Threat{}, OpenThrees: []Threat{}},
+	}
+	seen := make(map[string]bool)
+
+	for y := 0; y < pb.size; y++ {
+		for x := 0; x < pb.size; x++ {
+			player := pb.at(x, y)
+			if player == 0 {
+				continue
+			}
+			for d, dir := range directions {
+				shape := pb.shape(x, y, d, player)
+				if !shape.IsFour() && !shape.IsOpenThree() {
+					continue
+				}
+
+				// Completion points and partner stones lie within one window of this stone
+				target := ShapeFive
+				if shape.IsOpenThree() {
+					target = ShapeOpenFour
+				}
+				threat := Threat{Shape: shape.String()}
+				key := []byte{byte(player), byte(d)}
+				for offset := -patternReach; offset <= patternReach; offset++ {
+					nx, ny := x+dir[0]*offset, y+dir[1]*offset
+					if !pb.inside(nx, ny) {
+						continue
+					}
+					switch pb.at(nx, ny) {
+					case 0:
+						if pb.shape(nx, ny, d, player) >= target {
+							threat.Points = append(threat.Points, model.Move{X: nx, Y: ny, Player: player})
+							key = append(key, byte(nx), byte(ny))
+						}
+					case player:
+						if pb.shape(nx, ny, d, player) == shape {
+							threat.Stones = append(threat.Stones, model.Move{X: nx, Y: ny, Player: player})
+						}
+					}
+				}
+				if len(threat.Points) == 0 || seen[string(key)] {
+					continue
+				}
+				seen[string(key)] = true
+
+				side := &threats[player-1]
+				if shape.IsFour() {
+					side.Fours = append(side.Fours, threat)
+				} else {
+					side.OpenThrees = append(side.OpenThrees, threat)
+				}
+			}
+		}
+	}
+	return threats
+}
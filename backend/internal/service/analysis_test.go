@@ -0,0 +1,105 @@
+// Unit tests for position analysis
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestAnalyzeMultiPV(t *testing.T) {
+	ai := NewEnhancedAIService()
+	position := Position{Board: lineBoard("_XO_", 1), LastMove: model.Move{X: 5, Y: 7}, ToMove: 1}
+	analysis, err := ai.Analyze(context.Background(), position, SearchLimits{Depth: 3}, 3)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if len(analysis.Candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, got %d", len(analysis.Candidates))
+	}
+	if analysis.Depth != 3 {
+		t.Errorf("Expected depth 3, got %d", analysis.Depth)
+	}
+	seen := make(map[model.Move]bool)
+	for i, candidate := range analysis.Candidates {
+		move := model.Move{X: candidate.X, Y: candidate.Y}
+		if seen[move] {
+			t.Errorf("Candidate %d repeats (%d,%d)", i, move.X, move.Y)
+		}
+		seen[move] = true
+		if position.Board[move.Y][move.X] != 0 {
+			t.Errorf("Candidate %d is on an occupied cell", i)
+		}
+		if len(candidate.PV) == 0 || candidate.PV[0].X != move.X || candidate.PV[0].Y != move.Y || candidate.PV[0].Player != 1 {
+			t.Errorf("Candidate %d: PV %v does not start with the move", i, candidate.PV)
+		}
+		if i > 0 && candidate.Score > analysis.Candidates[i-1].Score {
+			t.Errorf("Candidates are not sorted by score: %d after %d", candidate.Score, analysis.Candidates[i-1].Score)
+		}
+		if candidate.WinProbability < 0 || candidate.WinProbability > 1 {
+			t.Errorf("Candidate %d: win probability %v out of range", i, candidate.WinProbability)
+		}
+	}
+	if analysis.Score != analysis.Candidates[0].Score || analysis.Nodes == 0 {
+		t.Errorf("Expected the summary to match the best candidate, got score %d and %d nodes", analysis.Score, analysis.Nodes)
+	}
+
+	// The best move matches a plain search at the same depth
+	ai.ClearTranspositionTable()
+	best, _ := ai.BestMove(context.Background(), position, SearchLimits{Depth: 3})
+	if best.Score != analysis.Score {
+		t.Errorf("Expected the best candidate to score %d like BestMove, got %d", best.Score, analysis.Score)
+	}
+
+	if _, err := ai.Analyze(context.Background(), position, SearchLimits{}, MaxMultiPV+1); err == nil {
+		t.Error("Expected too many candidates to be rejected")
+	}
+}
+
+func TestAnalyzeWinningPosition(t *testing.T) {
+	ai := NewEnhancedAIService()
+	board := createEmptyBoard()
+	for x := 5; x <= 8; x++ {
+		board[7][x] = 2
+	}
+	for x := 5; x <= 7; x++ {
+		board[9][x] = 1
+	}
+	board[0][0] = 1
+	position := Position{Board: board, LastMove: model.Move{X: 0, Y: 0}, ToMove: 2}
+
+	analysis, err := ai.Analyze(context.Background(), position, SearchLimits{Difficulty: Medium}, 2)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if analysis.WinProbability != 1 || analysis.Candidates[0].Y != 7 {
+		t.Errorf("Expected a certain win on row 7, got %v at (%d,%d)", analysis.WinProbability, analysis.Candidates[0].X, analysis.Candidates[0].Y)
+	}
+
+	white := analysis.Threats[1]
+	if len(white.Fours) != 1 || white.Fours[0].Shape != "open_four" || len(white.Fours[0].Points) != 2 || len(white.Fours[0].Stones) != 4 {
+		t.Errorf("Expected white's open four with two winning points, got %+v", white.Fours)
+	}
+	black := analysis.Threats[0]
+	if len(black.OpenThrees) != 1 || len(black.OpenThrees[0].Stones) != 3 {
+		t.Errorf("Expected black's open three, got %+v", black.OpenThrees)
+	}
+
+	// A finished game is reported without candidates
+	board[7][9] = 2
+	analysis, _ = ai.Analyze(context.Background(), Position{Board: board, LastMove: model.Move{X: 9, Y: 7}, ToMove: 1}, SearchLimits{}, 3)
+	if !analysis.GameOver || len(analysis.Candidates) != 0 {
+		t.Errorf("Expected a finished game, got %+v", analysis)
+	}
+}
+
+func TestWinProbability(t *testing.T) {
+	if WinProbability(0) != 0.5 || WinProbability(mateScore) != 1 || WinProbability(-mateScore) != 0 {
+		t.Error("Expected even, won and lost positions to map to 0.5, 1 and 0")
+	}
+	if WinProbability(1000) <= WinProbability(100) {
+		t.Error("Expected the probability to grow with the score")
+	}
+}
@@ -0,0 +1,223 @@
+// Package service contains the Anthropic LLM adapter
+// This file talks to the Anthropic Messages API. The model is forced to answer through the
+// place_stone tool, so the move arrives as structured tool input rather than free text.
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// Defaults for the Anthropic adapter
+const (
+	defaultAnthropicEndpoint  = "https://api.anthropic.com/v1/messages"
+	defaultAnthropicModel     = "claude-sonnet-4-5"
+	defaultAnthropicMaxTokens = 1024
+	anthropicVersion          = "2023-06-01"
+)
+
+// AnthropicAdapter implements LLMAdapter for the Anthropic Messages API
+type AnthropicAdapter struct {
+	httpClient *http.Client
+}
+
+// NewAnthropicAdapter creates a new Anthropic adapter
+func NewAnthropicAdapter() *AnthropicAdapter {
+	return &AnthropicAdapter{
+		httpClient: &http.Client{
+			Timeout: 90 * time.Second,
+		},
+	}
+}
+
+// anthropicResponse is the part of a Messages API response the adapter reads
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// GetMove implements LLMAdapter interface for Anthropic
+func (a *AnthropicAdapter) GetMove(board [][]int, lastMove model.Move, config model.LLMConfig) (*model.LLMMove, error) {
+	return a.RetryMove(board, lastMove, config, nil)
+}
+
+// RetryMove implements MoveCorrector interface for Anthropic
+func (a *AnthropicAdapter) RetryMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) (*model.LLMMove, error) {
+	if err := a.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(a.buildRequest(board, lastMove, config, mistakes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", messagesURL(config.Endpoint), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", config.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, providerError(resp, anthropicErrorMessage(body))
+	}
+
+	var response anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	usage := model.LLMUsage{
+		Requests:         1,
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+		TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
+	}
+
+	// The tool input carries the move; text blocks are the fallback
+	var content string
+	var text strings.Builder
+	for _, block := range response.Content {
+		switch {
+		case block.Type == "tool_use" && block.Name == placeStoneTool:
+			content = string(block.Input)
+		case block.Type == "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if content == "" {
+		if response.StopReason == "max_tokens" && text.Len() == 0 {
+			return nil, &UnparsableMoveError{Err: errors.New("response was cut off at max_tokens before the move"), Usage: &usage}
+		}
+		content = text.String()
+	}
+	move, err := parseMoveJSON(content)
+	if err != nil {
+		return nil, &UnparsableMoveError{Err: err, Usage: &usage}
+	}
+	move.Usage = &usage
+	move.Player = 2 // LLM is player 2
+	move.Timestamp = time.Now()
+
+	return move, nil
+}
+
+// buildRequest assembles the Messages API payload with the move tool forced
+func (a *AnthropicAdapter) buildRequest(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"model":      stringParam(config, "model", defaultAnthropicModel),
+		"max_tokens": defaultAnthropicMaxTokens,
+		"system":     moveSystemPrompt,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": buildMovePrompt(board, lastMove) + toolAnswerFormat + correctionPrompt(mistakes)},
+		},
+		"tools": []map[string]interface{}{{
+			"name":         placeStoneTool,
+			"description":  placeStoneSummary,
+			"input_schema": placeStoneSchema(),
+		}},
+		"tool_choice": map[string]interface{}{"type": "tool", "name": placeStoneTool},
+	}
+	if params, ok := config.Parameters["max_tokens"]; ok {
+		requestBody["max_tokens"] = params
+	}
+	if params, ok := config.Parameters["temperature"]; ok {
+		requestBody["temperature"] = params
+	}
+	return requestBody
+}
+
+// ValidateConfig validates Anthropic configuration
+func (a *AnthropicAdapter) ValidateConfig(config model.LLMConfig) error {
+	if config.APIKey == "" {
+		return errors.New("API key is required for Anthropic")
+	}
+	if err := validateEndpoint(config.Endpoint); err != nil {
+		return err
+	}
+	if params, ok := config.Parameters["max_tokens"]; ok {
+		switch tokens := params.(type) {
+		case int:
+			if tokens > 0 {
+				return nil
+			}
+		case float64:
+			if tokens > 0 && tokens == float64(int(tokens)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("max_tokens must be a positive integer, got %v", params)
+	}
+	return nil
+}
+
+// GetModelInfo returns Anthropic model information
+func (a *AnthropicAdapter) GetModelInfo() model.LLMModel {
+	return model.LLMModel{
+		Name:           "claude",
+		DisplayName:    "Claude",
+		Provider:       "anthropic",
+		RequiresAPIKey: true,
+		DefaultParams: map[string]interface{}{
+			"model":       defaultAnthropicModel,
+			"temperature": 0.7,
+			"max_tokens":  defaultAnthropicMaxTokens,
+		},
+		Status: "available",
+	}
+}
+
+// messagesURL accepts the full Messages API URL, a base URL ending in /v1 or the bare host
+func messagesURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	switch {
+	case endpoint == "":
+		return defaultAnthropicEndpoint
+	case strings.HasSuffix(endpoint, "/v1/messages"):
+		return endpoint
+	case strings.HasSuffix(endpoint, "/v1"):
+		return endpoint + "/messages"
+	}
+	return endpoint + "/v1/messages"
+}
+
+// anthropicErrorMessage returns the type and message of an Anthropic error body, or the
+// body itself
+func anthropicErrorMessage(body []byte) string {
+	var apiError struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
+		return apiError.Error.Type + ": " + apiError.Error.Message
+	}
+	return string(body)
+}
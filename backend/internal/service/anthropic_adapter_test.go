@@ -0,0 +1,153 @@
+// Unit tests for the Anthropic adapter against a stub server
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+// anthropicStub serves the Messages API with the reply built for each decoded request
+func anthropicStub(t *testing.T, reply func(w http.ResponseWriter, request map[string]interface{}) (int, string)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("Missing API headers: %v", r.Header)
+		}
+		var request map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		status, body := reply(w, request)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestAnthropicAdapterToolUse(t *testing.T) {
+	server := anthropicStub(t, func(w http.ResponseWriter, request map[string]interface{}) (int, string) {
+		if request["model"] != "claude-haiku-test" || request["max_tokens"] != 256.0 {
+			t.Errorf("Expected the configured model and max_tokens, got %v and %v", request["model"], request["max_tokens"])
+		}
+		if request["system"] != moveSystemPrompt {
+			t.Errorf("Expected the system prompt, got %v", request["system"])
+		}
+		choice, _ := request["tool_choice"].(map[string]interface{})
+		if choice["type"] != "tool" || choice["name"] != placeStoneTool {
+			t.Errorf("Expected the move tool to be forced, got %v", request["tool_choice"])
+		}
+		return http.StatusOK, `{"type":"message","role":"assistant","stop_reason":"tool_use",
+			"content":[{"type":"tool_use","id":"toolu_1","name":"place_stone","input":{"x":9,"y":5,"reasoning":"extend"}}],
+			"usage":{"input_tokens":700,"output_tokens":60}}`
+	})
+	defer server.Close()
+
+	config := model.LLMConfig{
+		APIKey:     "test-key",
+		Endpoint:   server.URL,
+		Parameters: map[string]interface{}{"model": "claude-haiku-test", "max_tokens": 256.0},
+	}
+	move, err := NewAnthropicAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config)
+	if err != nil {
+		t.Fatalf("GetMove failed: %v", err)
+	}
+	if move.X != 9 || move.Y != 5 || move.Reasoning != "extend" || move.Player != 2 {
+		t.Errorf("Unexpected move %+v", move)
+	}
+	want := model.LLMUsage{Requests: 1, PromptTokens: 700, CompletionTokens: 60, TotalTokens: 760}
+	if move.Usage == nil || *move.Usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, move.Usage)
+	}
+}
+
+func TestAnthropicAdapterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+		detail string
+	}{
+		{"rate limit", http.StatusTooManyRequests, `{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`, ErrLLMRateLimited, "retry after 20s"},
+		{"overloaded", statusOverloaded, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, ErrLLMOverloaded, "overloaded_error: Overloaded"},
+		{"bad key", http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, nil, "status 401: authentication_error: invalid x-api-key"},
+	}
+	for _, tt := range tests {
+		server := anthropicStub(t, func(w http.ResponseWriter, request map[string]interface{}) (int, string) {
+			w.Header().Set("Retry-After", "20")
+			return tt.status, tt.body
+		})
+		_, err := NewAnthropicAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, model.LLMConfig{APIKey: "test-key", Endpoint: server.URL + "/v1"})
+		server.Close()
+		if err == nil || !strings.Contains(err.Error(), tt.detail) {
+			t.Errorf("%s: expected an error with %q, got %v", tt.name, tt.detail, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+		if tt.want == nil && (errors.Is(err, ErrLLMRateLimited) || errors.Is(err, ErrLLMOverloaded)) {
+			t.Errorf("%s: expected a permanent error, got %v", tt.name, err)
+		}
+	}
+
+	server := anthropicStub(t, func(w http.ResponseWriter, request map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"content":[],"stop_reason":"max_tokens","usage":{"input_tokens":700,"output_tokens":1}}`
+	})
+	_, err := NewAnthropicAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, model.LLMConfig{APIKey: "test-key", Endpoint: server.URL})
+	server.Close()
+	if err == nil || !strings.Contains(err.Error(), "max_tokens") {
+		t.Errorf("Expected a max_tokens error, got %v", err)
+	}
+}
+
+func TestAnthropicAdapterConfig(t *testing.T) {
+	adapter := NewAnthropicAdapter()
+	tests := []struct {
+		name   string
+		config model.LLMConfig
+		valid  bool
+	}{
+		{"no key", model.LLMConfig{}, false},
+		{"key", model.LLMConfig{APIKey: "test-key"}, true},
+		{"max tokens", model.LLMConfig{APIKey: "test-key", Parameters: map[string]interface{}{"max_tokens": 2048.0}}, true},
+		{"zero max tokens", model.LLMConfig{APIKey: "test-key", Parameters: map[string]interface{}{"max_tokens": 0.0}}, false},
+		{"text max tokens", model.LLMConfig{APIKey: "test-key", Parameters: map[string]interface{}{"max_tokens": "many"}}, false},
+	}
+	for _, tt := range tests {
+		if err := adapter.ValidateConfig(tt.config); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+
+	urls := map[string]string{
+		"":                                      defaultAnthropicEndpoint,
+		"http://localhost:9000":                 "http://localhost:9000/v1/messages",
+		"http://localhost:9000/v1/":             "http://localhost:9000/v1/messages",
+		"https://api.anthropic.com/v1/messages": "https://api.anthropic.com/v1/messages",
+	}
+	for endpoint, want := range urls {
+		if got := messagesURL(endpoint); got != want {
+			t.Errorf("messagesURL(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+
+	found := false
+	for _, info := range NewLLMService().GetAvailableModels() {
+		if info.Name == "claude" {
+			found = info.Status == "not_configured"
+		}
+	}
+	if !found {
+		t.Error("Expected claude to be registered and waiting for a key")
+	}
+}
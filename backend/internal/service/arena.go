@@ -0,0 +1,438 @@
+// Package service contains the engine-vs-engine match runner
+// This file plays games between engine configurations and estimates their Elo difference
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+
+	"gomoku-backend/internal/model"
+)
+
+// ArenaPlayer is one side of a match
+type ArenaPlayer struct {
+	Name      string                 // Label used in reports
+	NewEngine func() (Engine, error) // Creates an engine instance; called once per concurrent game slot
+	Limits    SearchLimits
+}
+
+// MatchConfig describes a match between two players
+type MatchConfig struct {
+	First, Second ArenaPlayer
+	Games         int            // Number of games, rounded up to an even number for colour swaps
+	Openings      [][]model.Move // Opening suite; each opening is played once with each colour
+	Concurrency   int            // Games played at the same time
+	MaxMoves      int            // Games reaching this many stones are drawn; 0 means a full board
+	SPRT          *SPRTConfig    // Stops the match early once the test is decided
+	OnGame        func(GameResult, MatchResult)
+}
+
+// GameResult is the outcome of one game
+type GameResult struct {
+	Number       int          `json:"number"`
+	Opening      int          `json:"opening"`
+	FirstIsBlack bool         `json:"firstIsBlack"`
+	Winner       int          `json:"winner"` // 0 draw, 1 first player, 2 second player
+	Moves        []model.Move `json:"moves"`
+	Err          error        `json:"-"` // Engine failure that forfeited the game
+}
+
+// MatchResult holds the score of a match from the first player's point of view
+type MatchResult struct {
+	Wins, Draws, Losses int
+	Forfeits            int
+	SPRT                SPRTVerdict
+	LLR                 float64
+}
+
+// SPRTConfig describes a sequential probability ratio test between two Elo hypotheses
+type SPRTConfig struct {
+	Elo0, Elo1  float64 // H0: the difference is Elo0; H1: it is Elo1
+	Alpha, Beta float64 // False positive and false negative rates
+}
+
+// SPRTVerdict is the state of a sequential test
+type SPRTVerdict string
+
+const (
+	SPRTContinue SPRTVerdict = "continue"
+	SPRTAcceptH0 SPRTVerdict = "H0 accepted"
+	SPRTAcceptH1 SPRTVerdict = "H1 accepted"
+)
+
+// ArenaOpenings returns the 26 standard three-stone openings: 13 with white directly
+// next to the centre stone and 13 with white diagonal to it, third stones within two
+// lines of the centre and mirror images removed
+func ArenaOpenings() [][]model.Move {
+	const center = 7
+	var openings [][]model.Move
+
+	// Direct openings are symmetric about the vertical line through both stones
+	for dx := 0; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			if dx == 0 && (dy == 0 || dy == -1) {
+				continue
+			}
+			openings = append(openings, []model.Move{
+				{X: center, Y: center}, {X: center, Y: center - 1}, {X: center + dx, Y: center + dy},
+			})
+		}
+	}
+
+	// Indirect openings are symmetric about the diagonal through both stones
+	for dx := -2; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			if (dx == 0 && dy == 0) || (dx == 1 && dy == -1) || -dy < dx {
+				continue
+			}
+			openings = append(openings, []model.Move{
+				{X: center, Y: center}, {X: center + 1, Y: center - 1}, {X: center + dx, Y: center + dy},
+			})
+		}
+	}
+	return openings
+}
+
+// RandomOpenings extends randomly chosen standard openings with random stones near the
+// centre, giving self-play games the variety training needs
+func RandomOpenings(count, randomMoves int, rng *rand.Rand) [][]model.Move {
+	base := ArenaOpenings()
+	openings := make([][]model.Move, 0, count)
+	for len(openings) < count {
+		opening := append([]model.Move(nil), base[rng.Intn(len(base))]...)
+		occupied := make(map[model.Move]bool)
+		for _, move := range opening {
+			occupied[move] = true
+		}
+		for len(opening) < 3+randomMoves {
+			move := model.Move{X: 7 + rng.Intn(7) - 3, Y: 7 + rng.Intn(7) - 3}
+			if !occupied[move] {
+				occupied[move] = true
+				opening = append(opening, move)
+			}
+		}
+		openings = append(openings, opening)
+	}
+	return openings
+}
+
+// LoadArenaOpenings reads an opening suite of Gomocup lines: x,y pairs relative to the centre
+func LoadArenaOpenings(r io.Reader) ([][]model.Move, error) {
+	var openings [][]model.Move
+	_, err := scanGomocupLines(r, 15, func(moves []model.Move) error {
+		if _, err := openingBoard(moves); err != nil {
+			return err
+		}
+		openings = append(openings, moves)
+		return nil
+	})
+	if err == nil && len(openings) == 0 {
+		err = errors.New("no openings found")
+	}
+	return openings, err
+}
+
+// openingBoard plays the opening moves, black first
+func openingBoard(moves []model.Move) ([][]int, error) {
+	board := createGrid(15)
+	for i, move := range moves {
+		if move.X < 0 || move.X >= 15 || move.Y < 0 || move.Y >= 15 || board[move.Y][move.X] != 0 {
+			return nil, fmt.Errorf("invalid opening move %d,%d", move.X, move.Y)
+		}
+		board[move.Y][move.X] = i%2 + 1
+		if fiveInRow(board, move.X, move.Y, i%2+1) {
+			return nil, errors.New("opening contains a finished game")
+		}
+	}
+	return board, nil
+}
+
+// RunMatch plays the match and returns the final score. Game pairs share an opening with
+// colours swapped. A cancelled context or a decided SPRT stops the match; games still in
+// progress are abandoned and not counted.
+func RunMatch(ctx context.Context, config MatchConfig) (MatchResult, error) {
+	if len(config.Openings) == 0 {
+		config.Openings = ArenaOpenings()
+	}
+	for _, opening := range config.Openings {
+		if _, err := openingBoard(opening); err != nil {
+			return MatchResult{}, err
+		}
+	}
+	games := config.Games + config.Games%2
+	concurrency := max(1, min(config.Concurrency, games))
+	maxMoves := config.MaxMoves
+	if maxMoves <= 0 || maxMoves > 225 {
+		maxMoves = 225
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mutex  sync.Mutex
+		result = MatchResult{SPRT: SPRTContinue}
+		jobs   = make(chan int)
+		wg     sync.WaitGroup
+		errs   = make(chan error, concurrency)
+	)
+
+	for slot := 0; slot < concurrency; slot++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			first, err := config.First.NewEngine()
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", config.First.Name, err)
+				cancel()
+				return
+			}
+			defer closeEngine(first)
+			second, err := config.Second.NewEngine()
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", config.Second.Name, err)
+				cancel()
+				return
+			}
+			defer closeEngine(second)
+
+			for number := range jobs {
+				game := GameResult{
+					Number:       number,
+					Opening:      number / 2 % len(config.Openings),
+					FirstIsBlack: number%2 == 0,
+				}
+				black, blackLimits := first, config.First.Limits
+				white, whiteLimits := second, config.Second.Limits
+				if !game.FirstIsBlack {
+					black, blackLimits, white, whiteLimits = white, whiteLimits, black, blackLimits
+				}
+
+				winner, moves, err := PlayGame(ctx, black, blackLimits, white, whiteLimits, config.Openings[game.Opening], maxMoves)
+				if ctx.Err() != nil {
+					return
+				}
+				game.Moves, game.Err = moves, err
+				switch {
+				case winner == 0:
+					game.Winner = 0
+				case (winner == 1) == game.FirstIsBlack:
+					game.Winner = 1
+				default:
+					game.Winner = 2
+				}
+
+				mutex.Lock()
+				result.add(game)
+				if config.SPRT != nil {
+					result.LLR = result.SPRTLLR(config.SPRT.Elo0, config.SPRT.Elo1)
+					result.SPRT = config.SPRT.verdict(result.LLR)
+					if result.SPRT != SPRTContinue {
+						cancel()
+					}
+				}
+				if config.OnGame != nil {
+					config.OnGame(game, result)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for number := 0; number < games; number++ {
+			select {
+			case jobs <- number:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	close(errs)
+	if err := <-errs; err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// closeEngine releases engines that hold resources such as brain processes
+func closeEngine(engine Engine) {
+	if closer, ok := engine.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
+
+// PlayGame plays one game from the opening and returns the winner (0 for a draw) and the
+// moves played. An engine that fails or plays an illegal move forfeits the game.
+func PlayGame(ctx context.Context, black Engine, blackLimits SearchLimits, white Engine, whiteLimits SearchLimits, opening []model.Move, maxMoves int) (int, []model.Move, error) {
+	board, err := openingBoard(opening)
+	if err != nil {
+		return 0, nil, err
+	}
+	moves := append([]model.Move(nil), opening...)
+	lastMove := model.Move{X: -1, Y: -1}
+	if len(moves) > 0 {
+		lastMove = moves[len(moves)-1]
+	}
+
+	for len(moves) < maxMoves {
+		player := len(moves)%2 + 1
+		engine, limits := black, blackLimits
+		if player == 2 {
+			engine, limits = white, whiteLimits
+		}
+
+		position := Position{Board: copyBoard(board), LastMove: lastMove, ToMove: player}
+		move, err := engine.BestMove(ctx, position, limits)
+		if err == nil && (move.X < 0 || move.X >= 15 || move.Y < 0 || move.Y >= 15 || board[move.Y][move.X] != 0) {
+			err = fmt.Errorf("illegal move %d,%d", move.X, move.Y)
+		}
+		if err != nil {
+			return 3 - player, moves, fmt.Errorf("%s: %w", engine.Name(), err)
+		}
+
+		board[move.Y][move.X] = player
+		lastMove = model.Move{X: move.X, Y: move.Y, Player: player}
+		moves = append(moves, lastMove)
+		if fiveInRow(board, move.X, move.Y, player) {
+			return player, moves, nil
+		}
+	}
+	return 0, moves, nil
+}
+
+// copyBoard returns a deep copy of the board
+func copyBoard(board [][]int) [][]int {
+	board2 := make([][]int, len(board))
+	for i := range board {
+		board2[i] = append([]int(nil), board[i]...)
+	}
+	return board2
+}
+
+// fiveInRow checks if the stone at x,y completes five or more in a row
+func fiveInRow(board [][]int, x, y, player int) bool {
+	directions := [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
+	for _, dir := range directions {
+		count := 1
+		for _, sign := range []int{1, -1} {
+			for i := 1; i < 5; i++ {
+				nx, ny := x+sign*dir[0]*i, y+sign*dir[1]*i
+				if nx < 0 || nx >= len(board) || ny < 0 || ny >= len(board) || board[ny][nx] != player {
+					break
+				}
+				count++
+			}
+		}
+		if count >= 5 {
+			return true
+		}
+	}
+	return false
+}
+
+// add counts a finished game
+func (r *MatchResult) add(game GameResult) {
+	switch game.Winner {
+	case 0:
+		r.Draws++
+	case 1:
+		r.Wins++
+	default:
+		r.Losses++
+	}
+	if game.Err != nil {
+		r.Forfeits++
+	}
+}
+
+// Games returns the number of games counted
+func (r MatchResult) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score returns the first player's score fraction, draws counting half
+func (r MatchResult) Score() float64 {
+	if r.Games() == 0 {
+		return 0.5
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(r.Games())
+}
+
+// variance returns the per-game score variance
+func (r MatchResult) variance() float64 {
+	n := float64(r.Games())
+	if n == 0 {
+		return 0
+	}
+	s := r.Score()
+	return (float64(r.Wins)*(1-s)*(1-s) + float64(r.Draws)*(0.5-s)*(0.5-s) + float64(r.Losses)*s*s) / n
+}
+
+// Elo returns the first player's Elo advantage and the half-width of its 95% confidence
+// interval. The margin is infinite until both players have scored something.
+func (r MatchResult) Elo() (float64, float64) {
+	n := float64(r.Games())
+	if n == 0 || r.variance() == 0 {
+		return scoreToElo(r.Score()), math.Inf(1)
+	}
+	s := r.Score()
+	margin := 1.959964 * math.Sqrt(r.variance()/n)
+	return scoreToElo(s), (scoreToElo(s+margin) - scoreToElo(s-margin)) / 2
+}
+
+// SPRTLLR returns the log-likelihood ratio of H1 (Elo1) against H0 (Elo0) using the
+// normal approximation of the game results. Until every outcome has occurred each counts
+// half a game more, so that one-sided results still move the test.
+func (r MatchResult) SPRTLLR(elo0, elo1 float64) float64 {
+	wins, draws, losses := float64(r.Wins), float64(r.Draws), float64(r.Losses)
+	if r.Games() == 0 {
+		return 0
+	}
+	if r.Wins == 0 || r.Draws == 0 || r.Losses == 0 {
+		wins, draws, losses = wins+0.5, draws+0.5, losses+0.5
+	}
+
+	n := wins + draws + losses
+	s := (wins + draws/2) / n
+	variance := (wins*(1-s)*(1-s) + draws*(0.5-s)*(0.5-s) + losses*s*s) / n
+	s0, s1 := eloToScore(elo0), eloToScore(elo1)
+	return n * (s1 - s0) * (2*s - s0 - s1) / (2 * variance)
+}
+
+// Bounds returns the LLR bounds below which H0 and above which H1 is accepted
+func (c SPRTConfig) Bounds() (float64, float64) {
+	return math.Log(c.Beta / (1 - c.Alpha)), math.Log((1 - c.Beta) / c.Alpha)
+}
+
+// verdict decides the test for the given log-likelihood ratio
+func (c SPRTConfig) verdict(llr float64) SPRTVerdict {
+	lower, upper := c.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTAcceptH1
+	case llr <= lower:
+		return SPRTAcceptH0
+	}
+	return SPRTContinue
+}
+
+// scoreToElo converts a score fraction to an Elo difference, clamped away from 0 and 1
+func scoreToElo(s float64) float64 {
+	s = math.Max(1e-6, math.Min(1-1e-6, s))
+	return -400 * math.Log10(1/s-1)
+}
+
+// eloToScore converts an Elo difference to the expected score fraction
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
@@ -0,0 +1,122 @@
+// Unit tests for the engine-vs-engine match runner
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestArenaOpenings(t *testing.T) {
+	openings := ArenaOpenings()
+	if len(openings) != 26 {
+		t.Fatalf("Expected 26 openings, got %d", len(openings))
+	}
+
+	// No two openings may be symmetric images of each other
+	book := NewOpeningBook()
+	seen := make(map[uint64]bool)
+	for i, opening := range openings {
+		board, err := openingBoard(opening)
+		if err != nil {
+			t.Fatalf("Opening %d is invalid: %v", i, err)
+		}
+		hash, _ := book.canonical(board)
+		if seen[hash] {
+			t.Errorf("Opening %d duplicates an earlier opening", i)
+		}
+		seen[hash] = true
+	}
+}
+
+func TestLoadArenaOpenings(t *testing.T) {
+	openings, err := LoadArenaOpenings(strings.NewReader("# suite\n0,0, 1,0, 0,1\n0,0, 0,0\n"))
+	if err == nil {
+		t.Errorf("Expected an error for a repeated cell, got %v", openings)
+	}
+
+	openings, err = LoadArenaOpenings(strings.NewReader("0,0, 1,0, 0,1\n0,0\n"))
+	if err != nil || len(openings) != 2 || openings[0][1].X != 8 {
+		t.Errorf("Unexpected openings %v (%v)", openings, err)
+	}
+}
+
+func TestMatchResultElo(t *testing.T) {
+	result := MatchResult{Wins: 60, Draws: 20, Losses: 20}
+	elo, margin := result.Elo()
+	if math.Abs(elo-147.2) > 0.1 {
+		t.Errorf("Expected +147.2 Elo for a 70%% score, got %.1f", elo)
+	}
+	if margin < 50 || margin > 100 {
+		t.Errorf("Expected a margin of about 75 Elo over 100 games, got %.1f", margin)
+	}
+
+	even := MatchResult{Wins: 10, Losses: 10}
+	if elo, _ := even.Elo(); elo != 0 {
+		t.Errorf("Expected 0 Elo for an even score, got %.1f", elo)
+	}
+}
+
+func TestSPRTVerdict(t *testing.T) {
+	sprt := SPRTConfig{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	lower, upper := sprt.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("Unexpected bounds [%.3f, %.3f]", lower, upper)
+	}
+
+	strong := MatchResult{Wins: 700, Draws: 100, Losses: 200}
+	if verdict := sprt.verdict(strong.SPRTLLR(sprt.Elo0, sprt.Elo1)); verdict != SPRTAcceptH1 {
+		t.Errorf("Expected H1 for a dominant score, got %s", verdict)
+	}
+	weak := MatchResult{Wins: 200, Draws: 100, Losses: 700}
+	if verdict := sprt.verdict(weak.SPRTLLR(sprt.Elo0, sprt.Elo1)); verdict != SPRTAcceptH0 {
+		t.Errorf("Expected H0 for a losing score, got %s", verdict)
+	}
+	// A clean sweep has no variance; the test must still decide
+	sweep := MatchResult{Wins: 10}
+	if verdict := sprt.verdict(sweep.SPRTLLR(0, 50)); verdict != SPRTAcceptH1 {
+		t.Errorf("Expected H1 after a 10-0 sweep, got %s", verdict)
+	}
+	few := MatchResult{Wins: 3, Draws: 1, Losses: 2}
+	if verdict := sprt.verdict(few.SPRTLLR(sprt.Elo0, sprt.Elo1)); verdict != SPRTContinue {
+		t.Errorf("Expected the test to continue after 6 games, got %s", verdict)
+	}
+}
+
+func TestRunMatch(t *testing.T) {
+	minimax := ArenaPlayer{
+		Name:      "minimax",
+		NewEngine: func() (Engine, error) { return NewEnhancedAIService(), nil },
+		Limits:    SearchLimits{Depth: 2},
+	}
+	heuristic := ArenaPlayer{
+		Name:      "heuristic",
+		NewEngine: func() (Engine, error) { return &heuristicEngine{ai: NewAIService()}, nil },
+	}
+
+	games := 0
+	result, err := RunMatch(context.Background(), MatchConfig{
+		First:       minimax,
+		Second:      heuristic,
+		Games:       3,
+		Openings:    ArenaOpenings()[:2],
+		Concurrency: 2,
+		OnGame: func(game GameResult, result MatchResult) {
+			games++
+			if len(game.Moves) < 3 {
+				t.Errorf("Game %d has no moves beyond the opening", game.Number)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("RunMatch failed: %v", err)
+	}
+	if games != 4 || result.Games() != 4 {
+		t.Errorf("Expected 3 games rounded up to 4, got %d reported and %d counted", games, result.Games())
+	}
+	if result.Forfeits != 0 {
+		t.Errorf("Expected no forfeits, got %d", result.Forfeits)
+	}
+	t.Logf("minimax vs heuristic: +%d =%d -%d", result.Wins, result.Draws, result.Losses)
+}
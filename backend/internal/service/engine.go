@@ -0,0 +1,171 @@
+// Package service contains the common engine interface and the engine registry
+// This file lets AI engines register themselves so controllers can look them up by name
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// Position is the input to an engine search
+type Position struct {
+	Board    [][]int    // Current board: 0 empty, 1 black, 2 white
+	LastMove model.Move // Last move played, {-1, -1} if none
+	ToMove   int        // Side to move: 1 or 2
+}
+
+// SearchLimits bounds an engine search; zero values use the engine's defaults
+type SearchLimits struct {
+	Difficulty Difficulty    // Strength preset the other limits override
+	Depth      int           // Maximum search depth in plies
+	Nodes      int64         // Maximum nodes (or playouts for MCTS)
+	Time       time.Duration // Maximum thinking time
+	Weights    *EvalWeights  // Evaluation weights; nil uses the engine's own
+}
+
+// EngineCapabilities describes what an engine supports
+type EngineCapabilities struct {
+	Description string `json:"description"`
+	Difficulty  bool   `json:"difficulty"`  // Honours SearchLimits.Difficulty
+	Depth       bool   `json:"depth"`       // Honours SearchLimits.Depth
+	Nodes       bool   `json:"nodes"`       // Honours SearchLimits.Nodes
+	Time        bool   `json:"time"`        // Honours SearchLimits.Time
+	PV          bool   `json:"pv"`          // Returns a principal variation
+	OpeningBook bool   `json:"openingBook"` // Consults an opening book
+	Weights     bool   `json:"weights"`     // Honours SearchLimits.Weights
+}
+
+// Engine is an AI that chooses moves
+type Engine interface {
+	// Name is the unique registry name used to select the engine
+	Name() string
+	// Capabilities describes the limits and features the engine supports
+	Capabilities() EngineCapabilities
+	// BestMove searches the position within the limits; a cancelled context stops the
+	// search early and the best move found so far is returned
+	BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error)
+}
+
+// engineRegistry holds every registered engine by name
+var engineRegistry = struct {
+	mutex   sync.RWMutex
+	engines map[string]Engine
+}{engines: make(map[string]Engine)}
+
+// RegisterEngine makes an engine available by name. Engines register themselves from
+// init functions; registering the same name twice panics.
+func RegisterEngine(engine Engine) {
+	engineRegistry.mutex.Lock()
+	defer engineRegistry.mutex.Unlock()
+
+	name := engine.Name()
+	if _, exists := engineRegistry.engines[name]; exists {
+		panic(fmt.Sprintf("engine %q registered twice", name))
+	}
+	engineRegistry.engines[name] = engine
+}
+
+// GetEngine returns the engine registered under name
+func GetEngine(name string) (Engine, bool) {
+	engineRegistry.mutex.RLock()
+	defer engineRegistry.mutex.RUnlock()
+
+	engine, exists := engineRegistry.engines[name]
+	return engine, exists
+}
+
+// Engines returns all registered engines sorted by name
+func Engines() []Engine {
+	engineRegistry.mutex.RLock()
+	defer engineRegistry.mutex.RUnlock()
+
+	engines := make([]Engine, 0, len(engineRegistry.engines))
+	for _, engine := range engineRegistry.engines {
+		engines = append(engines, engine)
+	}
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i].Name() < engines[j].Name()
+	})
+	return engines
+}
+
+// Validate checks the position is a 15x15 board with a valid side to move
+func (p Position) Validate() error {
+	if p.ToMove != 1 && p.ToMove != 2 {
+		return errors.New("side to move must be 1 or 2")
+	}
+	if len(p.Board) != 15 {
+		return errors.New("board must be 15x15")
+	}
+	for _, row := range p.Board {
+		if len(row) != 15 {
+			return errors.New("board must be 15x15")
+		}
+	}
+	return nil
+}
+
+// Validate checks the limits are not negative and that the engine supports every limit set
+func (l SearchLimits) Validate(capabilities EngineCapabilities) error {
+	if l.Depth < 0 || l.Nodes < 0 || l.Time < 0 {
+		return errors.New("limits must not be negative")
+	}
+	switch {
+	case l.Depth > 0 && !capabilities.Depth:
+		return errors.New("engine does not support a depth limit")
+	case l.Nodes > 0 && !capabilities.Nodes:
+		return errors.New("engine does not support a node limit")
+	case l.Time > 0 && !capabilities.Time:
+		return errors.New("engine does not support a time limit")
+	case l.Weights != nil && !capabilities.Weights:
+		return errors.New("engine does not support evaluation weights")
+	}
+	if l.Weights != nil {
+		return l.Weights.Validate()
+	}
+	return nil
+}
+
+// heuristicEngine exposes the rule-based AIService through the Engine interface.
+// AIService is not safe for concurrent use, so searches are serialised.
+type heuristicEngine struct {
+	mutex sync.Mutex
+	ai    *AIService
+}
+
+// Name returns the registry name
+func (e *heuristicEngine) Name() string {
+	return "heuristic"
+}
+
+// Capabilities describes the heuristic engine
+func (e *heuristicEngine) Capabilities() EngineCapabilities {
+	return EngineCapabilities{
+		Description: "Rule-based heuristic: win, block, threats, then nearby moves",
+	}
+}
+
+// BestMove returns the heuristic move for the side to move
+func (e *heuristicEngine) BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error) {
+	if err := position.Validate(); err != nil {
+		return model.AIMove{}, err
+	}
+	if err := limits.Validate(e.Capabilities()); err != nil {
+		return model.AIMove{}, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.ai.GetMoveForPlayer(position.Board, position.LastMove, position.ToMove), nil
+}
+
+func init() {
+	RegisterEngine(&heuristicEngine{ai: NewAIService()})
+}
@@ -0,0 +1,154 @@
+// Unit tests for the engine interface and registry
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestEngineRegistry(t *testing.T) {
+	for _, name := range []string{"heuristic", "minimax", "mcts"} {
+		engine, exists := GetEngine(name)
+		if !exists {
+			t.Errorf("Expected engine %q to be registered", name)
+			continue
+		}
+		if engine.Name() != name {
+			t.Errorf("Expected engine name %q, got %q", name, engine.Name())
+		}
+	}
+
+	if _, exists := GetEngine("missing"); exists {
+		t.Error("Expected unknown engine lookup to fail")
+	}
+
+	engines := Engines()
+	for i := 1; i < len(engines); i++ {
+		if engines[i-1].Name() >= engines[i].Name() {
+			t.Errorf("Expected engines sorted by name, got %q before %q", engines[i-1].Name(), engines[i].Name())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a duplicate name to panic")
+		}
+	}()
+	RegisterEngine(NewMCTSService())
+}
+
+func TestEngineBestMove(t *testing.T) {
+	position := Position{Board: createComplexBoard(), LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}
+
+	for _, engine := range Engines() {
+		move, err := engine.BestMove(context.Background(), position, SearchLimits{Difficulty: Easy})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", engine.Name(), err)
+			continue
+		}
+		if move.X < 0 || move.X >= 15 || move.Y < 0 || move.Y >= 15 || position.Board[move.Y][move.X] != 0 {
+			t.Errorf("%s: illegal move (%d,%d)", engine.Name(), move.X, move.Y)
+		}
+	}
+
+	// Invalid input is rejected
+	engine, _ := GetEngine("heuristic")
+	if _, err := engine.BestMove(context.Background(), Position{Board: createEmptyBoard(), ToMove: 3}, SearchLimits{}); err == nil {
+		t.Error("Expected an error for an invalid side to move")
+	}
+	if _, err := engine.BestMove(context.Background(), position, SearchLimits{Depth: 4}); err == nil {
+		t.Error("Expected an error for a depth limit the engine does not support")
+	}
+}
+
+func TestMinimaxSearchLimits(t *testing.T) {
+	ai := NewEnhancedAIService()
+	position := Position{Board: createCenterBoard(), LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}
+
+	// A node limit stops the search close to the limit
+	if _, err := ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Expert, Nodes: 5000}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if nodes := ai.GetStats()["nodes_searched"].(uint64); nodes > 5000+4*stopCheckInterval {
+		t.Errorf("Expected about 5000 nodes, searched %d", nodes)
+	}
+
+	// A depth limit bounds the principal variation
+	ai.ClearTranspositionTable()
+	move, _ := ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Expert, Depth: 2})
+	if len(move.PV) == 0 || len(move.PV) > 2 {
+		t.Errorf("Expected a principal variation of at most 2 moves, got %d", len(move.PV))
+	}
+
+	// A cancelled context returns promptly with a legal move
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	move, _ = ai.BestMove(ctx, position, SearchLimits{Difficulty: Expert})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected a cancelled search to return promptly, took %v", elapsed)
+	}
+	if position.Board[move.Y][move.X] != 0 {
+		t.Errorf("Expected a legal move after cancellation, got (%d,%d)", move.X, move.Y)
+	}
+}
+
+func TestMinimaxMoveStats(t *testing.T) {
+	ai := NewEnhancedAIService()
+	position := Position{Board: createCenterBoard(), LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}
+
+	// Concurrent searches on one engine each report their own node count
+	limits := []int64{2000, 40000}
+	moves := make([]model.AIMove, len(limits))
+	var wg sync.WaitGroup
+	for i, nodes := range limits {
+		wg.Add(1)
+		go func(i int, nodes int64) {
+			defer wg.Done()
+			moves[i], _ = ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Expert, Nodes: nodes})
+		}(i, nodes)
+	}
+	wg.Wait()
+
+	small, _ := moves[0].Stats["nodes_searched"].(uint64)
+	large, _ := moves[1].Stats["nodes_searched"].(uint64)
+	if small == 0 || small > 2000+4*stopCheckInterval {
+		t.Errorf("Expected about 2000 nodes in the small search's stats, got %v", moves[0].Stats)
+	}
+	if large <= small {
+		t.Errorf("Expected the large search to report more nodes, got %d and %d", small, large)
+	}
+}
+
+func TestMinimaxSideToMoveKeys(t *testing.T) {
+	// Equal stone counts, so either side may be to move
+	board := createEmptyBoard()
+	board[4][4], board[4][8], board[7][10], board[8][5] = 2, 2, 2, 2
+	board[4][5], board[8][7], board[9][9], board[9][10] = 1, 1, 1, 1
+	limits := SearchLimits{Difficulty: Medium, Depth: 4}
+
+	// One engine searches the board for black and then for white; white's search must not
+	// take black's entries for its own
+	shared := NewEnhancedAIService()
+	for _, player := range []int{1, 2} {
+		position := Position{Board: copyBoard(board), LastMove: model.Move{X: -1, Y: -1}, ToMove: player}
+		got, _ := shared.BestMove(context.Background(), position, limits)
+		want, _ := NewEnhancedAIService().BestMove(context.Background(), position, limits)
+		if got.Score != want.Score {
+			t.Errorf("Player %d: shared engine scored %d, fresh engine %d", player, got.Score, want.Score)
+		}
+	}
+}
+
+func TestSharedTranspositionTableMemory(t *testing.T) {
+	engine, _ := GetEngine("minimax")
+	bytes := len(engine.(*EnhancedAIService).transpositionTable.slots) * int(unsafe.Sizeof(ttSlot{}))
+	if bytes > SharedTTMemory || bytes <= SharedTTMemory/4 {
+		t.Errorf("Expected the shared table to use up to %d bytes, got %d", SharedTTMemory, bytes)
+	}
+}
@@ -3,6 +3,7 @@ package service
 
 import (
 	"fmt"
+	"runtime"
 	"testing"
 	"time"
 
@@ -69,7 +70,7 @@ func BenchmarkEvaluateBoard(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		ai.evaluateBoard(board, lastMove)
+		ai.evaluateBoard(board, lastMove, 2)
 	}
 }
 
@@ -96,9 +97,7 @@ func BenchmarkTranspositionTable(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		hash := ai.hashBoard(board)
-		ai.tableMutex.RLock()
-		_ = ai.transpositionTable[hash]
-		ai.tableMutex.RUnlock()
+		ai.transpositionTable.probe(hash)
 	}
 }
 
@@ -182,6 +181,13 @@ func TestPerformanceComparison(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			// Baseline: unordered (raster) move generation
+			ai.ClearTranspositionTable()
+			ai.moveOrdering = false
+			ai.GetAIMove(test.board, test.lastMove, test.difficulty)
+			before := ai.GetStats()
+			ai.moveOrdering = true
+
 			// Clear table for fair comparison
 			ai.ClearTranspositionTable()
 
@@ -202,6 +208,10 @@ func TestPerformanceComparison(t *testing.T) {
 			t.Logf("Nodes searched: %v", stats["nodes_searched"])
 			t.Logf("Cutoffs: %v", stats["cutoffs"])
 			t.Logf("Table entries: %v", stats["table_entries"])
+			t.Logf("Nodes before/after ordering: %v / %v", before["nodes_searched"], stats["nodes_searched"])
+			t.Logf("Cutoff ratio before/after ordering: %.3f / %.3f", cutoffRatio(before), cutoffRatio(stats))
+			t.Logf("First-move cutoff rate before/after ordering: %.3f / %.3f",
+				firstMoveCutoffRate(before), firstMoveCutoffRate(stats))
 
 			// Validate move
 			if move.X < 0 || move.X >= 15 || move.Y < 0 || move.Y >= 15 {
@@ -218,6 +228,24 @@ func TestPerformanceComparison(t *testing.T) {
 	}
 }
 
+// cutoffRatio returns the fraction of searched nodes that produced a cutoff
+func cutoffRatio(stats map[string]interface{}) float64 {
+	nodes := stats["nodes_searched"].(uint64)
+	if nodes == 0 {
+		return 0
+	}
+	return float64(stats["cutoffs"].(uint64)) / float64(nodes)
+}
+
+// firstMoveCutoffRate returns the fraction of cutoffs produced by the first move tried
+func firstMoveCutoffRate(stats map[string]interface{}) float64 {
+	cutoffs := stats["cutoffs"].(uint64)
+	if cutoffs == 0 {
+		return 0
+	}
+	return float64(stats["first_move_cutoffs"].(uint64)) / float64(cutoffs)
+}
+
 // TestStressTest performs stress testing with multiple rapid moves
 func TestStressTest(t *testing.T) {
 	ai := NewEnhancedAIService()
@@ -357,6 +385,49 @@ func BenchmarkComparison(b *testing.B) {
 	// })
 }
 
+// BenchmarkLazySMPSpeedup measures how a fixed-depth search scales with 1, 2, 4 and 8 threads.
+// Each sub-benchmark reports its speedup over the single-threaded run.
+func BenchmarkLazySMPSpeedup(b *testing.B) {
+	SetMaxSearchThreads(8)
+	defer SetMaxSearchThreads(runtime.NumCPU())
+
+	// A quiet opening leaves no forcing line, so every depth is searched in full
+	board := createCenterBoard()
+	board[8][8] = 2
+	board[6][8] = 1
+	lastMove := model.Move{X: 8, Y: 6}
+	var baseline float64
+
+	for _, threads := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Threads_%d", threads), func(b *testing.B) {
+			ai := NewEnhancedAIService()
+			ai.timeLimit = time.Minute
+			ai.SetSearchThreads(Hard, threads)
+
+			var nodes uint64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				ai.ClearTranspositionTable()
+				b.StartTimer()
+
+				ai.GetAIMove(board, lastMove, Hard)
+				nodes += ai.GetStats()["nodes_searched"].(uint64)
+			}
+			b.StopTimer()
+
+			perOp := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+			if threads == 1 {
+				baseline = perOp
+			}
+			if baseline > 0 {
+				b.ReportMetric(baseline/perOp, "speedup")
+			}
+			b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
+
 // TestAIStrength provides a basic strength test
 func TestAIStrength(t *testing.T) {
 	ai := NewEnhancedAIService()
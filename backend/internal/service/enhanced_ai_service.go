@@ -1,14 +1,12 @@
 // Package service contains enhanced AI algorithms for the Gomoku game
-// This package implements minimax algorithm with alpha-beta pruning and transposition table
+// This package implements principal variation search with aspiration windows and a transposition table
 package service
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
+	"context"
 	"math"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gomoku-backend/internal/model"
@@ -24,367 +22,684 @@ const (
 	Expert
 )
 
-// TranspositionTableEntry stores evaluated board positions
-type TranspositionTableEntry struct {
-	Score    int
-	Depth    int
-	Flag     EntryFlag
-	BestMove model.Move
+// String returns the difficulty's name as used in requests
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Hard:
+		return "hard"
+	case Expert:
+		return "expert"
+	default:
+		return "medium"
+	}
 }
 
-// EntryFlag indicates the type of score stored
-type EntryFlag int
-
 const (
-	Exact EntryFlag = iota
-	LowerBound
-	UpperBound
+	// mateScore is the score of a win at ply 0; wins found deeper score lower,
+	// so every proven win scores above winScore and shorter wins are preferred
+	mateScore = winScore + maxSearchPly
+
+	// aspirationWindow is the initial half-width of the window around the previous score
+	aspirationWindow = 200
+
+	// infinity bounds the search window
+	infinity = math.MaxInt32
 )
 
 // EnhancedAIService handles AI move generation with advanced algorithms
 type EnhancedAIService struct {
-	boardSize       int
-	maxDepth        map[Difficulty]int
-	transpositionTable map[string]*TranspositionTableEntry
-	tableMutex      sync.RWMutex
-	searchStartTime time.Time
-	timeLimit       time.Duration
-	nodesSearched   uint64
-	cutoffs         uint64
+	boardSize          int
+	maxDepth           map[Difficulty]int
+	threads            map[Difficulty]int
+	networks           map[Difficulty]*NeuralNetwork
+	transpositionTable *transpositionTable
+	book               *OpeningBook
+	evalWeights        EvalWeights
+	weights            *evalTable
+	personality        *Personality
+	styleTables        [3]*evalTable // Personality evaluation per side, indexed by the searching player
+	timeLimit          time.Duration
+	moveOrdering       bool
+	statsMutex         sync.Mutex
+	searchStartTime    time.Time
+	nodesSearched      uint64
+	cutoffs            uint64
+	firstMoveCutoffs   uint64
+}
+
+// search holds the state shared by all workers of one move search
+type search struct {
+	ai        *EnhancedAIService
+	ctx       context.Context
+	player    int // Side to move at the root
+	startTime time.Time
+	timeLimit time.Duration
+	nodeLimit int64 // 0 means no limit
+	weights   *evalTable
+	network   *NeuralNetwork // Replaces the pattern evaluation when set
+	salt      uint64         // Keys this evaluation's transposition entries
+	// Move ordering weights: percentages of the attack and defence shape scores and a bonus
+	// per broken shape formed
+	orderAttack  int
+	orderDefence int
+	orderBroken  int
+	nodes        atomic.Int64
+	stopped      atomic.Bool
+}
+
+// stopCheckInterval is how many nodes a worker searches between limit checks
+const stopCheckInterval = 256
+
+// searchWorker is one search thread with its own board copy and move-ordering tables.
+// Workers of the same search share only the transposition table (Lazy SMP).
+type searchWorker struct {
+	search           *search
+	ai               *EnhancedAIService
+	id               int
+	board            [][]int
+	pb               *patternBoard
+	nodes            uint64
+	cutoffs          uint64
+	firstMoveCutoffs uint64
+	killers          [maxSearchPly][2]model.Move
+	history          [3][]int
+	pvTable          [maxSearchPly + 1][maxSearchPly + 1]model.Move
+	pvLength         [maxSearchPly + 1]int
+	excluded         []model.Move // Root moves left out, for multi-PV analysis
 }
 
 // NewEnhancedAIService creates a new enhanced AI service instance
 func NewEnhancedAIService() *EnhancedAIService {
 	return &EnhancedAIService{
-		boardSize:    15,
+		boardSize: 15,
 		maxDepth: map[Difficulty]int{
-			Easy:   2,  // Quick look-ahead
-			Medium: 4,  // Moderate depth
-			Hard:   6,  // Deep analysis
-			Expert: 8,  // Maximum depth with time limit
+			Easy:   2, // Quick look-ahead
+			Medium: 4, // Moderate depth
+			Hard:   6, // Deep analysis
+			Expert: 8, // Maximum depth with time limit
+		},
+		threads: map[Difficulty]int{
+			Easy:   1,
+			Medium: 1,
+			Hard:   2,
+			Expert: 4,
 		},
-		transpositionTable: make(map[string]*TranspositionTableEntry),
+		networks:           make(map[Difficulty]*NeuralNetwork),
+		transpositionTable: newTranspositionTable(defaultTTMemory),
+		evalWeights:        DefaultEvalWeights(),
+		weights:            defaultEvalTable,
 		timeLimit:          5 * time.Second, // 5 second thinking time
+		moveOrdering:       true,
 	}
 }
 
-// GetAIMove generates the best move for the AI using minimax with alpha-beta pruning
-func (ai *EnhancedAIService) GetAIMove(board [][]int, lastMove model.Move, difficulty Difficulty) model.AIMove {
-	ai.searchStartTime = time.Now()
-	ai.nodesSearched = 0
-	ai.cutoffs = 0
+func init() {
+	ai := NewEnhancedAIService()
+	ai.SetTranspositionTableMemory(SharedTTMemory)
+	ai.SetOpeningBook(NewDefaultOpeningBook())
+	RegisterEngine(ai)
+}
 
-	// Get available moves
-	moves := ai.getAvailableMoves(board, lastMove)
-	if len(moves) == 0 {
-		return model.AIMove{X: 7, Y: 7, Score: -1}
-	}
+// SetSearchThreads sets how many threads a search at the given difficulty asks for.
+// Threads beyond the first are granted from the global budget set by SetMaxSearchThreads.
+func (ai *EnhancedAIService) SetSearchThreads(difficulty Difficulty, threads int) {
+	ai.statsMutex.Lock()
+	defer ai.statsMutex.Unlock()
 
-	// For easy difficulty, use simple heuristic
-	if difficulty == Easy {
-		return ai.getHeuristicMove(board, lastMove, moves)
+	ai.threads[difficulty] = max(threads, 1)
+}
+
+// SetNeuralNetwork makes searches at the given difficulty evaluate with the network instead
+// of the pattern evaluation; nil restores the pattern evaluation. Searches that select their
+// own weights keep using the patterns.
+func (ai *EnhancedAIService) SetNeuralNetwork(difficulty Difficulty, network *NeuralNetwork) {
+	ai.statsMutex.Lock()
+	defer ai.statsMutex.Unlock()
+
+	if network == nil {
+		delete(ai.networks, difficulty)
+		return
 	}
+	ai.networks[difficulty] = network
+}
 
-	// For medium and above, use minimax with alpha-beta
-	maxDepth := ai.maxDepth[difficulty]
+// NeuralNetwork returns the network used at the given difficulty, or nil for the patterns
+func (ai *EnhancedAIService) NeuralNetwork(difficulty Difficulty) *NeuralNetwork {
+	ai.statsMutex.Lock()
+	defer ai.statsMutex.Unlock()
 
-	var bestMove model.Move
-	var bestScore int = math.MinInt32
+	return ai.networks[difficulty]
+}
 
-	// Iterative deepening with time limit
-	for depth := 1; depth <= maxDepth; depth++ {
-		if time.Since(ai.searchStartTime) > ai.timeLimit {
-			break
-		}
+// SetOpeningBook sets the opening book consulted before searching; nil disables it.
+// It should be called before the service starts answering requests.
+func (ai *EnhancedAIService) SetOpeningBook(book *OpeningBook) {
+	ai.book = book
+}
 
-		score, move := ai.minimax(board, depth, math.MinInt32, math.MaxInt32, true, lastMove)
+// OpeningBook returns the opening book in use, or nil
+func (ai *EnhancedAIService) OpeningBook() *OpeningBook {
+	return ai.book
+}
 
-		if score > bestScore {
-			bestScore = score
-			bestMove = move
+// GetAIMove generates the best move for the AI playing white (2)
+func (ai *EnhancedAIService) GetAIMove(board [][]int, lastMove model.Move, difficulty Difficulty) model.AIMove {
+	return ai.GetMoveForPlayer(board, lastMove, 2, difficulty)
+}
+
+// GetMoveForPlayer generates the best move for the given side to move using iterative
+// deepening principal variation search. lastMove may be {-1, -1} when nothing has been played.
+func (ai *EnhancedAIService) GetMoveForPlayer(board [][]int, lastMove model.Move, player int, difficulty Difficulty) model.AIMove {
+	return ai.searchMove(context.Background(), board, lastMove, player, SearchLimits{Difficulty: difficulty})
+}
+
+// Name returns the registry name
+func (ai *EnhancedAIService) Name() string {
+	return "minimax"
+}
+
+// Capabilities describes the minimax engine
+func (ai *EnhancedAIService) Capabilities() EngineCapabilities {
+	return EngineCapabilities{
+		Description: "Principal variation search with pattern or neural evaluation, Lazy SMP and an opening book",
+		Difficulty:  true,
+		Depth:       true,
+		Nodes:       true,
+		Time:        true,
+		PV:          true,
+		OpeningBook: ai.book != nil,
+		Weights:     true,
+	}
+}
+
+// BestMove searches the position within the limits
+func (ai *EnhancedAIService) BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error) {
+	if err := position.Validate(); err != nil {
+		return model.AIMove{}, err
+	}
+	if err := limits.Validate(ai.Capabilities()); err != nil {
+		return model.AIMove{}, err
+	}
+	return ai.searchMove(ctx, position.Board, position.LastMove, position.ToMove, limits), nil
+}
+
+// searchMove runs the search for the side to move. Depth, node and time limits override the
+// difficulty's defaults; Easy plays the heuristic move unless a depth or node limit is given.
+func (ai *EnhancedAIService) searchMove(ctx context.Context, board [][]int, lastMove model.Move, player int, limits SearchLimits) model.AIMove {
+	s := ai.newSearch(ctx, player, limits)
+	difficulty := limits.Difficulty
+
+	// Get available moves
+	moves := ai.getAvailableMoves(board, lastMove)
+	if len(moves) == 0 {
+		return model.AIMove{X: 7, Y: 7, Score: -1, Stats: ai.recordStats(s, nil)}
+	}
+
+	// Play from the opening book while the position is known
+	if ai.book != nil {
+		if move, ok := ai.book.Pick(board, player); ok {
+			return model.AIMove{X: move.X, Y: move.Y, PV: []model.Move{move}, FromBook: true, Stats: ai.recordStats(s, nil)}
 		}
+	}
 
-		// If we found a winning move, no need to search deeper
-		if score >= 100000 {
-			break
+	// For easy difficulty, use simple heuristic
+	if difficulty == Easy && limits.Depth == 0 && limits.Nodes == 0 {
+		move := ai.getHeuristicMove(board, moves, player)
+		move.Stats = ai.recordStats(s, nil)
+		return move
+	}
+
+	// For medium and above, search with iterative deepening on the main worker
+	// while helper workers search the same tree and fill the shared table
+	maxDepth := ai.searchDepth(limits)
+	ai.statsMutex.Lock()
+	requested := ai.threads[difficulty]
+	ai.statsMutex.Unlock()
+
+	helpers := searchThreads.acquire(requested - 1)
+	workers := []*searchWorker{ai.newSearchWorker(s, board, 0)}
+	var wg sync.WaitGroup
+	for id := 1; id <= helpers; id++ {
+		worker := ai.newSearchWorker(s, board, id)
+		workers = append(workers, worker)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.iterate(maxDepth, lastMove)
+		}()
+	}
+
+	main := workers[0]
+	bestScore, pv := main.iterate(maxDepth, lastMove)
+
+	s.stopped.Store(true)
+	wg.Wait()
+	searchThreads.release(helpers)
+	stats := ai.recordStats(s, workers)
+
+	// Fall back to the best ordered move if no iteration completed
+	if len(pv) == 0 {
+		fallback := moves[0]
+		if ordered := main.orderMoves(lastMove, player, model.Move{X: -1, Y: -1}, 0); len(ordered) > 0 {
+			fallback = ordered[0]
 		}
+		pv = []model.Move{fallback}
 	}
 
-	// Fallback to center if no move found
-	if bestMove.X == -1 && len(moves) > 0 {
-		bestMove = moves[0]
+	// Label the line with the side playing each move
+	side := player
+	for i := range pv {
+		pv[i].Player = side
+		side = 3 - side
 	}
 
 	return model.AIMove{
-		X:     bestMove.X,
-		Y:     bestMove.Y,
+		X:     pv[0].X,
+		Y:     pv[0].Y,
 		Score: bestScore,
+		PV:    pv,
+		Stats: stats,
 	}
 }
 
-// minimax implements the minimax algorithm with alpha-beta pruning
-func (ai *EnhancedAIService) minimax(board [][]int, depth, alpha, beta int, isMaximizing bool, lastMove model.Move) (int, model.Move) {
-	ai.nodesSearched++
+// newSearch prepares the shared state of a search for the side to move within the limits
+func (ai *EnhancedAIService) newSearch(ctx context.Context, player int, limits SearchLimits) *search {
+	s := &search{
+		ai:           ai,
+		ctx:          ctx,
+		player:       player,
+		startTime:    time.Now(),
+		timeLimit:    ai.timeLimit,
+		nodeLimit:    limits.Nodes,
+		weights:      ai.weights,
+		orderAttack:  100,
+		orderDefence: 50,
+	}
+	if limits.Time > 0 {
+		s.timeLimit = limits.Time
+	}
+	if p := ai.personality; p != nil {
+		s.orderAttack, s.orderDefence, s.orderBroken = p.OrderAttack, p.OrderDefence, p.OrderBroken
+	}
+	switch {
+	case limits.Weights != nil:
+		s.weights = limits.Weights.table()
+	case ai.personality != nil:
+		s.weights = ai.styleTables[player]
+	default:
+		s.network = ai.NeuralNetwork(limits.Difficulty)
+	}
+	s.salt = s.weights.salt
+	if s.network != nil {
+		s.salt = s.network.salt
+	}
+	return s
+}
 
-	// Check time limit
-	if time.Since(ai.searchStartTime) > ai.timeLimit {
-		return 0, model.Move{X: -1, Y: -1}
+// searchDepth returns the deepest iteration the limits allow; Easy searches like Medium
+// when asked to search at all
+func (ai *EnhancedAIService) searchDepth(limits SearchLimits) int {
+	if limits.Depth > 0 {
+		return min(limits.Depth, maxSearchPly-1)
 	}
-
-	// Check terminal conditions
-	if score := ai.evaluateTerminal(board, lastMove); score != math.MinInt32 {
-		return score, model.Move{X: -1, Y: -1}
+	if limits.Difficulty == Easy {
+		return ai.maxDepth[Medium]
 	}
+	return ai.maxDepth[limits.Difficulty]
+}
 
-	// Check depth limit
-	if depth == 0 {
-		return ai.evaluateBoard(board, lastMove), model.Move{X: -1, Y: -1}
-	}
-
-	// Check transposition table
-	boardHash := ai.hashBoard(board)
-	ai.tableMutex.RLock()
-	if entry, exists := ai.transpositionTable[boardHash]; exists && entry.Depth >= depth {
-		ai.tableMutex.RUnlock()
-
-		switch entry.Flag {
-		case Exact:
-			return entry.Score, entry.BestMove
-		case LowerBound:
-			if entry.Score > alpha {
-				alpha = entry.Score
-			}
-		case UpperBound:
-			if entry.Score < beta {
-				beta = entry.Score
-			}
-		}
+// newSearchWorker creates a worker with a private copy of the board
+func (ai *EnhancedAIService) newSearchWorker(s *search, board [][]int, id int) *searchWorker {
+	grid := make([][]int, len(board))
+	for i := range board {
+		grid[i] = make([]int, len(board[i]))
+		copy(grid[i], board[i])
+	}
+
+	w := &searchWorker{
+		search: s,
+		ai:     ai,
+		id:     id,
+		board:  grid,
+		pb:     newPatternBoard(grid),
+	}
+	w.pb.weights = s.weights
+	w.pb.attachNetwork(s.network)
+	w.resetOrdering()
+	return w
+}
 
-		if alpha >= beta {
-			ai.cutoffs++
-			return entry.Score, entry.BestMove
-		}
-	} else {
-		ai.tableMutex.RUnlock()
+// recordStats publishes the counters of a finished search as the last-search statistics and
+// returns them for the caller
+func (ai *EnhancedAIService) recordStats(s *search, workers []*searchWorker) map[string]interface{} {
+	var nodes, cutoffs, firstMoveCutoffs uint64
+	for _, w := range workers {
+		nodes += w.nodes
+		cutoffs += w.cutoffs
+		firstMoveCutoffs += w.firstMoveCutoffs
 	}
 
-	moves := ai.getAvailableMoves(board, lastMove)
-	if len(moves) == 0 {
-		return 0, model.Move{X: -1, Y: -1}
+	ai.statsMutex.Lock()
+	ai.searchStartTime = s.startTime
+	ai.nodesSearched, ai.cutoffs, ai.firstMoveCutoffs = nodes, cutoffs, firstMoveCutoffs
+	ai.statsMutex.Unlock()
+
+	return ai.searchStats(nodes, cutoffs, firstMoveCutoffs, time.Since(s.startTime))
+}
+
+// shouldStop reports whether the search has been stopped, cancelled or has run out of time or nodes
+func (s *search) shouldStop() bool {
+	if s.stopped.Load() {
+		return true
+	}
+	if time.Since(s.startTime) > s.timeLimit || s.ctx.Err() != nil ||
+		(s.nodeLimit > 0 && s.nodes.Load() >= s.nodeLimit) {
+		s.stopped.Store(true)
+		return true
 	}
+	return false
+}
 
-	var bestMove model.Move
-	var bestScore int
+// checkStop counts the worker's nodes into the shared total and checks the limits every
+// stopCheckInterval nodes
+func (w *searchWorker) checkStop() bool {
+	if w.nodes%stopCheckInterval == 0 {
+		w.search.nodes.Add(stopCheckInterval)
+		return w.search.shouldStop()
+	}
+	return w.search.stopped.Load()
+}
 
-	if isMaximizing {
-		bestScore = math.MinInt32
-		for _, move := range moves {
-			// Make move
-			board[move.Y][move.X] = 2 // AI piece
+// iterate runs iterative deepening up to maxDepth and returns the score and principal
+// variation of the deepest completed iteration. Odd helpers skip depth 1 so that the
+// workers spread out over different depths.
+func (w *searchWorker) iterate(maxDepth int, lastMove model.Move) (int, []model.Move) {
+	var pv []model.Move
+	bestScore := 0
 
-			score, _ := ai.minimax(board, depth-1, alpha, beta, false, move)
+	for depth := 1 + w.id%2; depth <= maxDepth; depth++ {
+		if w.search.shouldStop() {
+			break
+		}
 
-			// Undo move
-			board[move.Y][move.X] = 0
+		score, line := w.searchRoot(depth, bestScore, lastMove, pv)
+		if w.search.stopped.Load() {
+			break
+		}
 
-			if score > bestScore {
-				bestScore = score
-				bestMove = move
-			}
+		bestScore = score
+		if len(line) > 0 {
+			pv = line
+		}
 
-			alpha = max(alpha, bestScore)
-			if beta <= alpha {
-				ai.cutoffs++
-				break // Beta cutoff
-			}
+		// A proven win or loss will not change with more depth
+		if abs(score) >= winScore {
+			break
 		}
-	} else {
-		bestScore = math.MaxInt32
-		for _, move := range moves {
-			// Make move
-			board[move.Y][move.X] = 1 // Human piece
+	}
 
-			score, _ := ai.minimax(board, depth-1, alpha, beta, true, move)
+	return bestScore, pv
+}
 
-			// Undo move
-			board[move.Y][move.X] = 0
+// searchRoot runs one iteration at the given depth inside an aspiration window around the
+// previous score, widening the window whenever the result falls outside it
+func (w *searchWorker) searchRoot(depth, previous int, lastMove model.Move, pv []model.Move) (int, []model.Move) {
+	alpha, beta := -infinity, infinity
+	window := aspirationWindow
+	if depth >= 3 && abs(previous) < winScore {
+		alpha, beta = previous-window, previous+window
+	}
 
-			if score < bestScore {
-				bestScore = score
-				bestMove = move
-			}
+	for {
+		score := w.negamax(depth, 0, alpha, beta, w.search.player, lastMove, pv)
+		if w.search.stopped.Load() {
+			return 0, nil
+		}
 
-			beta = min(beta, bestScore)
-			if beta <= alpha {
-				ai.cutoffs++
-				break // Alpha cutoff
-			}
+		switch {
+		case score <= alpha && alpha > -infinity:
+			window *= 4
+			alpha = max(score-window, -infinity)
+		case score >= beta && beta < infinity:
+			window *= 4
+			beta = min(score+window, infinity)
+		default:
+			line := make([]model.Move, w.pvLength[0])
+			copy(line, w.pvTable[0][:w.pvLength[0]])
+			return score, line
 		}
 	}
+}
 
-	// Store result in transposition table
-	flag := Exact
-	if bestScore <= alpha {
-		flag = UpperBound
-	} else if bestScore >= beta {
-		flag = LowerBound
+// negamax implements principal variation search with alpha-beta pruning.
+// Scores are from the point of view of the player to move; prevPV is the principal
+// variation of the previous iteration and is tried first at the root.
+func (w *searchWorker) negamax(depth, ply, alpha, beta, player int, lastMove model.Move, prevPV []model.Move) int {
+	w.nodes++
+	w.pvLength[ply] = 0
+	pb := w.pb
+	tt := w.ai.transpositionTable
+
+	// Check search limits
+	if w.checkStop() {
+		return 0
 	}
 
-	ai.tableMutex.Lock()
-	ai.transpositionTable[boardHash] = &TranspositionTableEntry{
-		Score:    bestScore,
-		Depth:    depth,
-		Flag:     flag,
-		BestMove: bestMove,
+	// The previous move may have ended the game
+	if lastMove.X >= 0 && lastMove.Y >= 0 && pb.isFive(lastMove.X, lastMove.Y) {
+		return -(mateScore - ply)
+	}
+	if pb.isFull() {
+		return 0
 	}
-	ai.tableMutex.Unlock()
 
-	return bestScore, bestMove
-}
+	// Check depth limit
+	if depth <= 0 || ply >= maxSearchPly {
+		return pb.evaluate(player)
+	}
 
-// evaluateTerminal checks for terminal game states (win/loss/draw)
-func (ai *EnhancedAIService) evaluateTerminal(board [][]int, lastMove model.Move) int {
-	// Check if last move created a win
-	if lastMove.X >= 0 && lastMove.Y >= 0 {
-		player := board[lastMove.Y][lastMove.X]
-		if player != 0 && ai.checkWin(board, lastMove.X, lastMove.Y, player) {
-			if player == 2 { // AI wins
-				return 100000
-			} else { // Human wins
-				return -100000
+	// Check transposition table; a shallower entry still supplies a move to try first
+	alphaOrig := alpha
+	ttMove := model.Move{X: -1, Y: -1}
+	if ply == 0 && len(prevPV) > 0 {
+		ttMove = prevPV[0]
+	}
+	// Entries are keyed by the side to move and the evaluation as well as the stones
+	key := pb.hash ^ zobristSide[player] ^ w.search.salt
+	if entry, exists := tt.probe(key); exists {
+		if ttMove.X < 0 {
+			ttMove = entry.BestMove
+		}
+		if entry.Depth >= depth && ply > 0 {
+			score := scoreFromTT(entry.Score, ply)
+			switch entry.Flag {
+			case Exact:
+				return score
+			case LowerBound:
+				alpha = max(alpha, score)
+			case UpperBound:
+				beta = min(beta, score)
+			}
+			if alpha >= beta {
+				w.cutoffs++
+				return score
 			}
 		}
 	}
 
-	// Check for draw
-	if ai.isBoardFull(board) {
+	var moves []model.Move
+	if w.ai.moveOrdering {
+		moves = w.orderMoves(lastMove, player, ttMove, ply)
+	} else {
+		moves = w.ai.getAvailableMoves(w.board, lastMove)
+	}
+	if len(moves) == 0 {
 		return 0
 	}
 
-	return math.MinInt32 // Not a terminal state
-}
+	opponent := 3 - player
+	bestScore := -infinity
+	bestMove := moves[0]
+	searched := 0
 
-// evaluateBoard provides a comprehensive evaluation of the board state
-func (ai *EnhancedAIService) evaluateBoard(board [][]int, lastMove model.Move) int {
-	score := 0
+	for i, move := range moves {
+		if ply == 0 && w.isExcluded(move) {
+			continue
+		}
+		searched++
 
-	// Evaluate all positions
-	for y := 0; y < ai.boardSize; y++ {
-		for x := 0; x < ai.boardSize; x++ {
-			if board[y][x] != 0 {
-				pieceScore := ai.evaluatePositionAdvanced(board, x, y, board[y][x])
-				if board[y][x] == 2 { // AI piece
-					score += pieceScore
-				} else { // Human piece
-					score -= pieceScore
-				}
+		// Make move
+		w.board[move.Y][move.X] = player
+		pb.place(move.X, move.Y, player)
+
+		var score int
+		if searched == 1 {
+			score = -w.negamax(depth-1, ply+1, -beta, -alpha, opponent, move, nil)
+		} else {
+			// Null-window search to prove the move is no better than the current best
+			score = -w.negamax(depth-1, ply+1, -alpha-1, -alpha, opponent, move, nil)
+			if score > alpha && score < beta {
+				score = -w.negamax(depth-1, ply+1, -beta, -alpha, opponent, move, nil)
 			}
 		}
-	}
 
-	// Add positional bonuses
-	centerX, centerY := ai.boardSize/2, ai.boardSize/2
-	if lastMove.X >= 0 && lastMove.Y >= 0 {
-		distanceToCenter := abs(centerX-lastMove.X) + abs(centerY-lastMove.Y)
-		score += (7 - distanceToCenter) * 2 // Prefer center positions
-	}
+		// Undo move
+		w.board[move.Y][move.X] = 0
+		pb.remove(move.X, move.Y)
 
-	return score
-}
+		if w.search.stopped.Load() {
+			return 0
+		}
 
-// evaluatePositionAdvanced provides advanced position evaluation
-func (ai *EnhancedAIService) evaluatePositionAdvanced(board [][]int, x, y, player int) int {
-	directions := [][]int{
-		{1, 0},   // Horizontal
-		{0, 1},   // Vertical
-		{1, 1},   // Diagonal \
-		{1, -1},  // Diagonal /
+		if score > bestScore {
+			bestScore = score
+			bestMove = move
+		}
+		if score > alpha {
+			alpha = score
+			w.updatePV(ply, move)
+		}
+		if alpha >= beta {
+			w.recordCutoff(move, player, depth, ply, i)
+			break
+		}
 	}
 
-	totalScore := 0
-	opponent := 3 - player
-
-	for _, dir := range directions {
-		pattern := ai.getPattern(board, x, y, dir[0], dir[1], player)
-		score := ai.evaluatePattern(pattern, player, opponent)
-		totalScore += score
+	if searched == 0 {
+		return -infinity
 	}
 
-	return totalScore
-}
+	// A root searched without some moves has no score to share
+	if ply == 0 && len(w.excluded) > 0 {
+		return bestScore
+	}
 
-// getPattern extracts the pattern around a position in a specific direction
-func (ai *EnhancedAIService) getPattern(board [][]int, x, y, dx, dy, player int) string {
-	pattern := make([]rune, 9) // 4 spaces + piece + 4 spaces
+	// Store result in transposition table
+	flag := Exact
+	if bestScore <= alphaOrig {
+		flag = UpperBound
+	} else if bestScore >= beta {
+		flag = LowerBound
+	}
 
-	// Center is current position
-	pattern[4] = rune(player + '0')
+	tt.store(key, TranspositionTableEntry{
+		Score:    scoreToTT(bestScore, ply),
+		Depth:    depth,
+		Flag:     flag,
+		BestMove: bestMove,
+	})
 
-	// Build pattern in both directions
-	for i := 1; i <= 4; i++ {
-		// Positive direction
-		px, py := x+dx*i, y+dy*i
-		if px >= 0 && px < ai.boardSize && py >= 0 && py < ai.boardSize {
-			pattern[4+i] = rune(board[py][px] + '0')
-		} else {
-			pattern[4+i] = 'X' // Out of bounds
-		}
+	return bestScore
+}
 
-		// Negative direction
-		nx, ny := x-dx*i, y-dy*i
-		if nx >= 0 && nx < ai.boardSize && ny >= 0 && ny < ai.boardSize {
-			pattern[4-i] = rune(board[ny][nx] + '0')
-		} else {
-			pattern[4-i] = 'X' // Out of bounds
+// isExcluded reports whether a root move is left out of the search
+func (w *searchWorker) isExcluded(move model.Move) bool {
+	for _, excluded := range w.excluded {
+		if excluded.X == move.X && excluded.Y == move.Y {
+			return true
 		}
 	}
+	return false
+}
 
-	return string(pattern)
+// updatePV makes the move followed by the child's line the principal variation at this ply
+func (w *searchWorker) updatePV(ply int, move model.Move) {
+	w.pvTable[ply][0] = move
+	copy(w.pvTable[ply][1:], w.pvTable[ply+1][:w.pvLength[ply+1]])
+	w.pvLength[ply] = w.pvLength[ply+1] + 1
 }
 
-// evaluatePattern evaluates a pattern and returns a score
-func (ai *EnhancedAIService) evaluatePattern(pattern string, player, opponent int) int {
-	// Winning patterns
-	if strings.Contains(pattern, fmt.Sprintf("%d%d%d%d%d", player, player, player, player, player)) {
-		return 100000
+// scoreToTT converts a win score to be relative to the stored node rather than the root
+func scoreToTT(score, ply int) int {
+	switch {
+	case score >= winScore:
+		return score + ply
+	case score <= -winScore:
+		return score - ply
 	}
+	return score
+}
 
-	// Four in a row with open end
-	if strings.Contains(pattern, fmt.Sprintf("0%d%d%d%d0", player, player, player, player)) ||
-	   strings.Contains(pattern, fmt.Sprintf("%d%d%d%d0", player, player, player, player)) ||
-	   strings.Contains(pattern, fmt.Sprintf("0%d%d%d%d", player, player, player, player)) {
-		return 10000
+// scoreFromTT converts a stored win score back to be relative to the root
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score >= winScore:
+		return score - ply
+	case score <= -winScore:
+		return score + ply
 	}
+	return score
+}
 
-	// Three in a row with open ends
-	if strings.Contains(pattern, fmt.Sprintf("0%d%d%d0", player, player, player)) {
-		return 1000
+// evaluateBoard provides a comprehensive evaluation of the board state from the given player's point of view
+func (ai *EnhancedAIService) evaluateBoard(board [][]int, lastMove model.Move, player int) int {
+	// The side to move is whoever did not play the last move
+	toMove := player
+	if lastMove.X >= 0 && lastMove.Y >= 0 && board[lastMove.Y][lastMove.X] != 0 {
+		toMove = 3 - board[lastMove.Y][lastMove.X]
 	}
+	pb := newPatternBoard(board)
+	pb.weights = ai.weights
+	return ai.evaluatePatterns(pb, toMove, player)
+}
 
-	// Three in a row with one open end
-	if strings.Contains(pattern, fmt.Sprintf("%d%d%d0", player, player, player)) ||
-	   strings.Contains(pattern, fmt.Sprintf("0%d%d%d", player, player, player)) {
-		return 100
+// evaluatePatterns converts the incremental pattern evaluation to the given player's point of view
+func (ai *EnhancedAIService) evaluatePatterns(pb *patternBoard, toMove, player int) int {
+	if toMove == player {
+		return pb.evaluate(player)
 	}
+	return -pb.evaluate(toMove)
+}
 
-	// Two in a row with potential
-	if strings.Contains(pattern, fmt.Sprintf("0%d%d0", player, player)) {
-		return 50
-	}
+// evaluatePositionAdvanced scores the shapes a player would form by playing at (x, y)
+func (ai *EnhancedAIService) evaluatePositionAdvanced(board [][]int, x, y, player int) int {
+	totalScore := 0
 
-	// Blocking opponent's patterns
-	opponentStr := fmt.Sprintf("%d", opponent)
-	if strings.Contains(pattern, fmt.Sprintf("0%s%s%s%s0", opponentStr, opponentStr, opponentStr, opponentStr)) {
-		return 5000 // Block opponent's four
+	for _, dir := range directions {
+		key := uint16(0)
+		for offset := -patternReach; offset <= patternReach; offset++ {
+			if offset == 0 {
+				continue
+			}
+			nx, ny := x+dir[0]*offset, y+dir[1]*offset
+			code := uint16(cellWall)
+			if nx >= 0 && nx < ai.boardSize && ny >= 0 && ny < ai.boardSize {
+				code = uint16(board[ny][nx])
+			}
+			key |= code << windowSlot(offset)
+		}
+		totalScore += shapeScores[shapeTable[player-1][key]]
 	}
 
-	return 10
+	return totalScore
 }
 
 // getAvailableMoves gets reasonable moves to consider (pruning the search space)
 func (ai *EnhancedAIService) getAvailableMoves(board [][]int, lastMove model.Move) []model.Move {
 	var moves []model.Move
-	visited := make(map[[2]int]bool)
+	visited := make([]bool, ai.boardSize*ai.boardSize)
 
 	// Consider positions near existing pieces
 	searchRadius := 2
@@ -396,12 +711,11 @@ func (ai *EnhancedAIService) getAvailableMoves(board [][]int, lastMove model.Mov
 				for dy := -searchRadius; dy <= searchRadius; dy++ {
 					for dx := -searchRadius; dx <= searchRadius; dx++ {
 						nx, ny := x+dx, y+dy
-						key := [2]int{nx, ny}
 
 						if nx >= 0 && nx < ai.boardSize && ny >= 0 && ny < ai.boardSize &&
-						   board[ny][nx] == 0 && !visited[key] {
+							board[ny][nx] == 0 && !visited[ny*ai.boardSize+nx] {
 							moves = append(moves, model.Move{X: nx, Y: ny})
-							visited[key] = true
+							visited[ny*ai.boardSize+nx] = true
 						}
 					}
 				}
@@ -416,7 +730,7 @@ func (ai *EnhancedAIService) getAvailableMoves(board [][]int, lastMove model.Mov
 			for dx := -1; dx <= 1; dx++ {
 				nx, ny := center+dx, center+dy
 				if nx >= 0 && nx < ai.boardSize && ny >= 0 && ny < ai.boardSize &&
-				   board[ny][nx] == 0 {
+					board[ny][nx] == 0 {
 					moves = append(moves, model.Move{X: nx, Y: ny})
 				}
 			}
@@ -427,33 +741,36 @@ func (ai *EnhancedAIService) getAvailableMoves(board [][]int, lastMove model.Mov
 }
 
 // getHeuristicMove provides a simple heuristic move for easy difficulty
-func (ai *EnhancedAIService) getHeuristicMove(board [][]int, lastMove model.Move, moves []model.Move) model.AIMove {
-	// Priority 1: Check if AI can win
+func (ai *EnhancedAIService) getHeuristicMove(board [][]int, moves []model.Move, player int) model.AIMove {
+	opponent := 3 - player
+
+	// Priority 1: Check if we can win
 	for _, move := range moves {
-		board[move.Y][move.X] = 2
-		if ai.checkWin(board, move.X, move.Y, 2) {
+		board[move.Y][move.X] = player
+		if ai.checkWin(board, move.X, move.Y, player) {
 			board[move.Y][move.X] = 0
-			return model.AIMove{X: move.X, Y: move.Y, Score: 1000}
+			return model.AIMove{X: move.X, Y: move.Y, Score: winScore}
 		}
 		board[move.Y][move.X] = 0
 	}
 
 	// Priority 2: Block opponent's win
 	for _, move := range moves {
-		board[move.Y][move.X] = 1
-		if ai.checkWin(board, move.X, move.Y, 1) {
+		board[move.Y][move.X] = opponent
+		if ai.checkWin(board, move.X, move.Y, opponent) {
 			board[move.Y][move.X] = 0
 			return model.AIMove{X: move.X, Y: move.Y, Score: 900}
 		}
 		board[move.Y][move.X] = 0
 	}
 
-	// Priority 3: Best positional score
+	// Priority 3: Best positional score, counting both attack and defence
 	bestScore := math.MinInt32
 	var bestMove model.Move
 
 	for _, move := range moves {
-		score := ai.evaluatePositionAdvanced(board, move.X, move.Y, 2)
+		score := ai.evaluatePositionAdvanced(board, move.X, move.Y, player) +
+			ai.evaluatePositionAdvanced(board, move.X, move.Y, opponent)/2
 		if score > bestScore {
 			bestScore = score
 			bestMove = move
@@ -466,10 +783,10 @@ func (ai *EnhancedAIService) getHeuristicMove(board [][]int, lastMove model.Move
 // checkWin checks if a player has won
 func (ai *EnhancedAIService) checkWin(board [][]int, x, y, player int) bool {
 	directions := [][]int{
-		{1, 0},   // Horizontal
-		{0, 1},   // Vertical
-		{1, 1},   // Diagonal \
-		{1, -1},  // Diagonal /
+		{1, 0},  // Horizontal
+		{0, 1},  // Vertical
+		{1, 1},  // Diagonal \
+		{1, -1}, // Diagonal /
 	}
 
 	for _, dir := range directions {
@@ -501,51 +818,91 @@ func (ai *EnhancedAIService) checkWin(board [][]int, x, y, player int) bool {
 	return false
 }
 
-// isBoardFull checks if the board is completely filled
-func (ai *EnhancedAIService) isBoardFull(board [][]int) bool {
-	for _, row := range board {
-		for _, cell := range row {
-			if cell == 0 {
-				return false
+// hashBoard creates a Zobrist hash of the board state for the transposition table
+func (ai *EnhancedAIService) hashBoard(board [][]int) uint64 {
+	var hash uint64
+	for y, row := range board {
+		for x, cell := range row {
+			if cell != 0 {
+				hash ^= zobristKeys[cell][y*ai.boardSize+x]
 			}
 		}
 	}
-	return true
+	return hash
 }
 
-// hashBoard creates a hash of the board state for transposition table
-func (ai *EnhancedAIService) hashBoard(board [][]int) string {
-	var sb strings.Builder
-	for _, row := range board {
-		for _, cell := range row {
-			sb.WriteRune(rune(cell + '0'))
-		}
-	}
+// GetStats returns performance statistics of the last search
+func (ai *EnhancedAIService) GetStats() map[string]interface{} {
+	ai.statsMutex.Lock()
+	defer ai.statsMutex.Unlock()
 
-	hash := sha256.Sum256([]byte(sb.String()))
-	return hex.EncodeToString(hash[:])
+	return ai.searchStats(ai.nodesSearched, ai.cutoffs, ai.firstMoveCutoffs, time.Since(ai.searchStartTime))
 }
 
-// GetStats returns performance statistics
-func (ai *EnhancedAIService) GetStats() map[string]interface{} {
-	ai.tableMutex.RLock()
-	defer ai.tableMutex.RUnlock()
+// searchStats formats the counters of a search
+func (ai *EnhancedAIService) searchStats(nodes, cutoffs, firstMoveCutoffs uint64, elapsed time.Duration) map[string]interface{} {
+	efficiency := 0.0
+	if nodes > 0 {
+		efficiency = float64(cutoffs) / float64(nodes) * 100
+	}
 
 	return map[string]interface{}{
-		"nodes_searched":       ai.nodesSearched,
-		"cutoffs":             ai.cutoffs,
-		"table_entries":       len(ai.transpositionTable),
-		"search_time":         time.Since(ai.searchStartTime).String(),
-		"pruning_efficiency":  float64(ai.cutoffs) / float64(ai.nodesSearched) * 100,
+		"nodes_searched":     nodes,
+		"cutoffs":            cutoffs,
+		"first_move_cutoffs": firstMoveCutoffs,
+		"table_entries":      ai.transpositionTable.len(),
+		"search_time":        elapsed.String(),
+		"pruning_efficiency": efficiency,
+	}
+}
+
+// SetTranspositionTableMemory replaces the transposition table with one using at most the given
+// number of bytes. It must not be called while a search is running.
+func (ai *EnhancedAIService) SetTranspositionTableMemory(bytes int64) {
+	ai.transpositionTable = newTranspositionTable(bytes)
+}
+
+// SetEvalWeights sets the evaluation weights used when a search does not select its own.
+// It must not be called while a search is running.
+func (ai *EnhancedAIService) SetEvalWeights(weights EvalWeights) error {
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+	ai.evalWeights = weights
+	ai.weights = weights.table()
+	return nil
+}
+
+// EvalWeights returns the evaluation weights used by default
+func (ai *EnhancedAIService) EvalWeights() EvalWeights {
+	return ai.evalWeights
+}
+
+// SetPersonality makes searches play in the personality's style unless they select their own
+// weights; nil restores the neutral style. It must not be called while a search is running.
+func (ai *EnhancedAIService) SetPersonality(personality *Personality) error {
+	if personality == nil {
+		ai.personality, ai.styleTables = nil, [3]*evalTable{}
+		return nil
 	}
+	if err := personality.Validate(); err != nil {
+		return err
+	}
+	ai.personality = personality
+	for player := 1; player <= 2; player++ {
+		ai.styleTables[player] = personality.table(player)
+	}
+	return nil
+}
+
+// Personality returns the playing style in use, or nil
+func (ai *EnhancedAIService) Personality() *Personality {
+	return ai.personality
 }
 
 // ClearTranspositionTable clears the transposition table
 func (ai *EnhancedAIService) ClearTranspositionTable() {
-	ai.tableMutex.Lock()
-	defer ai.tableMutex.Unlock()
-
-	ai.transpositionTable = make(map[string]*TranspositionTableEntry)
+	ai.transpositionTable.clear()
 }
 
 // Helper functions
@@ -568,4 +925,4 @@ func abs(x int) int {
 		return -x
 	}
 	return x
-}
\ No newline at end of file
+}
@@ -77,8 +77,8 @@ func TestGetAIMove_WinDetection(t *testing.T) {
 
 	move := ai.GetAIMove(board, lastMove, Medium)
 
-	// Should play winning move at (7,9) or (7,4)
-	expectedMoves := [][2]int{{7, 9}, {7, 4}}
+	// Should play winning move at (9,7) or (4,7)
+	expectedMoves := [][2]int{{9, 7}, {4, 7}}
 	found := false
 	for _, expected := range expectedMoves {
 		if move.X == expected[0] && move.Y == expected[1] {
@@ -88,7 +88,7 @@ func TestGetAIMove_WinDetection(t *testing.T) {
 	}
 
 	if !found {
-		t.Errorf("Expected winning move at (7,9) or (7,4), got (%d,%d)", move.X, move.Y)
+		t.Errorf("Expected winning move at (9,7) or (4,7), got (%d,%d)", move.X, move.Y)
 	}
 
 	if move.Score < 100000 {
@@ -113,8 +113,8 @@ func TestGetAIMove_BlockWin(t *testing.T) {
 
 	move := ai.GetAIMove(board, lastMove, Medium)
 
-	// Should block at (7,9) or (7,4)
-	expectedMoves := [][2]int{{7, 9}, {7, 4}}
+	// Should block at (9,7) or (4,7)
+	expectedMoves := [][2]int{{9, 7}, {4, 7}}
 	found := false
 	for _, expected := range expectedMoves {
 		if move.X == expected[0] && move.Y == expected[1] {
@@ -124,7 +124,7 @@ func TestGetAIMove_BlockWin(t *testing.T) {
 	}
 
 	if !found {
-		t.Errorf("Expected blocking move at (7,9) or (7,4), got (%d,%d)", move.X, move.Y)
+		t.Errorf("Expected blocking move at (9,7) or (4,7), got (%d,%d)", move.X, move.Y)
 	}
 }
 
@@ -323,6 +323,58 @@ func TestDifficulties(t *testing.T) {
 	}
 }
 
+func TestGetMoveForPlayer_Black(t *testing.T) {
+	ai := NewEnhancedAIService()
+
+	// Playing black, the engine should complete its own four rather than block white's
+	board := createEmptyBoard()
+	for x := 5; x <= 8; x++ {
+		board[7][x] = 1
+		board[9][x] = 2
+	}
+	lastMove := model.Move{X: 8, Y: 9}
+
+	for _, difficulty := range []Difficulty{Easy, Medium} {
+		move := ai.GetMoveForPlayer(board, lastMove, 1, difficulty)
+		if move.Y != 7 || (move.X != 4 && move.X != 9) {
+			t.Errorf("Difficulty %v: expected black to win at (4,7) or (9,7), got (%d,%d)",
+				difficulty, move.X, move.Y)
+		}
+		if len(move.PV) > 0 && move.PV[0].Player != 1 {
+			t.Errorf("Difficulty %v: expected PV to start with black, got player %d",
+				difficulty, move.PV[0].Player)
+		}
+	}
+}
+
+func TestEngineVsEngine(t *testing.T) {
+	black := NewEnhancedAIService()
+	white := NewEnhancedAIService()
+	engines := map[int]*EnhancedAIService{1: black, 2: white}
+
+	board := createEmptyBoard()
+	lastMove := model.Move{X: -1, Y: -1}
+	player := 1
+
+	for ply := 0; ply < 60; ply++ {
+		move := engines[player].GetMoveForPlayer(board, lastMove, player, Medium)
+		if move.X < 0 || move.X >= 15 || move.Y < 0 || move.Y >= 15 || board[move.Y][move.X] != 0 {
+			t.Fatalf("Ply %d: player %d returned illegal move (%d,%d)", ply, player, move.X, move.Y)
+		}
+
+		board[move.Y][move.X] = player
+		if black.checkWin(board, move.X, move.Y, player) {
+			t.Logf("Player %d won after %d moves", player, ply+1)
+			return
+		}
+
+		lastMove = model.Move{X: move.X, Y: move.Y, Player: player}
+		player = 3 - player
+	}
+
+	t.Logf("No winner after 60 moves")
+}
+
 // Helper functions for creating test boards
 
 func createEmptyBoard() [][]int {
@@ -379,3 +431,9 @@ func createComplexBoard() [][]int {
 
 	return board
 }
+
+func createCenterBoard() [][]int {
+	board := createEmptyBoard()
+	board[7][7] = 1
+	return board
+}
@@ -0,0 +1,304 @@
+// Package service contains the evaluation weight tuner
+// This file fits evaluation weights to game outcomes with Texel-style local search
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gomoku-backend/internal/model"
+)
+
+// TuningPosition is a quiet position reduced to its evaluation features, with the game
+// result from the side to move's point of view: 1 win, 0.5 draw, 0 loss
+type TuningPosition struct {
+	Features [evalParamCount]float64
+	Result   float64
+}
+
+// TuneOptions controls the weight search
+type TuneOptions struct {
+	Passes   int                                        // Maximum passes over all parameters; 0 means 100
+	Fixed    map[string]bool                            // Parameters that keep their starting value
+	Progress func(pass int, mse float64, w EvalWeights) // Called after every pass
+}
+
+// TuneResult is the outcome of a tuning run
+type TuneResult struct {
+	Weights      EvalWeights
+	Scale        float64 // Sigmoid scale fitted to the starting weights
+	InitialError float64
+	FinalError   float64
+	Passes       int
+}
+
+// evalFeatures returns the terms the evaluation multiplies by each parameter, in the order of
+// evalParamNames. It reports false for positions already decided by a four or a five,
+// whose score does not depend on the weights.
+func (pb *patternBoard) evalFeatures(toMove int) ([evalParamCount]float64, bool) {
+	var f [evalParamCount]float64
+	opponent := 3 - toMove
+	if _, forced := pb.forcedScore(toMove); forced || pb.counts[1][ShapeFive]+pb.counts[2][ShapeFive] > 0 {
+		return f, false
+	}
+
+	for i, s := 0, ShapeClosedTwo; s <= ShapeOpenFour; i, s = i+1, s+1 {
+		f[i] = float64(pb.counts[toMove][s] - pb.counts[opponent][s])
+	}
+	f[8] = float64(pb.centrality[toMove] - pb.centrality[opponent])
+	f[9] = 1
+	if pb.hasThreeThreat(toMove) {
+		f[10] = 1
+	}
+	return f, true
+}
+
+// ExtractTuningPositions replays a game and returns its quiet positions labelled with the
+// result. The first skip positions are left out as they carry little information. The
+// game is won by whoever completed five with the last move and drawn otherwise.
+func ExtractTuningPositions(moves []model.Move, skip int) ([]TuningPosition, error) {
+	var positions []TuningPosition
+	err := replayGame(moves, skip, func(pb *patternBoard, toMove int, result float64) {
+		if features, quiet := pb.evalFeatures(toMove); quiet {
+			positions = append(positions, TuningPosition{Features: features, Result: result})
+		}
+	})
+	return positions, err
+}
+
+// replayGame calls visit with every position from the skip-th on, before the move played
+// there, along with the game result from the side to move's point of view
+func replayGame(moves []model.Move, skip int, visit func(pb *patternBoard, toMove int, result float64)) error {
+	board, err := openingBoard(moves[:max(0, len(moves)-1)])
+	if err != nil {
+		return err
+	}
+
+	winner := 0
+	if len(moves) > 0 {
+		last := moves[len(moves)-1]
+		player := (len(moves)-1)%2 + 1
+		if last.X < 0 || last.X >= 15 || last.Y < 0 || last.Y >= 15 || board[last.Y][last.X] != 0 {
+			return fmt.Errorf("invalid move %d,%d", last.X, last.Y)
+		}
+		board[last.Y][last.X] = player
+		if fiveInRow(board, last.X, last.Y, player) {
+			winner = player
+		}
+	}
+
+	pb := newPatternBoard(createGrid(15))
+	for i, move := range moves {
+		toMove := i%2 + 1
+		if i >= skip {
+			result := 0.5
+			if winner == toMove {
+				result = 1
+			} else if winner != 0 {
+				result = 0
+			}
+			visit(pb, toMove, result)
+		}
+		pb.place(move.X, move.Y, toMove)
+	}
+	return nil
+}
+
+// createGrid returns an empty size x size grid
+func createGrid(size int) [][]int {
+	grid := make([][]int, size)
+	for i := range grid {
+		grid[i] = make([]int, size)
+	}
+	return grid
+}
+
+// TuneEvalWeights adjusts the weights to minimise the squared error between the game results
+// and the win probability predicted from the evaluation. Each parameter is stepped up and
+// down while that lowers the error, with steps halving down to one point.
+func TuneEvalWeights(positions []TuningPosition, start EvalWeights, options TuneOptions) (TuneResult, error) {
+	if len(positions) == 0 {
+		return TuneResult{}, errors.New("no positions to tune on")
+	}
+	if err := start.Validate(); err != nil {
+		return TuneResult{}, err
+	}
+	passes := options.Passes
+	if passes <= 0 {
+		passes = 100
+	}
+
+	weights := start
+	params := weights.params()
+	vector := func() [evalParamCount]float64 {
+		var v [evalParamCount]float64
+		for i, param := range params {
+			v[i] = float64(*param)
+		}
+		return v
+	}
+
+	scale := fitScale(positions, vector())
+	result := TuneResult{Scale: scale, InitialError: tuningError(positions, vector(), scale)}
+	best := result.InitialError
+
+	steps := make([]int, len(params))
+	for i, param := range params {
+		steps[i] = max(1, *param/4)
+	}
+
+	for result.Passes < passes {
+		result.Passes++
+		improved := false
+		for i, param := range params {
+			if options.Fixed[evalParamNames[i]] {
+				continue
+			}
+
+			original := *param
+			accepted := false
+			for _, delta := range []int{steps[i], -steps[i]} {
+				*param = max(0, min(original+delta, winScore/4-1))
+				if *param == original {
+					continue
+				}
+				if mse := tuningError(positions, vector(), scale); mse < best {
+					best, accepted = mse, true
+					break
+				}
+			}
+			if !accepted {
+				*param = original
+				steps[i] = max(1, steps[i]/2)
+			}
+			improved = improved || accepted
+		}
+
+		if options.Progress != nil {
+			options.Progress(result.Passes, best, weights)
+		}
+		if !improved && allOnes(steps) {
+			break
+		}
+	}
+
+	result.Weights = weights
+	result.FinalError = best
+	return result, nil
+}
+
+// allOnes reports whether every step has reached one point
+func allOnes(steps []int) bool {
+	for _, step := range steps {
+		if step > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// tuningError returns the mean squared error of the predicted win probabilities
+func tuningError(positions []TuningPosition, weights [evalParamCount]float64, scale float64) float64 {
+	total := 0.0
+	for i := range positions {
+		score := 0.0
+		for j, feature := range positions[i].Features {
+			score += feature * weights[j]
+		}
+		diff := positions[i].Result - 1/(1+math.Exp(-scale*score))
+		total += diff * diff
+	}
+	return total / float64(len(positions))
+}
+
+// fitScale finds the sigmoid scale that best fits the starting weights, by golden-section
+// search over its logarithm
+func fitScale(positions []TuningPosition, weights [evalParamCount]float64) float64 {
+	lo, hi := math.Log(1e-6), math.Log(1e-1)
+	ratio := (math.Sqrt(5) - 1) / 2
+	errorAt := func(logScale float64) float64 {
+		return tuningError(positions, weights, math.Exp(logScale))
+	}
+
+	a, b := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	fa, fb := errorAt(a), errorAt(b)
+	for i := 0; i < 60; i++ {
+		if fa < fb {
+			hi, b, fb = b, a, fa
+			a = hi - ratio*(hi-lo)
+			fa = errorAt(a)
+		} else {
+			lo, a, fa = a, b, fb
+			b = lo + ratio*(hi-lo)
+			fb = errorAt(b)
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// LoadGameRecords reads games from a Piskvork .psq file or from a file of Gomocup lines
+// (x,y pairs relative to the centre, one game per line)
+func LoadGameRecords(path string) ([][]model.Move, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".psq") {
+		game, err := readPSQ(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return [][]model.Move{game}, nil
+	}
+
+	var games [][]model.Move
+	if _, err := scanGomocupLines(file, 15, func(moves []model.Move) error {
+		games = append(games, moves)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return games, nil
+}
+
+// readPSQ reads a Piskvork game record: a "Piskvork 15x15, ..." header followed by
+// 1-based "x,y,time" lines until the first line of another form
+func readPSQ(r io.Reader) ([]model.Move, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "Piskvork 15x15") {
+		return nil, errors.New("not a 15x15 Piskvork record")
+	}
+
+	var moves []model.Move
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ",")
+		if len(fields) != 3 {
+			break
+		}
+		x, errX := strconv.Atoi(fields[0])
+		y, errY := strconv.Atoi(fields[1])
+		if errX != nil || errY != nil {
+			break
+		}
+		moves = append(moves, model.Move{X: x - 1, Y: y - 1})
+	}
+	return moves, scanner.Err()
+}
+
+// FormatGameRecord writes a game as a Gomocup line of x,y pairs relative to the centre
+func FormatGameRecord(moves []model.Move) string {
+	parts := make([]string, len(moves))
+	for i, move := range moves {
+		parts[i] = fmt.Sprintf("%d,%d", move.X-7, move.Y-7)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,166 @@
+// Unit tests for evaluation weights and the weight tuner
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestEvalFeaturesMatchEvaluate(t *testing.T) {
+	weights := DefaultEvalWeights()
+	var vector [evalParamCount]float64
+	for i, param := range weights.params() {
+		vector[i] = float64(*param)
+	}
+
+	boards := [][][]int{createCenterBoard(), createComplexBoard(), lineBoard("_XX_X_", 1), lineBoard("OXX__", 2)}
+	for i, board := range boards {
+		pb := newPatternBoard(board)
+		for toMove := 1; toMove <= 2; toMove++ {
+			features, quiet := pb.evalFeatures(toMove)
+			if !quiet {
+				continue
+			}
+			score := 0.0
+			for j, feature := range features {
+				score += feature * vector[j]
+			}
+			if int(score) != pb.evaluate(toMove) {
+				t.Errorf("Board %d, side %d: features give %d, evaluate gives %d", i, toMove, int(score), pb.evaluate(toMove))
+			}
+		}
+	}
+}
+
+func TestEvalWeightsSelection(t *testing.T) {
+	ai := NewEnhancedAIService()
+	board := createCenterBoard()
+	lastMove := model.Move{X: 7, Y: 7}
+	before := ai.evaluateBoard(board, lastMove, 1)
+
+	heavy := DefaultEvalWeights()
+	heavy.Name = "heavy"
+	heavy.Center = 100
+	if err := ai.SetEvalWeights(heavy); err != nil {
+		t.Fatalf("SetEvalWeights failed: %v", err)
+	}
+	if after := ai.evaluateBoard(board, lastMove, 1); after == before {
+		t.Error("Expected the weights to change the evaluation")
+	}
+	if heavy.table().salt == 0 || defaultEvalTable.salt != 0 {
+		t.Error("Expected only non-default weights to salt transposition keys")
+	}
+
+	// Per-search weights are validated like other limits
+	invalid := DefaultEvalWeights()
+	invalid.Tempo = -1
+	position := Position{Board: board, LastMove: lastMove, ToMove: 2}
+	if _, err := ai.BestMove(context.Background(), position, SearchLimits{Depth: 2, Weights: &invalid}); err == nil {
+		t.Error("Expected negative weights to be rejected")
+	}
+	if _, err := ai.BestMove(context.Background(), position, SearchLimits{Depth: 2, Weights: &heavy}); err != nil {
+		t.Errorf("BestMove with weights failed: %v", err)
+	}
+	heuristic, _ := GetEngine("heuristic")
+	if _, err := heuristic.BestMove(context.Background(), position, SearchLimits{Weights: &heavy}); err == nil {
+		t.Error("Expected the heuristic engine to reject weights")
+	}
+
+	if err := RegisterEvalWeights(DefaultEvalWeights()); err == nil {
+		t.Error("Expected the default set to be protected")
+	}
+}
+
+func TestExtractTuningPositions(t *testing.T) {
+	// Black builds five on row 7 while white plays on row 9
+	var moves []model.Move
+	for i := 0; i < 5; i++ {
+		moves = append(moves, model.Move{X: 3 + i, Y: 7})
+		if i < 4 {
+			moves = append(moves, model.Move{X: 3 + 2*i, Y: 11})
+		}
+	}
+
+	positions, err := ExtractTuningPositions(moves, 2)
+	if err != nil {
+		t.Fatalf("ExtractTuningPositions failed: %v", err)
+	}
+	if len(positions) == 0 {
+		t.Fatal("Expected quiet positions")
+	}
+	for i, position := range positions {
+		if position.Result != 0 && position.Result != 1 {
+			t.Errorf("Position %d: expected a decisive label, got %v", i, position.Result)
+		}
+	}
+	if positions[0].Result != 1 {
+		t.Errorf("Expected black to move first with a winning label, got %v", positions[0].Result)
+	}
+
+	// A game without five is a draw
+	positions, _ = ExtractTuningPositions(moves[:4], 0)
+	for _, position := range positions {
+		if position.Result != 0.5 {
+			t.Errorf("Expected draw labels, got %v", position.Result)
+		}
+	}
+}
+
+func TestTuneEvalWeights(t *testing.T) {
+	// Open twos decide these games; the position bias is zero
+	var positions []TuningPosition
+	for i := 0; i < 200; i++ {
+		var features [evalParamCount]float64
+		features[1] = float64(i%4 + 1)
+		features[9] = 1
+		result := 1.0
+		if i%2 == 1 {
+			features[1], result = -features[1], 0
+		}
+		positions = append(positions, TuningPosition{Features: features, Result: result})
+	}
+
+	start := DefaultEvalWeights()
+	passes := 0
+	result, err := TuneEvalWeights(positions, start, TuneOptions{
+		Passes:   20,
+		Fixed:    map[string]bool{"tempo": true},
+		Progress: func(int, float64, EvalWeights) { passes++ },
+	})
+	if err != nil {
+		t.Fatalf("TuneEvalWeights failed: %v", err)
+	}
+	if result.FinalError >= result.InitialError {
+		t.Errorf("Expected the error to fall, got %.4f -> %.4f", result.InitialError, result.FinalError)
+	}
+	if result.Weights.OpenTwo <= start.OpenTwo {
+		t.Errorf("Expected the open two weight to grow from %d, got %d", start.OpenTwo, result.Weights.OpenTwo)
+	}
+	if result.Weights.Tempo != start.Tempo {
+		t.Errorf("Expected the fixed tempo weight to stay %d, got %d", start.Tempo, result.Weights.Tempo)
+	}
+	if passes != result.Passes {
+		t.Errorf("Expected progress after each of %d passes, got %d", result.Passes, passes)
+	}
+}
+
+func TestLoadGameRecords(t *testing.T) {
+	dir := t.TempDir()
+	psq := filepath.Join(dir, "game.psq")
+	os.WriteFile(psq, []byte("Piskvork 15x15, 11:11, 0\n8,8,100\n9,8,200\n8,9,300\n-1\npbrain-a.exe\n"), 0o644)
+	games, err := LoadGameRecords(psq)
+	if err != nil || len(games) != 1 || len(games[0]) != 3 || games[0][0] != (model.Move{X: 7, Y: 7}) {
+		t.Errorf("Unexpected PSQ games %v (%v)", games, err)
+	}
+
+	lines := filepath.Join(dir, "games.txt")
+	os.WriteFile(lines, []byte(FormatGameRecord(games[0])+"\n0,0, 1,1\n"), 0o644)
+	games2, err := LoadGameRecords(lines)
+	if err != nil || len(games2) != 2 || games2[0][2] != games[0][2] {
+		t.Errorf("Unexpected line games %v (%v)", games2, err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package service contains the evaluation weight sets
+// This file externalises the pattern evaluation parameters so they can be tuned,
+// loaded from files and selected at runtime
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultEvalWeightsName is the name of the built-in weight set
+const DefaultEvalWeightsName = "default"
+
+// EvalWeights are the parameters of the pattern evaluation. Shape weights score each stone
+// once per direction in which it forms the shape; a five always scores winScore.
+type EvalWeights struct {
+	Name        string `json:"name"`
+	ClosedTwo   int    `json:"closed_two"`
+	OpenTwo     int    `json:"open_two"`
+	ClosedThree int    `json:"closed_three"`
+	BrokenThree int    `json:"broken_three"`
+	OpenThree   int    `json:"open_three"`
+	BrokenFour  int    `json:"broken_four"`
+	Four        int    `json:"four"`
+	OpenFour    int    `json:"open_four"`
+	Center      int    `json:"center"`       // Per stone and ring closer to the centre
+	Tempo       int    `json:"tempo"`        // Having the move in a quiet position
+	ThreeThreat int    `json:"three_threat"` // Open three to move that the opponent cannot answer with a four
+}
+
+// evalParamNames name the tunable parameters in the order returned by params
+var evalParamNames = []string{
+	"closed_two", "open_two", "closed_three", "broken_three", "open_three",
+	"broken_four", "four", "open_four", "center", "tempo", "three_threat",
+}
+
+// evalParamCount is the number of tunable parameters
+const evalParamCount = 11
+
+// evalTable is the compiled form of a weight set used by the pattern board
+type evalTable struct {
+	shapes      [shapeCount]int
+	center      int
+	tempo       int
+	threeThreat int
+	self        int    // Side whose material is scaled by attack, the other by defence; 0 for none
+	attack      int    // Percentage applied to the own side's material
+	defence     int    // Percentage applied to the other side's material
+	salt        uint64 // Mixed into transposition keys so weight sets do not share entries
+}
+
+// scale weighs a side's material by the attack or defence percentage
+func (t *evalTable) scale(player, material int) int {
+	switch {
+	case t.self == 0:
+		return material
+	case player == t.self:
+		return material * t.attack / 100
+	default:
+		return material * t.defence / 100
+	}
+}
+
+// DefaultEvalWeights returns the hand-tuned weights the evaluation has always used
+func DefaultEvalWeights() EvalWeights {
+	return EvalWeights{
+		Name:        DefaultEvalWeightsName,
+		ClosedTwo:   shapeScores[ShapeClosedTwo],
+		OpenTwo:     shapeScores[ShapeOpenTwo],
+		ClosedThree: shapeScores[ShapeClosedThree],
+		BrokenThree: shapeScores[ShapeBrokenThree],
+		OpenThree:   shapeScores[ShapeOpenThree],
+		BrokenFour:  shapeScores[ShapeBrokenFour],
+		Four:        shapeScores[ShapeFour],
+		OpenFour:    shapeScores[ShapeOpenFour],
+		Center:      2,
+		Tempo:       tempoBonus,
+		ThreeThreat: shapeScores[ShapeOpenFour],
+	}
+}
+
+// defaultEvalTable is used by pattern boards unless a search selects other weights
+var defaultEvalTable = DefaultEvalWeights().table()
+
+// params returns pointers to the tunable parameters in the order of evalParamNames
+func (w *EvalWeights) params() []*int {
+	return []*int{
+		&w.ClosedTwo, &w.OpenTwo, &w.ClosedThree, &w.BrokenThree, &w.OpenThree,
+		&w.BrokenFour, &w.Four, &w.OpenFour, &w.Center, &w.Tempo, &w.ThreeThreat,
+	}
+}
+
+// Validate checks every weight is non-negative and well below a five
+func (w EvalWeights) Validate() error {
+	for i, param := range w.params() {
+		if *param < 0 || *param >= winScore/4 {
+			return fmt.Errorf("weight %s must be between 0 and %d", evalParamNames[i], winScore/4-1)
+		}
+	}
+	return nil
+}
+
+// table compiles the weights
+func (w EvalWeights) table() *evalTable {
+	t := &evalTable{
+		center:      w.Center,
+		tempo:       w.Tempo,
+		threeThreat: w.ThreeThreat,
+		salt:        w.fingerprint() ^ defaultFingerprint,
+	}
+	t.shapes = [shapeCount]int{
+		ShapeClosedTwo:   w.ClosedTwo,
+		ShapeOpenTwo:     w.OpenTwo,
+		ShapeClosedThree: w.ClosedThree,
+		ShapeBrokenThree: w.BrokenThree,
+		ShapeOpenThree:   w.OpenThree,
+		ShapeBrokenFour:  w.BrokenFour,
+		ShapeFour:        w.Four,
+		ShapeOpenFour:    w.OpenFour,
+		ShapeFive:        winScore,
+	}
+	return t
+}
+
+// defaultFingerprint makes the default weights' salt zero
+var defaultFingerprint = DefaultEvalWeights().fingerprint()
+
+// fingerprint hashes the parameter values
+func (w EvalWeights) fingerprint() uint64 {
+	h := fnv.New64a()
+	for _, param := range w.params() {
+		fmt.Fprintf(h, "%d,", *param)
+	}
+	return h.Sum64()
+}
+
+// evalWeightsRegistry holds the weight sets selectable by name
+var evalWeightsRegistry = struct {
+	mutex sync.RWMutex
+	sets  map[string]EvalWeights
+}{sets: map[string]EvalWeights{DefaultEvalWeightsName: DefaultEvalWeights()}}
+
+// RegisterEvalWeights makes a weight set selectable by name, replacing one of the same name.
+// The default set cannot be replaced.
+func RegisterEvalWeights(weights EvalWeights) error {
+	if weights.Name == "" || weights.Name == DefaultEvalWeightsName {
+		return errors.New("weight set needs a name other than " + DefaultEvalWeightsName)
+	}
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+
+	evalWeightsRegistry.mutex.Lock()
+	defer evalWeightsRegistry.mutex.Unlock()
+	evalWeightsRegistry.sets[weights.Name] = weights
+	return nil
+}
+
+// GetEvalWeights returns the weight set registered under name
+func GetEvalWeights(name string) (EvalWeights, bool) {
+	evalWeightsRegistry.mutex.RLock()
+	defer evalWeightsRegistry.mutex.RUnlock()
+
+	weights, exists := evalWeightsRegistry.sets[name]
+	return weights, exists
+}
+
+// EvalWeightSets returns all registered weight sets sorted by name
+func EvalWeightSets() []EvalWeights {
+	evalWeightsRegistry.mutex.RLock()
+	defer evalWeightsRegistry.mutex.RUnlock()
+
+	sets := make([]EvalWeights, 0, len(evalWeightsRegistry.sets))
+	for _, weights := range evalWeightsRegistry.sets {
+		sets = append(sets, weights)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Name < sets[j].Name
+	})
+	return sets
+}
+
+// LoadEvalWeights reads a JSON weight set. Missing weights keep their default values and a
+// missing name defaults to the file name without extension.
+func LoadEvalWeights(path string) (EvalWeights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EvalWeights{}, err
+	}
+
+	weights := DefaultEvalWeights()
+	weights.Name = ""
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return EvalWeights{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if weights.Name == "" {
+		weights.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := weights.Validate(); err != nil {
+		return EvalWeights{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return weights, nil
+}
@@ -0,0 +1,420 @@
+// Package service contains the external engine adapter
+// This file drives third-party Gomocup brains over the Piskvork protocol
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+const (
+	// defaultBrainStartTimeout bounds how long a brain may take to answer START
+	defaultBrainStartTimeout = 10 * time.Second
+	// defaultBrainTimeMargin is the grace period beyond the turn time before a brain is killed
+	defaultBrainTimeMargin = time.Second
+)
+
+// externalMoveTime is the turn time given to a brain for each difficulty
+var externalMoveTime = map[Difficulty]time.Duration{
+	Easy:   200 * time.Millisecond,
+	Medium: time.Second,
+	Hard:   3 * time.Second,
+	Expert: 5 * time.Second,
+}
+
+// ErrBrainTimeout is returned when a brain does not answer within its time limit
+var ErrBrainTimeout = errors.New("brain did not answer in time")
+
+// errBrainExited is returned when the brain closes its output while a reply is awaited
+var errBrainExited = errors.New("brain exited")
+
+// ExternalEngineConfig describes a Piskvork brain executable
+type ExternalEngineConfig struct {
+	Name         string        // Registry name; defaults to the executable name
+	Path         string        // Executable path or name on PATH
+	Args         []string      // Extra command line arguments
+	Dir          string        // Working directory; defaults to the executable's directory
+	StartTimeout time.Duration // Time allowed to answer START
+	TimeMargin   time.Duration // Grace period beyond the turn time before the brain is killed
+	MaxMemory    int64         // Sent as INFO max_memory; 0 means no limit
+}
+
+// ExternalEngine plays moves by running a Gomocup brain over the Piskvork protocol.
+// The brain process is started on first use, killed when it misses a deadline and
+// restarted when it has exited. Searches are serialised on the single process.
+type ExternalEngine struct {
+	config ExternalEngineConfig
+
+	mutex    sync.Mutex
+	process  *brainProcess
+	started  bool
+	about    string
+	moves    int
+	restarts int
+	timeouts int
+	crashes  int
+	lastTime time.Duration
+}
+
+// brainProcess is a running brain and the lines it has written
+type brainProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string   // Closed when stdout reaches EOF
+	done  chan struct{} // Closed when the process has exited
+}
+
+// NewExternalEngine creates an engine for the brain; the process is not started yet
+func NewExternalEngine(config ExternalEngineConfig) (*ExternalEngine, error) {
+	path, err := exec.LookPath(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("brain executable: %w", err)
+	}
+	config.Path = path
+	if config.Name == "" {
+		config.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if config.Dir == "" {
+		config.Dir = filepath.Dir(path)
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = defaultBrainStartTimeout
+	}
+	if config.TimeMargin <= 0 {
+		config.TimeMargin = defaultBrainTimeMargin
+	}
+	return &ExternalEngine{config: config}, nil
+}
+
+// RegisterExternalEngine creates an external engine and adds it to the engine registry
+func RegisterExternalEngine(config ExternalEngineConfig) (*ExternalEngine, error) {
+	engine, err := NewExternalEngine(config)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := GetEngine(engine.Name()); exists {
+		return nil, fmt.Errorf("engine %q is already registered", engine.Name())
+	}
+	RegisterEngine(engine)
+	return engine, nil
+}
+
+// Name returns the registry name
+func (e *ExternalEngine) Name() string {
+	return e.config.Name
+}
+
+// Capabilities describes the external engine
+func (e *ExternalEngine) Capabilities() EngineCapabilities {
+	return EngineCapabilities{
+		Description: "External Gomocup brain " + filepath.Base(e.config.Path) + " (Piskvork protocol)",
+		Difficulty:  true,
+		Time:        true,
+	}
+}
+
+// BestMove sends the position to the brain and waits for its move. A brain that has
+// crashed is restarted and asked once more; one that misses its deadline is killed.
+func (e *ExternalEngine) BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error) {
+	if err := position.Validate(); err != nil {
+		return model.AIMove{}, err
+	}
+	if err := limits.Validate(e.Capabilities()); err != nil {
+		return model.AIMove{}, err
+	}
+
+	turnTime := limits.Time
+	if turnTime == 0 {
+		turnTime = externalMoveTime[limits.Difficulty]
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var move model.Move
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = e.ensureStarted(); err != nil {
+			return model.AIMove{}, err
+		}
+
+		start := time.Now()
+		move, err = e.requestMove(ctx, position, turnTime)
+		e.lastTime = time.Since(start)
+		if err == nil {
+			break
+		}
+
+		// A brain that is still thinking would answer into the next request, so it is
+		// replaced whatever went wrong; one that died is asked again straight away
+		e.stop()
+		if !errors.Is(err, errBrainExited) || ctx.Err() != nil {
+			break
+		}
+		e.crashes++
+	}
+	if err != nil {
+		return model.AIMove{}, fmt.Errorf("%s: %w", e.Name(), err)
+	}
+
+	e.moves++
+	return model.AIMove{X: move.X, Y: move.Y, Stats: e.stats()}, nil
+}
+
+// GetStats returns statistics of the brain process
+func (e *ExternalEngine) GetStats() map[string]interface{} {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.stats()
+}
+
+// stats formats the counters of the brain process; the caller holds the lock
+func (e *ExternalEngine) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"about":       e.about,
+		"moves":       e.moves,
+		"restarts":    e.restarts,
+		"timeouts":    e.timeouts,
+		"crashes":     e.crashes,
+		"search_time": e.lastTime.String(),
+	}
+}
+
+// Close asks the brain to exit and kills it if it does not
+func (e *ExternalEngine) Close() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.process == nil {
+		return
+	}
+	e.process.send("END")
+	select {
+	case <-e.process.done:
+	case <-time.After(e.config.TimeMargin):
+	}
+	e.stop()
+}
+
+// ensureStarted starts the brain unless a live process exists
+func (e *ExternalEngine) ensureStarted() error {
+	if e.process != nil && !e.process.exited() {
+		return nil
+	}
+	e.stop()
+	if e.started {
+		e.restarts++
+	}
+	e.started = true
+
+	process, err := startBrain(e.config)
+	if err != nil {
+		return err
+	}
+	e.process = process
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.StartTimeout)
+	defer cancel()
+
+	e.process.send("START 15")
+	if reply, err := e.process.readReply(ctx); err != nil || reply != "OK" {
+		e.stop()
+		if err == nil {
+			err = fmt.Errorf("START rejected: %s", reply)
+		}
+		return fmt.Errorf("%s: %w", e.Name(), err)
+	}
+
+	e.process.send("INFO rule 0")
+	e.process.send("INFO timeout_match 0")
+	e.process.send("INFO max_memory " + strconv.FormatInt(e.config.MaxMemory, 10))
+
+	if e.about == "" {
+		e.process.send("ABOUT")
+		about, err := e.process.readReply(ctx)
+		if errors.Is(err, ErrBrainTimeout) || errors.Is(err, errBrainExited) {
+			e.stop()
+			return fmt.Errorf("%s: ABOUT: %w", e.Name(), err)
+		}
+		e.about = about
+	}
+	return nil
+}
+
+// requestMove sends the position and waits for the brain's reply
+func (e *ExternalEngine) requestMove(ctx context.Context, position Position, turnTime time.Duration) (model.Move, error) {
+	e.process.send("INFO timeout_turn " + strconv.FormatInt(turnTime.Milliseconds(), 10))
+
+	// The brain plays its own stones as 1 and the opponent's as 2; the last move goes last
+	var stones []string
+	var last string
+	for y, row := range position.Board {
+		for x, cell := range row {
+			if cell == 0 {
+				continue
+			}
+			field := 2
+			if cell == position.ToMove {
+				field = 1
+			}
+			line := fmt.Sprintf("%d,%d,%d", x, y, field)
+			if x == position.LastMove.X && y == position.LastMove.Y {
+				last = line
+			} else {
+				stones = append(stones, line)
+			}
+		}
+	}
+	if last != "" {
+		stones = append(stones, last)
+	}
+
+	if len(stones) == 0 {
+		e.process.send("BEGIN")
+	} else {
+		e.process.send("BOARD")
+		for _, stone := range stones {
+			e.process.send(stone)
+		}
+		e.process.send("DONE")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, turnTime+e.config.TimeMargin)
+	defer cancel()
+
+	reply, err := e.process.readReply(ctx)
+	if err != nil {
+		if errors.Is(err, ErrBrainTimeout) {
+			e.timeouts++
+		}
+		return model.Move{}, err
+	}
+
+	move, err := parseBrainMove(reply)
+	if err != nil {
+		return model.Move{}, err
+	}
+	if position.Board[move.Y][move.X] != 0 {
+		return model.Move{}, fmt.Errorf("brain played occupied cell %d,%d", move.X, move.Y)
+	}
+	return move, nil
+}
+
+// stop kills the brain process and forgets it
+func (e *ExternalEngine) stop() {
+	if e.process == nil {
+		return
+	}
+	e.process.kill()
+	e.process = nil
+}
+
+// startBrain launches the brain executable
+func startBrain(config ExternalEngineConfig) (*brainProcess, error) {
+	cmd := exec.Command(config.Path, config.Args...)
+	cmd.Dir = config.Dir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting brain: %w", err)
+	}
+
+	process := &brainProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan string, 64),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			process.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(process.lines)
+		cmd.Wait()
+		close(process.done)
+	}()
+	return process, nil
+}
+
+// send writes one command line; write errors surface as a dead process
+func (p *brainProcess) send(line string) {
+	io.WriteString(p.stdin, line+"\r\n")
+}
+
+// readReply returns the next line that is not a MESSAGE or DEBUG line.
+// ERROR and UNKNOWN replies are returned as errors.
+func (p *brainProcess) readReply(ctx context.Context) (string, error) {
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", errBrainExited
+			}
+			command, _, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(command) {
+			case "", "MESSAGE", "DEBUG", "SUGGEST":
+				continue
+			case "ERROR", "UNKNOWN":
+				return "", fmt.Errorf("brain replied %q", line)
+			}
+			return line, nil
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", ErrBrainTimeout
+			}
+			return "", ctx.Err()
+		}
+	}
+}
+
+// exited reports whether the process has terminated
+func (p *brainProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// kill terminates the process and waits for its output to drain
+func (p *brainProcess) kill() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	for range p.lines {
+	}
+	<-p.done
+}
+
+// parseBrainMove parses an "x,y" reply inside the board
+func parseBrainMove(reply string) (model.Move, error) {
+	xs, ys, found := strings.Cut(reply, ",")
+	x, errX := strconv.Atoi(strings.TrimSpace(xs))
+	y, errY := strconv.Atoi(strings.TrimSpace(ys))
+	if !found || errX != nil || errY != nil || x < 0 || x >= 15 || y < 0 || y >= 15 {
+		return model.Move{}, fmt.Errorf("invalid brain move %q", reply)
+	}
+	return model.Move{X: x, Y: y}, nil
+}
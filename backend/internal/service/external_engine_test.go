@@ -0,0 +1,172 @@
+// Unit tests for the external Piskvork engine adapter
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// TestHelperBrain is not a real test: the external engine tests run the test binary
+// as a minimal Piskvork brain. BRAIN_MODE selects its behaviour:
+// "play" answers with the first empty cell, "hang" never answers a move request and
+// "crash" exits on its first move request while BRAIN_MARKER does not exist yet.
+func TestHelperBrain(t *testing.T) {
+	mode := os.Getenv("BRAIN_MODE")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	board := createEmptyBoard()
+	reply := func() {
+		switch mode {
+		case "hang":
+			return
+		case "crash":
+			marker := os.Getenv("BRAIN_MARKER")
+			if _, err := os.Stat(marker); err != nil {
+				os.WriteFile(marker, nil, 0o644)
+				os.Exit(1)
+			}
+		}
+		for y := range board {
+			for x := range board[y] {
+				if board[y][x] == 0 {
+					board[y][x] = 1
+					fmt.Printf("%d,%d\n", x, y)
+					return
+				}
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		command, args, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		switch command {
+		case "START":
+			fmt.Println("OK")
+		case "ABOUT":
+			fmt.Printf("name=\"helper-%s\"\n", mode)
+		case "BEGIN":
+			board = createEmptyBoard()
+			reply()
+		case "BOARD":
+			board = createEmptyBoard()
+			for scanner.Scan() && scanner.Text() != "DONE" {
+				var x, y, field int
+				fmt.Sscanf(scanner.Text(), "%d,%d,%d", &x, &y, &field)
+				board[y][x] = field
+			}
+			fmt.Println("MESSAGE thinking")
+			reply()
+		case "INFO":
+		case "END":
+			return
+		default:
+			fmt.Println("UNKNOWN " + command + " " + args)
+		}
+	}
+}
+
+// newHelperEngine returns an external engine running the helper brain in mode
+func newHelperEngine(t *testing.T, mode string) *ExternalEngine {
+	t.Helper()
+	t.Setenv("BRAIN_MODE", mode)
+	t.Setenv("BRAIN_MARKER", filepath.Join(t.TempDir(), "crashed"))
+
+	engine, err := NewExternalEngine(ExternalEngineConfig{
+		Name:       "helper-" + mode,
+		Path:       os.Args[0],
+		Args:       []string{"-test.run=^TestHelperBrain$"},
+		TimeMargin: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewExternalEngine failed: %v", err)
+	}
+	t.Cleanup(engine.Close)
+	return engine
+}
+
+func TestExternalEngineBestMove(t *testing.T) {
+	engine := newHelperEngine(t, "play")
+	ctx := context.Background()
+
+	// The brain opens on the empty board
+	move, err := engine.BestMove(ctx, Position{Board: createEmptyBoard(), LastMove: model.Move{X: -1, Y: -1}, ToMove: 1}, SearchLimits{})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if move.X != 0 || move.Y != 0 {
+		t.Errorf("Expected the helper to open at 0,0, got %d,%d", move.X, move.Y)
+	}
+
+	// The board is sent to the same process, which skips occupied cells
+	board := createEmptyBoard()
+	board[0][0] = 1
+	board[0][1] = 2
+	move, err = engine.BestMove(ctx, Position{Board: board, LastMove: model.Move{X: 1, Y: 0}, ToMove: 1}, SearchLimits{})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if move.X != 2 || move.Y != 0 {
+		t.Errorf("Expected 2,0, got %d,%d", move.X, move.Y)
+	}
+
+	stats := engine.GetStats()
+	if stats["about"] != `name="helper-play"` || stats["moves"] != 2 || stats["restarts"] != 0 {
+		t.Errorf("Unexpected stats %v", stats)
+	}
+
+	if _, err := engine.BestMove(ctx, Position{Board: board, ToMove: 1}, SearchLimits{Depth: 3}); err == nil {
+		t.Error("Expected a depth limit to be rejected")
+	}
+}
+
+func TestExternalEngineRestartsAfterCrash(t *testing.T) {
+	engine := newHelperEngine(t, "crash")
+
+	move, err := engine.BestMove(context.Background(), Position{Board: createCenterBoard(), LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}, SearchLimits{})
+	if err != nil {
+		t.Fatalf("Expected the restarted brain to answer, got %v", err)
+	}
+	if move.X != 0 || move.Y != 0 {
+		t.Errorf("Expected 0,0, got %d,%d", move.X, move.Y)
+	}
+
+	stats := engine.GetStats()
+	if stats["crashes"] != 1 || stats["restarts"] != 1 {
+		t.Errorf("Expected one crash and one restart, got %v", stats)
+	}
+}
+
+func TestExternalEngineTimeout(t *testing.T) {
+	engine := newHelperEngine(t, "hang")
+	position := Position{Board: createCenterBoard(), LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}
+
+	start := time.Now()
+	_, err := engine.BestMove(context.Background(), position, SearchLimits{Time: 100 * time.Millisecond})
+	if !errors.Is(err, ErrBrainTimeout) {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Timeout took %v", elapsed)
+	}
+
+	// The hung brain was killed; the next request starts a new one
+	if _, err := engine.BestMove(context.Background(), position, SearchLimits{Time: 100 * time.Millisecond}); !errors.Is(err, ErrBrainTimeout) {
+		t.Fatalf("Expected a second timeout, got %v", err)
+	}
+	if stats := engine.GetStats(); stats["timeouts"] != 2 || stats["restarts"] != 1 {
+		t.Errorf("Expected two timeouts and one restart, got %v", stats)
+	}
+}
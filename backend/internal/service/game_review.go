@@ -0,0 +1,438 @@
+// Package service contains post-game analysis
+// This file replays finished games through the engine in the background and reports, for
+// every move, how much it lost against the engine's choice and when the game was decided
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gomoku-backend/internal/model"
+)
+
+// Review job states
+const (
+	ReviewQueued  = "queued"
+	ReviewRunning = "running"
+	ReviewDone    = "done"
+	ReviewFailed  = "failed"
+)
+
+// Move classifications, from the mover's loss of winning chances against the best move
+const (
+	MoveBest       = "best"
+	MoveGood       = "good"
+	MoveInaccuracy = "inaccuracy"
+	MoveMistake    = "mistake"
+	MoveBlunder    = "blunder"
+)
+
+const (
+	inaccuracyLoss = 0.05         // Win probability lost by an inaccuracy
+	mistakeLoss    = 0.10         // ... by a mistake
+	blunderLoss    = 0.20         // ... by a blunder
+	decidedChance  = 0.90         // Winner's chance from which the game counts as decided
+	decisiveScore  = winScore - 2 // Least score of a proven or statically forced win
+
+	maxCachedReviews = 256
+	reviewQueueSize  = 32
+)
+
+// ErrGameNotFinished is returned when reviewing a game still in progress
+var ErrGameNotFinished = errors.New("game is not finished")
+
+// ErrReviewQueueFull is returned when too many reviews are waiting
+var ErrReviewQueueFull = errors.New("too many games waiting for analysis")
+
+// ReviewedGame is a finished game handed to the review service
+type ReviewedGame struct {
+	ID     string
+	Mode   string       // ai, llm or pvp
+	Moves  []model.Move // Black first, alternating
+	Winner int          // 1 or 2; 0 for a draw or a game without a winner
+}
+
+// GameSource looks finished games up by ID for review. It returns ErrGameNotFound for
+// games it does not know and ErrGameNotFinished for games still in progress.
+type GameSource interface {
+	FinishedGame(id string) (ReviewedGame, error)
+}
+
+// MoveReview is the engine's verdict on one move
+type MoveReview struct {
+	Number         int          `json:"number"` // 1-based
+	X              int          `json:"x"`
+	Y              int          `json:"y"`
+	Player         int          `json:"player"`
+	Score          int          `json:"score"`          // Played move's value for the mover
+	BestScore      int          `json:"bestScore"`      // Best move's value for the mover
+	Swing          int          `json:"swing"`          // Evaluation lost against the best move
+	WinProbability float64      `json:"winProbability"` // Mover's chance after the move
+	Loss           float64      `json:"loss"`           // Win probability lost against the best move
+	Best           model.Move   `json:"best"`           // Engine's choice in the position
+	BestLine       []model.Move `json:"bestLine"`
+	Classification string       `json:"classification"`
+}
+
+// PlayerReview sums one player's moves
+type PlayerReview struct {
+	Player       int     `json:"player"`
+	Moves        int     `json:"moves"`
+	Best         int     `json:"best"`
+	Inaccuracies int     `json:"inaccuracies"`
+	Mistakes     int     `json:"mistakes"`
+	Blunders     int     `json:"blunders"`
+	AverageLoss  float64 `json:"averageLoss"`
+}
+
+// GameReview is the analysis of a finished game
+type GameReview struct {
+	GameID      string          `json:"gameId"`
+	Mode        string          `json:"mode"`
+	Status      string          `json:"status"` // queued, running, done or failed
+	Error       string          `json:"error,omitempty"`
+	Progress    int             `json:"progress"` // Moves reviewed so far
+	Total       int             `json:"total"`
+	Winner      int             `json:"winner"`
+	DecidedAt   int             `json:"decidedAt"` // Move after which the winner was never in doubt; 0 if none
+	Moves       []MoveReview    `json:"moves"`
+	Players     [2]PlayerReview `json:"players"` // Black, then white
+	CreatedAt   time.Time       `json:"createdAt"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+}
+
+// reviewJob is a queued review along with its game
+type reviewJob struct {
+	game   ReviewedGame
+	review *GameReview
+}
+
+// GameReviewService analyses finished games one at a time in the background and caches the
+// reviews per game
+type GameReviewService struct {
+	ai      *EnhancedAIService
+	limits  SearchLimits
+	sources []GameSource
+	queue   chan reviewJob
+	reviews map[string]*GameReview
+	order   []string // Reviewed game IDs, oldest first
+	mutex   sync.Mutex
+}
+
+// NewGameReviewService creates the service and starts its worker. Every position is searched
+// within the limits.
+func NewGameReviewService(limits SearchLimits, sources ...GameSource) *GameReviewService {
+	s := &GameReviewService{
+		ai:      NewEnhancedAIService(),
+		limits:  limits,
+		sources: sources,
+		queue:   make(chan reviewJob, reviewQueueSize),
+		reviews: make(map[string]*GameReview),
+	}
+	go s.run()
+	return s
+}
+
+// AddSource adds a place to look finished games up in
+func (s *GameReviewService) AddSource(source GameSource) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sources = append(s.sources, source)
+}
+
+// Review returns the game's review, queueing it the first time the game is asked for. A
+// failed review is queued again if its game can still be found.
+func (s *GameReviewService) Review(gameID string) (GameReview, error) {
+	s.mutex.Lock()
+	review, exists := s.reviews[gameID]
+	sources := s.sources
+	s.mutex.Unlock()
+	if exists && review.Status != ReviewFailed {
+		return s.snapshot(review), nil
+	}
+
+	for _, source := range sources {
+		game, err := source.FinishedGame(gameID)
+		if errors.Is(err, ErrGameNotFound) {
+			continue
+		}
+		if err != nil {
+			return GameReview{}, err
+		}
+		return s.Submit(game)
+	}
+
+	// Submitted games have no source to fetch them from again
+	if exists {
+		return s.snapshot(review), nil
+	}
+	return GameReview{}, ErrGameNotFound
+}
+
+// Submit queues a review of the game unless it is already reviewed or waiting. A game
+// without an ID is given one.
+func (s *GameReviewService) Submit(game ReviewedGame) (GameReview, error) {
+	if game.ID == "" {
+		game.ID = "review_" + uuid.New().String()
+	}
+	if err := validateReviewedGame(game); err != nil {
+		return GameReview{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if review, exists := s.reviews[game.ID]; exists && review.Status != ReviewFailed {
+		return s.copyReview(review), nil
+	}
+	review := &GameReview{
+		GameID:    game.ID,
+		Mode:      game.Mode,
+		Status:    ReviewQueued,
+		Total:     len(game.Moves),
+		Winner:    game.Winner,
+		Moves:     []MoveReview{},
+		Players:   [2]PlayerReview{{Player: 1}, {Player: 2}},
+		CreatedAt: time.Now(),
+	}
+	select {
+	case s.queue <- reviewJob{game: game, review: review}:
+	default:
+		return GameReview{}, ErrReviewQueueFull
+	}
+
+	if _, exists := s.reviews[game.ID]; !exists {
+		s.order = append(s.order, game.ID)
+	}
+	s.reviews[game.ID] = review
+	s.evict()
+	return s.copyReview(review), nil
+}
+
+// run reviews queued games until the program exits
+func (s *GameReviewService) run() {
+	for job := range s.queue {
+		s.mutex.Lock()
+		job.review.Status = ReviewRunning
+		s.mutex.Unlock()
+
+		err := s.reviewGame(context.Background(), job.game, job.review)
+
+		s.mutex.Lock()
+		now := time.Now()
+		job.review.CompletedAt = &now
+		job.review.Status = ReviewDone
+		if err != nil {
+			job.review.Status = ReviewFailed
+			job.review.Error = err.Error()
+			log.Printf("Analysis of game %s failed: %v", job.game.ID, err)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// reviewGame searches the position before every move and after the last one. The value of a
+// position for the side to move is the best move's value; the played move's value is the
+// negated value of the position it leads to. A five on the board settles the winner.
+func (s *GameReviewService) reviewGame(ctx context.Context, game ReviewedGame, review *GameReview) error {
+	board := createGrid(15)
+	lastMove := model.Move{X: -1, Y: -1}
+	winner := game.Winner
+	current, err := s.ai.Analyze(ctx, Position{Board: copyBoard(board), LastMove: lastMove, ToMove: 1}, s.limits, 1)
+	if err != nil {
+		return err
+	}
+
+	for i, move := range game.Moves {
+		player := i%2 + 1
+		board[move.Y][move.X] = player
+		lastMove = model.Move{X: move.X, Y: move.Y, Player: player}
+
+		// The position after the move, from the opponent's point of view
+		var next PositionAnalysis
+		if fiveInRow(board, move.X, move.Y, player) {
+			next = PositionAnalysis{Score: -mateScore, GameOver: true}
+			winner = player
+		} else if next, err = s.ai.Analyze(ctx, Position{Board: copyBoard(board), LastMove: lastMove, ToMove: 3 - player}, s.limits, 1); err != nil {
+			return err
+		}
+
+		moveReview := reviewMove(i+1, lastMove, current, -next.Score)
+		s.mutex.Lock()
+		review.Moves = append(review.Moves, moveReview)
+		review.Progress = i + 1
+		s.mutex.Unlock()
+		current = next
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	review.Winner = winner
+	review.DecidedAt = decidedAt(review.Moves, winner)
+	review.Players = summarizeReview(review.Moves)
+	return nil
+}
+
+// reviewMove compares the played move's value with the best move in the analysed position
+func reviewMove(number int, move model.Move, before PositionAnalysis, played int) MoveReview {
+	review := MoveReview{
+		Number:         number,
+		X:              move.X,
+		Y:              move.Y,
+		Player:         move.Player,
+		Score:          played,
+		BestScore:      played,
+		WinProbability: WinProbability(played),
+		Best:           move,
+		BestLine:       []model.Move{},
+		Classification: MoveBest,
+	}
+	if len(before.Candidates) == 0 {
+		return review
+	}
+
+	best := before.Candidates[0]
+	review.Best = model.Move{X: best.X, Y: best.Y, Player: move.Player}
+	review.BestLine = best.PV
+	if best.X == move.X && best.Y == move.Y {
+		return review
+	}
+
+	// Searches from different roots disagree a little; the played move never beats the best
+	review.BestScore = max(best.Score, played)
+	review.Swing = review.BestScore - played
+	if review.Swing == 0 {
+		return review
+	}
+	review.Loss = WinProbability(review.BestScore) - review.WinProbability
+	review.Classification = classifyMove(review.BestScore, played, review.Loss)
+	return review
+}
+
+// classifyMove names a move from the win probability it gave away. Missing a forced win or
+// walking into a forced loss is always a blunder.
+func classifyMove(best, played int, loss float64) string {
+	switch {
+	case best >= decisiveScore && played < decisiveScore,
+		played <= -decisiveScore && best > -decisiveScore,
+		loss >= blunderLoss:
+		return MoveBlunder
+	case loss >= mistakeLoss:
+		return MoveMistake
+	case loss >= inaccuracyLoss:
+		return MoveInaccuracy
+	}
+	return MoveGood
+}
+
+// decidedAt returns the first move from which the winner's chance stayed at decidedChance or
+// more until the end, or 0 for a game without a winner
+func decidedAt(moves []MoveReview, winner int) int {
+	if winner == 0 {
+		return 0
+	}
+	decided := 0
+	for i := len(moves) - 1; i >= 0; i-- {
+		chance := moves[i].WinProbability
+		if moves[i].Player != winner {
+			chance = 1 - chance
+		}
+		if chance < decidedChance {
+			break
+		}
+		decided = moves[i].Number
+	}
+	return decided
+}
+
+// summarizeReview counts each player's move classifications
+func summarizeReview(moves []MoveReview) [2]PlayerReview {
+	players := [2]PlayerReview{{Player: 1}, {Player: 2}}
+	for _, move := range moves {
+		player := &players[move.Player-1]
+		player.Moves++
+		player.AverageLoss += move.Loss
+		switch move.Classification {
+		case MoveBest:
+			player.Best++
+		case MoveInaccuracy:
+			player.Inaccuracies++
+		case MoveMistake:
+			player.Mistakes++
+		case MoveBlunder:
+			player.Blunders++
+		}
+	}
+	for i := range players {
+		if players[i].Moves > 0 {
+			players[i].AverageLoss /= float64(players[i].Moves)
+		}
+	}
+	return players
+}
+
+// validateReviewedGame checks the moves alternate on empty cells and end no later than a five
+func validateReviewedGame(game ReviewedGame) error {
+	if len(game.Moves) == 0 {
+		return errors.New("game has no moves")
+	}
+	if game.Winner < 0 || game.Winner > 2 {
+		return errors.New("winner must be 0, 1 or 2")
+	}
+	for i, move := range game.Moves {
+		if move.Player != 0 && move.Player != i%2+1 {
+			return fmt.Errorf("move %d is not played by player %d", i+1, i%2+1)
+		}
+	}
+
+	board, err := openingBoard(game.Moves[:len(game.Moves)-1])
+	if err != nil {
+		return err
+	}
+	last := game.Moves[len(game.Moves)-1]
+	if last.X < 0 || last.X >= 15 || last.Y < 0 || last.Y >= 15 || board[last.Y][last.X] != 0 {
+		return fmt.Errorf("invalid move %d,%d", last.X, last.Y)
+	}
+	return nil
+}
+
+// evict forgets the oldest finished reviews beyond the cache size
+func (s *GameReviewService) evict() {
+	for len(s.order) > maxCachedReviews {
+		evicted := false
+		for i, id := range s.order {
+			status := s.reviews[id].Status
+			if status == ReviewDone || status == ReviewFailed {
+				delete(s.reviews, id)
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				evicted = true
+				break
+			}
+		}
+		if !evicted {
+			return
+		}
+	}
+}
+
+// snapshot copies a review under the lock
+func (s *GameReviewService) snapshot(review *GameReview) GameReview {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.copyReview(review)
+}
+
+// copyReview copies a review so the worker can keep filling it in; the caller holds the lock
+func (s *GameReviewService) copyReview(review *GameReview) GameReview {
+	result := *review
+	result.Moves = append([]MoveReview{}, review.Moves...)
+	return result
+}
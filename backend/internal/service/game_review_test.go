@@ -0,0 +1,132 @@
+// Unit tests for post-game analysis
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// gameSourceFunc adapts a function to GameSource
+type gameSourceFunc func(id string) (ReviewedGame, error)
+
+func (f gameSourceFunc) FinishedGame(id string) (ReviewedGame, error) {
+	return f(id)
+}
+
+// waitForReview polls the service until the review is finished
+func waitForReview(t *testing.T, s *GameReviewService, id string) GameReview {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		review, err := s.Review(id)
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if review.Status == ReviewDone || review.Status == ReviewFailed {
+			return review
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Review did not finish")
+	return GameReview{}
+}
+
+func TestGameReview(t *testing.T) {
+	// White ignores black's row until the open three becomes an open four
+	moves := []model.Move{
+		{X: 7, Y: 7}, {X: 0, Y: 0},
+		{X: 8, Y: 7}, {X: 14, Y: 14},
+		{X: 9, Y: 7}, {X: 0, Y: 14},
+		{X: 10, Y: 7}, {X: 14, Y: 0},
+		{X: 11, Y: 7},
+	}
+	s := NewGameReviewService(SearchLimits{Difficulty: Medium, Depth: 2})
+
+	submitted, err := s.Submit(ReviewedGame{Mode: "ai", Moves: moves})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if submitted.GameID == "" || submitted.Total != len(moves) {
+		t.Fatalf("Unexpected submitted review %+v", submitted)
+	}
+
+	review := waitForReview(t, s, submitted.GameID)
+	if review.Status != ReviewDone || len(review.Moves) != len(moves) {
+		t.Fatalf("Expected a finished review of every move, got %s with %d moves (%s)", review.Status, len(review.Moves), review.Error)
+	}
+	if review.Winner != 1 {
+		t.Errorf("Expected the five to make black the winner, got %d", review.Winner)
+	}
+
+	missed := review.Moves[5]
+	if missed.Classification != MoveBlunder || missed.Best.Y != 7 || missed.Swing <= 0 {
+		t.Errorf("Expected leaving the open three to be a blunder, got %+v", missed)
+	}
+	last := review.Moves[len(moves)-1]
+	if last.Classification != MoveBest || last.WinProbability != 1 {
+		t.Errorf("Expected the winning move to be best, got %+v", last)
+	}
+	if review.DecidedAt == 0 || review.DecidedAt > missed.Number {
+		t.Errorf("Expected the game to be decided by move %d, got %d", missed.Number, review.DecidedAt)
+	}
+	if white := review.Players[1]; white.Moves != 4 || white.Blunders == 0 {
+		t.Errorf("Unexpected summary for white %+v", white)
+	}
+
+	// The review is cached
+	again, err := s.Submit(ReviewedGame{ID: submitted.GameID, Moves: moves})
+	if err != nil || again.CompletedAt == nil || !again.CompletedAt.Equal(*review.CompletedAt) {
+		t.Error("Expected the cached review to be returned")
+	}
+}
+
+func TestGameReviewSources(t *testing.T) {
+	source := gameSourceFunc(func(id string) (ReviewedGame, error) {
+		switch id {
+		case "playing":
+			return ReviewedGame{}, ErrGameNotFinished
+		case "finished":
+			return ReviewedGame{ID: id, Mode: "pvp", Moves: []model.Move{{X: 7, Y: 7}, {X: 8, Y: 8}}}, nil
+		}
+		return ReviewedGame{}, ErrGameNotFound
+	})
+	s := NewGameReviewService(SearchLimits{Depth: 1}, source)
+
+	if _, err := s.Review("missing"); err != ErrGameNotFound {
+		t.Errorf("Expected ErrGameNotFound, got %v", err)
+	}
+	if _, err := s.Review("playing"); err != ErrGameNotFinished {
+		t.Errorf("Expected ErrGameNotFinished, got %v", err)
+	}
+	review := waitForReview(t, s, "finished")
+	if review.Mode != "pvp" || review.Winner != 0 || review.DecidedAt != 0 {
+		t.Errorf("Expected an undecided PVP review, got %+v", review)
+	}
+
+	invalid := [][]model.Move{
+		nil,
+		{{X: 7, Y: 7}, {X: 7, Y: 7}},
+		{{X: 7, Y: 7, Player: 2}},
+		{{X: 15, Y: 0}},
+	}
+	for i, moves := range invalid {
+		if _, err := s.Submit(ReviewedGame{Moves: moves}); err == nil {
+			t.Errorf("Expected invalid game %d to be rejected", i)
+		}
+	}
+}
+
+func TestClassifyMove(t *testing.T) {
+	tests := map[float64]string{0.01: MoveGood, 0.07: MoveInaccuracy, 0.15: MoveMistake, 0.5: MoveBlunder}
+	for loss, want := range tests {
+		if got := classifyMove(0, 0, loss); got != want {
+			t.Errorf("classifyMove with loss %v = %s, want %s", loss, got, want)
+		}
+	}
+
+	// Forced results decide regardless of the probabilities
+	if classifyMove(winScore, 5000, 0.01) != MoveBlunder || classifyMove(-5000, -mateScore, 0.01) != MoveBlunder {
+		t.Error("Expected missing a win and walking into a loss to be blunders")
+	}
+}
@@ -3,6 +3,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -11,16 +13,31 @@ import (
 	"gomoku-backend/internal/model"
 )
 
+// ErrNotYourTurn is returned when a player moves out of turn
+var ErrNotYourTurn = errors.New("not your turn")
+
+// ErrGameNotInProgress is returned when moving in a game that is not being played
+var ErrGameNotInProgress = errors.New("game is not in progress")
+
 // GameService manages game rooms and online matches for PVP feature
 type GameService struct {
-	rooms map[string]*model.Room
-	mutex sync.RWMutex
+	rooms    map[string]*model.Room
+	aiSeats  map[string]aiSeat // AI players by player ID
+	finished []func(ReviewedGame)
+	mutex    sync.RWMutex
+}
+
+// aiSeat is the engine and strength an AI player plays with
+type aiSeat struct {
+	engine     Engine
+	difficulty Difficulty
 }
 
 // NewGameService creates a new game service instance
 func NewGameService() *GameService {
 	return &GameService{
-		rooms: make(map[string]*model.Room),
+		rooms:   make(map[string]*model.Room),
+		aiSeats: make(map[string]aiSeat),
 	}
 }
 
@@ -73,6 +90,75 @@ func (gs *GameService) JoinRoom(roomID, playerName string) (*model.Room, *model.
 	return room, player, nil
 }
 
+// AddAIPlayer seats an AI playing in the named personality's style in a waiting room
+func (gs *GameService) AddAIPlayer(roomID, personality string, difficulty Difficulty) (*model.Room, *model.PVPPlayer, error) {
+	engine, exists := PersonalityAI(personality)
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown personality: %s", personality)
+	}
+	
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+	
+	room, exists := gs.rooms[roomID]
+	if !exists {
+		return nil, nil, fmt.Errorf("room not found")
+	}
+	
+	if room.Status != "waiting" {
+		return nil, nil, fmt.Errorf("room is not accepting new players")
+	}
+	
+	player := room.AddAIPlayer("AI ("+personality+")", personality)
+	if player == nil {
+		return nil, nil, fmt.Errorf("room is full")
+	}
+	gs.aiSeats[player.ID] = aiSeat{engine: engine, difficulty: difficulty}
+	
+	return room, player, nil
+}
+
+// PlayAIMove plays the move of the AI seat whose turn it is. The move is nil when it is not
+// an AI's turn.
+func (gs *GameService) PlayAIMove(ctx context.Context, roomID string) (*model.Room, *model.PVPMove, error) {
+	gs.mutex.RLock()
+	room, exists := gs.rooms[roomID]
+	if !exists || room.Game == nil || room.Game.Status != "playing" {
+		gs.mutex.RUnlock()
+		return room, nil, nil
+	}
+	playerID := room.Game.CurrentPlayer
+	seat, isAI := gs.aiSeats[playerID]
+	player := room.GetPlayer(playerID)
+	if !isAI || player == nil {
+		gs.mutex.RUnlock()
+		return room, nil, nil
+	}
+	position := Position{Board: copyBoard(room.Game.Board), LastMove: model.Move{X: -1, Y: -1}, ToMove: player.PlayerNumber}
+	if moves := room.Game.Moves; len(moves) > 0 {
+		last := moves[len(moves)-1]
+		position.LastMove = model.Move{X: last.X, Y: last.Y, Player: 3 - player.PlayerNumber}
+	}
+	gs.mutex.RUnlock()
+	
+	// Search without holding the lock; MakeMove checks the turn again
+	aiMove, err := seat.engine.BestMove(ctx, position, SearchLimits{Difficulty: seat.difficulty})
+	if err != nil {
+		return nil, nil, err
+	}
+	return gs.MakeMove(roomID, playerID, aiMove.X, aiMove.Y)
+}
+
+// deleteRoom removes a room and its AI seats; the caller holds the lock
+func (gs *GameService) deleteRoom(roomID string) {
+	if room, exists := gs.rooms[roomID]; exists {
+		for _, player := range room.Players {
+			delete(gs.aiSeats, player.ID)
+		}
+	}
+	delete(gs.rooms, roomID)
+}
+
 // GetRoom retrieves a room by ID
 func (gs *GameService) GetRoom(roomID string) *model.Room {
 	gs.mutex.RLock()
@@ -114,7 +200,7 @@ func (gs *GameService) MakeMove(roomID, playerID string, x, y int) (*model.Room,
 	}
 	
 	if room.Game.Status != "playing" {
-		return nil, nil, fmt.Errorf("game is not in progress")
+		return nil, nil, ErrGameNotInProgress
 	}
 	
 	// Validate player
@@ -125,7 +211,7 @@ func (gs *GameService) MakeMove(roomID, playerID string, x, y int) (*model.Room,
 	
 	// Validate turn
 	if room.Game.CurrentPlayer != playerID {
-		return nil, nil, fmt.Errorf("not your turn")
+		return nil, nil, ErrNotYourTurn
 	}
 	
 	// Make the move
@@ -133,10 +219,25 @@ func (gs *GameService) MakeMove(roomID, playerID string, x, y int) (*model.Room,
 	if move == nil {
 		return nil, nil, fmt.Errorf("invalid move")
 	}
+	if room.Game.Status == "finished" {
+		game := reviewedRoomGame(room, room.Game.ID)
+		for _, handler := range gs.finished {
+			handler(game)
+		}
+	}
 	
 	return room, move, nil
 }
 
+// OnGameFinished registers a function called with every game that ends on the board. It is
+// called with the service locked, so it must not block or call back into the service.
+func (gs *GameService) OnGameFinished(handler func(ReviewedGame)) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+	
+	gs.finished = append(gs.finished, handler)
+}
+
 // CleanupRooms removes inactive rooms
 func (gs *GameService) CleanupRooms() {
 	gs.mutex.Lock()
@@ -146,7 +247,7 @@ func (gs *GameService) CleanupRooms() {
 	for roomID, room := range gs.rooms {
 		// Remove rooms that have been inactive for more than 1 hour
 		if now.Sub(room.CreatedAt) > time.Hour {
-			delete(gs.rooms, roomID)
+			gs.deleteRoom(roomID)
 		}
 	}
 }
@@ -198,9 +299,9 @@ func (gs *GameService) LeaveRoom(roomID, playerID string) error {
 	
 	room.RemovePlayer(playerID)
 	
-	// If room is empty, delete it
-	if len(room.Players) == 0 {
-		delete(gs.rooms, roomID)
+	// If only AI seats are left, delete the room
+	if !room.HasHumanPlayers() {
+		gs.deleteRoom(roomID)
 	}
 	
 	return nil
@@ -216,6 +317,39 @@ func (gs *GameService) GetRoomStatus(roomID string) (string, error) {
 	return room.Status, nil
 }
 
+// FinishedGame returns a finished PVP game for review, found by its ID or its room's ID
+func (gs *GameService) FinishedGame(gameID string) (ReviewedGame, error) {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	
+	var room *model.Room
+	for _, candidate := range gs.rooms {
+		if candidate.ID == gameID || (candidate.Game != nil && candidate.Game.ID == gameID) {
+			room = candidate
+			break
+		}
+	}
+	if room == nil || room.Game == nil {
+		return ReviewedGame{}, ErrGameNotFound
+	}
+	if room.Game.Status != "finished" {
+		return ReviewedGame{}, ErrGameNotFinished
+	}
+	return reviewedRoomGame(room, gameID), nil
+}
+
+// reviewedRoomGame converts a room's game for review under the given ID
+func reviewedRoomGame(room *model.Room, gameID string) ReviewedGame {
+	reviewed := ReviewedGame{ID: gameID, Mode: "pvp"}
+	for i, move := range room.Game.Moves {
+		reviewed.Moves = append(reviewed.Moves, model.Move{X: move.X, Y: move.Y, Player: i%2 + 1})
+	}
+	if winner := room.GetPlayer(room.Game.Winner); winner != nil {
+		reviewed.Winner = winner.PlayerNumber
+	}
+	return reviewed
+}
+
 // SetPlayerReady sets a player's ready status
 func (gs *GameService) SetPlayerReady(roomID, playerID string, ready bool) error {
 	gs.mutex.Lock()
@@ -270,9 +404,9 @@ func (gs *GameService) HandlePlayerDisconnect(roomID, playerID string) error {
 	room.RemovePlayer(playerID)
 	room.UpdatedAt = time.Now()
 	
-	// If room is empty, delete it
-	if len(room.Players) == 0 {
-		delete(gs.rooms, roomID)
+	// If only AI seats are left, delete the room
+	if !room.HasHumanPlayers() {
+		gs.deleteRoom(roomID)
 	} else {
 		// Reset room status to waiting if there are remaining players
 		room.Status = "waiting"
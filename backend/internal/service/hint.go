@@ -0,0 +1,171 @@
+// Package service contains move hints for human players
+// This file recommends a move and explains it in plain words from the shapes it makes
+// and the threats it blocks
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gomoku-backend/internal/model"
+)
+
+// Hint is a recommended move with a short explanation
+type Hint struct {
+	X              int          `json:"x"`
+	Y              int          `json:"y"`
+	Player         int          `json:"player"`
+	Score          int          `json:"score"`
+	WinProbability float64      `json:"winProbability"`
+	Reasons        []string     `json:"reasons"`
+	Explanation    string       `json:"explanation"`
+	PV             []model.Move `json:"pv,omitempty"`
+}
+
+// Hint searches the best move for the side to move and explains it
+func (ai *EnhancedAIService) Hint(ctx context.Context, position Position, limits SearchLimits) (Hint, error) {
+	move, err := ai.BestMove(ctx, position, limits)
+	if err != nil {
+		return Hint{}, err
+	}
+	if position.Board[move.Y][move.X] != 0 {
+		return Hint{}, errors.New("no legal move left")
+	}
+
+	hint := Hint{
+		X:              move.X,
+		Y:              move.Y,
+		Player:         position.ToMove,
+		Score:          move.Score,
+		WinProbability: WinProbability(move.Score),
+		Reasons:        ExplainMove(position.Board, move.X, move.Y, position.ToMove),
+		PV:             move.PV,
+	}
+	switch {
+	case move.FromBook:
+		hint.Reasons = append(hint.Reasons, "is a known strong opening move")
+	case move.Score >= winScore && !strings.HasPrefix(hint.Reasons[0], "wins"):
+		hint.Reasons = append(hint.Reasons, fmt.Sprintf("forces a win within %d moves", (len(move.PV)+1)/2))
+	case move.Score <= -winScore:
+		hint.Reasons = append(hint.Reasons, "holds out longest in a lost position")
+	}
+	hint.Explanation = joinReasons(cellName(move.X, move.Y), hint.Reasons)
+	return hint, nil
+}
+
+// ExplainMove describes in words what playing (x, y) does for the player, most urgent first:
+// winning, blocking fives and open threes, and the fours, threes and twos it makes
+func ExplainMove(board [][]int, x, y, player int) []string {
+	pb := newPatternBoard(board)
+	opponent := 3 - player
+	var reasons []string
+
+	// Shapes made by playing here and shapes the opponent would make here
+	var own, opp [4]Shape
+	fours, threes := 0, 0
+	for d := range directions {
+		own[d] = pb.shape(x, y, d, player)
+		opp[d] = pb.shape(x, y, d, opponent)
+		if own[d].IsFour() {
+			fours++
+		}
+		if own[d] == ShapeOpenThree || own[d] == ShapeBrokenThree {
+			threes++
+		}
+	}
+
+	for d := range directions {
+		if own[d] == ShapeFive {
+			return []string{"wins with five in a row on " + lineName(x, y, d)}
+		}
+	}
+	for d := range directions {
+		if opp[d] == ShapeFive {
+			reasons = append(reasons, "blocks the four on "+lineName(x, y, d))
+		}
+	}
+
+	switch {
+	case fours >= 2:
+		reasons = append(reasons, "creates a double four")
+	case fours == 1 && threes >= 1:
+		reasons = append(reasons, "creates a four-three")
+	case threes >= 2:
+		reasons = append(reasons, "creates a double three")
+	}
+
+	for d := range directions {
+		if opp[d] == ShapeOpenFour {
+			reasons = append(reasons, "blocks the open three on "+lineName(x, y, d))
+		}
+	}
+
+	if fours < 2 && threes < 2 && !(fours == 1 && threes >= 1) {
+		for d := range directions {
+			switch {
+			case own[d] == ShapeOpenFour:
+				reasons = append(reasons, "makes an open four on "+lineName(x, y, d))
+			case own[d].IsFour():
+				reasons = append(reasons, "makes a four on "+lineName(x, y, d)+", forcing a reply")
+			case own[d].IsOpenThree():
+				reasons = append(reasons, "makes an open three on "+lineName(x, y, d))
+			}
+		}
+	}
+
+	if len(reasons) == 0 {
+		for d := range directions {
+			if opp[d].IsFour() || opp[d].IsOpenThree() {
+				reasons = append(reasons, "stops the opponent building on "+lineName(x, y, d))
+				break
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		for d := range directions {
+			if own[d] == ShapeOpenTwo {
+				reasons = append(reasons, "builds an open two on "+lineName(x, y, d))
+				break
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		if centrality(x, y, pb.size) >= pb.size/2-2 {
+			reasons = append(reasons, "takes space near the centre")
+		} else {
+			reasons = append(reasons, "is the strongest move the search found")
+		}
+	}
+	return reasons
+}
+
+// joinReasons builds a sentence such as "H8 blocks the four on row 8 and makes an open three on column H."
+func joinReasons(cell string, reasons []string) string {
+	switch len(reasons) {
+	case 0:
+		return cell + "."
+	case 1:
+		return cell + " " + reasons[0] + "."
+	}
+	return cell + " " + strings.Join(reasons[:len(reasons)-1], ", ") + " and " + reasons[len(reasons)-1] + "."
+}
+
+// cellName names a cell with a column letter and a 1-based row, e.g. H8 for the centre
+func cellName(x, y int) string {
+	return fmt.Sprintf("%c%d", 'A'+x, y+1)
+}
+
+// lineName names the line through (x, y) in direction d
+func lineName(x, y, d int) string {
+	switch d {
+	case 0:
+		return fmt.Sprintf("row %d", y+1)
+	case 1:
+		return fmt.Sprintf("column %c", 'A'+x)
+	case 2:
+		return "the diagonal through " + cellName(x, y)
+	}
+	return "the anti-diagonal through " + cellName(x, y)
+}
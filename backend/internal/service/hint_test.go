@@ -0,0 +1,118 @@
+// Unit tests for move hints
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestExplainMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		col     int // column within the pattern to play
+		player  int // side playing, with 'X' its stones
+		want    string
+	}{
+		{"five", "_XXXX_", 0, 1, "wins with five in a row on row 8"},
+		{"block four", "_OOOOX", 0, 1, "blocks the four on row 8"},
+		{"block open three", "__OOO__", 1, 1, "blocks the open three on row 8"},
+		{"open four", "__XXX__", 1, 1, "makes an open four on row 8"},
+		{"open three", "__XX___", 4, 1, "makes an open three on row 8"},
+	}
+
+	for _, tt := range tests {
+		board := lineBoard(tt.pattern, tt.player)
+		reasons := ExplainMove(board, 3+tt.col, 7, tt.player)
+		if len(reasons) == 0 || reasons[0] != tt.want {
+			t.Errorf("%s: expected %q first, got %v", tt.name, tt.want, reasons)
+		}
+	}
+
+	// A four on row 8 and an open three on column H
+	board := lineBoard("_XXX__", 1)
+	board[8][7], board[9][7] = 1, 1
+	reasons := ExplainMove(board, 7, 7, 1)
+	if reasons[0] != "creates a four-three" {
+		t.Errorf("Expected a four-three, got %v", reasons)
+	}
+}
+
+func TestHint(t *testing.T) {
+	ai := NewEnhancedAIService()
+	board := lineBoard("__OOO__", 1)
+	board[10][10] = 1
+	board[11][11] = 1
+	position := Position{Board: board, LastMove: model.Move{X: 7, Y: 7}, ToMove: 1}
+
+	hint, err := ai.Hint(context.Background(), position, SearchLimits{Difficulty: Medium})
+	if err != nil {
+		t.Fatalf("Hint failed: %v", err)
+	}
+	if hint.Y != 7 || (hint.X != 4 && hint.X != 8) {
+		t.Errorf("Expected a block of the open three, got (%d,%d)", hint.X, hint.Y)
+	}
+	if !strings.HasPrefix(hint.Explanation, cellName(hint.X, hint.Y)+" ") || !strings.Contains(hint.Explanation, "blocks the open three on row 8") {
+		t.Errorf("Unexpected explanation %q", hint.Explanation)
+	}
+}
+
+func TestRecordHint(t *testing.T) {
+	s := NewLLMService()
+	game := model.NewLLMGame("ollama", "medium")
+	game.Board.Grid[7][7] = 1
+	game.AddMove(model.LLMMove{X: 7, Y: 7, Player: 1})
+	game.Board.Grid[7][8] = 2
+	game.AddMove(model.LLMMove{X: 8, Y: 7, Player: 2})
+	s.games[game.ID] = game
+
+	for want := 1; want <= 2; want++ {
+		position, hints, err := s.RecordHint(game.ID)
+		if err != nil {
+			t.Fatalf("RecordHint failed: %v", err)
+		}
+		if hints != want || game.Hints != want {
+			t.Errorf("Expected %d hints, got %d", want, hints)
+		}
+		if position.ToMove != 1 || position.LastMove.X != 8 || position.Board[7][8] != 2 {
+			t.Errorf("Unexpected position %+v", position)
+		}
+		position.Board[0][0] = 1
+		if game.Board.Grid[0][0] != 0 {
+			t.Error("Expected the position to be a copy of the game board")
+		}
+	}
+
+	if _, _, err := s.RecordHint("missing"); err != ErrGameNotFound {
+		t.Errorf("Expected ErrGameNotFound, got %v", err)
+	}
+}
+
+func TestAIGameRecordHint(t *testing.T) {
+	s := NewAIGameService()
+	turn := newTestAIGame(t, s, 1)
+	id := turn.Game.ID
+	opening := turn.Game.Moves[0]
+
+	position, hints, err := s.RecordHint(id)
+	if err != nil {
+		t.Fatalf("RecordHint failed: %v", err)
+	}
+	if hints != 1 || position.ToMove != 2 || position.LastMove.X != opening.X || position.Board[opening.Y][opening.X] != 1 {
+		t.Errorf("Unexpected hint position %+v after %d hints", position, hints)
+	}
+	if game, _ := s.GetGame(id); game.Hints != 1 {
+		t.Errorf("Expected the hint to be counted on the game, got %d", game.Hints)
+	}
+
+	s.Resign(id)
+	if _, _, err := s.RecordHint(id); err == nil {
+		t.Error("Expected no hint in a finished game")
+	}
+	if _, _, err := s.RecordHint("missing"); err != ErrGameNotFound {
+		t.Errorf("Expected ErrGameNotFound, got %v", err)
+	}
+}
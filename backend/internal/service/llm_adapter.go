@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,51 @@ type LLMAdapter interface {
 	GetModelInfo() model.LLMModel
 }
 
+// Errors an adapter wraps when the provider turns a request away for now; the same request
+// may succeed later
+var (
+	ErrLLMRateLimited = errors.New("LLM provider rate limit exceeded")
+	ErrLLMOverloaded  = errors.New("LLM provider overloaded")
+)
+
+// statusOverloaded is the non-standard status some providers send when they are overloaded
+const statusOverloaded = 529
+
+// MoveCorrector is implemented by adapters that can ask the model again after a bad answer,
+// telling it what was wrong with each of its earlier answers this turn
+type MoveCorrector interface {
+	RetryMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) (*model.LLMMove, error)
+}
+
+// StreamingAdapter is implemented by adapters that can stream the model's answer as it is
+// written, passing each piece of text to onToken
+type StreamingAdapter interface {
+	StreamMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error)
+}
+
+// UnparsableMoveError reports a reply no move could be read from. The provider billed the
+// request all the same, so the usage is kept when the adapter knows it.
+type UnparsableMoveError struct {
+	Err   error
+	Usage *model.LLMUsage
+}
+
+// Error describes the parse failure
+func (e *UnparsableMoveError) Error() string {
+	return fmt.Sprintf("failed to parse move: %v", e.Err)
+}
+
+// Unwrap returns the underlying parse error
+func (e *UnparsableMoveError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigChecker is implemented by adapters that decide for themselves whether a
+// configuration is complete, such as those whose API key is optional
+type ConfigChecker interface {
+	IsConfigured(config model.LLMConfig) bool
+}
+
 // DeepSeekAdapter implements LLMAdapter for DeepSeek API
 type DeepSeekAdapter struct {
 	httpClient *http.Client
@@ -39,12 +85,27 @@ func NewDeepSeekAdapter() *DeepSeekAdapter {
 
 // GetMove implements LLMAdapter interface for DeepSeek
 func (d *DeepSeekAdapter) GetMove(board [][]int, lastMove model.Move, config model.LLMConfig) (*model.LLMMove, error) {
+	return d.RetryMove(board, lastMove, config, nil)
+}
+
+// RetryMove implements MoveCorrector interface for DeepSeek
+func (d *DeepSeekAdapter) RetryMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) (*model.LLMMove, error) {
+	return d.requestMove(board, lastMove, config, mistakes, nil)
+}
+
+// StreamMove implements StreamingAdapter interface for DeepSeek
+func (d *DeepSeekAdapter) StreamMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
+	return d.requestMove(board, lastMove, config, mistakes, onToken)
+}
+
+// requestMove asks DeepSeek for a move, streaming the answer to onToken unless it is nil
+func (d *DeepSeekAdapter) requestMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
 	if config.APIKey == "" {
 		return nil, errors.New("DeepSeek API key is required")
 	}
 
 	// Build the prompt for DeepSeek
-	prompt := d.buildGamePrompt(board, lastMove)
+	prompt := buildMovePrompt(board, lastMove) + jsonAnswerFormat + correctionPrompt(mistakes)
 
 	// Prepare request payload
 	requestBody := map[string]interface{}{
@@ -70,6 +131,10 @@ func (d *DeepSeekAdapter) GetMove(board [][]int, lastMove model.Move, config mod
 	if params, ok := config.Parameters["max_tokens"]; ok {
 		requestBody["max_tokens"] = params
 	}
+	if onToken != nil {
+		requestBody["stream"] = true
+		requestBody["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
@@ -99,40 +164,26 @@ func (d *DeepSeekAdapter) GetMove(board [][]int, lastMove model.Move, config mod
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, providerError(resp, openAIErrorMessage(body))
 	}
 
 	// Parse response
-	var response struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	var reply chatReply
+	if onToken != nil {
+		reply, err = readChatStream(resp.Body, onToken)
+	} else {
+		reply, err = decodeChatResponse(resp.Body)
 	}
-
-	if len(response.Choices) == 0 {
-		return nil, errors.New("no response from DeepSeek API")
-	}
-
-	// Parse the move from response
-	move, err := d.parseMove(response.Choices[0].Message.Content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse move: %v", err)
+		return nil, err
 	}
 
-	// Validate move
-	if !d.isValidMove(board, move.X, move.Y) {
-		// If invalid, find a random valid move
-		validMove := d.findValidMove(board)
-		move.X = validMove.X
-		move.Y = validMove.Y
-		move.Reasoning = "原始选择无效，选择了一个有效位置：" + move.Reasoning
+	// Parse the move from response; the service checks that it is legal
+	move, err := parseMoveJSON(reply.Content)
+	if err != nil {
+		return nil, &UnparsableMoveError{Err: err, Usage: reply.Usage}
 	}
+	move.Usage = reply.Usage
 
 	move.Player = 2 // LLM is player 2
 	move.Timestamp = time.Now()
@@ -167,132 +218,6 @@ func (d *DeepSeekAdapter) GetModelInfo() model.LLMModel {
 	}
 }
 
-// buildGamePrompt creates a prompt describing the current game state
-func (d *DeepSeekAdapter) buildGamePrompt(board [][]int, lastMove model.Move) string {
-	var prompt strings.Builder
-
-	prompt.WriteString("当前五子棋棋局状态：\n")
-	prompt.WriteString("棋盘大小：15x15\n")
-	prompt.WriteString("玩家标记：1=人类玩家(黑子), 2=AI(白子), 0=空位\n\n")
-
-	// Add board state
-	prompt.WriteString("棋盘状态：\n")
-	for y := 0; y < 15; y++ {
-		for x := 0; x < 15; x++ {
-			if x > 0 {
-				prompt.WriteString(" ")
-			}
-			prompt.WriteString(strconv.Itoa(board[y][x]))
-		}
-		prompt.WriteString("\n")
-	}
-
-	prompt.WriteString(fmt.Sprintf("\n上一步棋：人类玩家在位置(%d, %d)下了黑子\n", lastMove.X, lastMove.Y))
-	prompt.WriteString("现在轮到你(AI)下白子。\n\n")
-	prompt.WriteString("请分析棋局并选择最佳落子位置。考虑因素包括：\n")
-	prompt.WriteString("1. 是否能形成五连获胜\n")
-	prompt.WriteString("2. 是否需要阻止对手形成五连\n")
-	prompt.WriteString("3. 是否能形成活三、活四等威胁\n")
-	prompt.WriteString("4. 整体战略布局\n\n")
-	prompt.WriteString("请返回JSON格式：{\"x\": 横坐标(0-14), \"y\": 纵坐标(0-14), \"reasoning\": \"你的分析过程\"}")
-
-	return prompt.String()
-}
-
-// parseMove parses the LLM response to extract move information
-func (d *DeepSeekAdapter) parseMove(content string) (*model.LLMMove, error) {
-	// Try to find JSON in the response
-	start := strings.Index(content, "{")
-	end := strings.LastIndex(content, "}")
-
-	if start == -1 || end == -1 || start >= end {
-		return nil, errors.New("no valid JSON found in response")
-	}
-
-	jsonStr := content[start : end+1]
-
-	var moveData struct {
-		X         int    `json:"x"`
-		Y         int    `json:"y"`
-		Reasoning string `json:"reasoning"`
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &moveData); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
-	}
-
-	return &model.LLMMove{
-		X:          moveData.X,
-		Y:          moveData.Y,
-		Reasoning:  moveData.Reasoning,
-		Confidence: 0.8, // Default confidence for DeepSeek
-	}, nil
-}
-
-// isValidMove checks if a move is valid
-func (d *DeepSeekAdapter) isValidMove(board [][]int, x, y int) bool {
-	if x < 0 || x >= 15 || y < 0 || y >= 15 {
-		return false
-	}
-	return board[y][x] == 0
-}
-
-// findValidMove finds a random valid move as fallback
-func (d *DeepSeekAdapter) findValidMove(board [][]int) model.Move {
-	for y := 0; y < 15; y++ {
-		for x := 0; x < 15; x++ {
-			if board[y][x] == 0 {
-				return model.Move{X: x, Y: y}
-			}
-		}
-	}
-	// Should never reach here in a valid game
-	return model.Move{X: 7, Y: 7}
-}
-
-// ChatGPTAdapter implements LLMAdapter for OpenAI ChatGPT (placeholder)
-type ChatGPTAdapter struct {
-	httpClient *http.Client
-}
-
-// NewChatGPTAdapter creates a new ChatGPT adapter
-func NewChatGPTAdapter() *ChatGPTAdapter {
-	return &ChatGPTAdapter{
-		httpClient: &http.Client{
-			Timeout: 90 * time.Second, // 增加超时时间以适应大模型响应时间
-		},
-	}
-}
-
-// GetMove implements LLMAdapter interface for ChatGPT (placeholder implementation)
-func (c *ChatGPTAdapter) GetMove(board [][]int, lastMove model.Move, config model.LLMConfig) (*model.LLMMove, error) {
-	return nil, errors.New("ChatGPT adapter not implemented yet")
-}
-
-// ValidateConfig validates ChatGPT configuration
-func (c *ChatGPTAdapter) ValidateConfig(config model.LLMConfig) error {
-	if config.APIKey == "" {
-		return errors.New("API key is required for ChatGPT")
-	}
-	return nil
-}
-
-// GetModelInfo returns ChatGPT model information
-func (c *ChatGPTAdapter) GetModelInfo() model.LLMModel {
-	return model.LLMModel{
-		Name:           "chatgpt",
-		DisplayName:    "ChatGPT",
-		Provider:       "openai",
-		RequiresAPIKey: true,
-		DefaultParams: map[string]interface{}{
-			"model":       "gpt-3.5-turbo",
-			"temperature": 0.7,
-			"max_tokens":  1000,
-		},
-		Status: "unavailable", // Not implemented yet
-	}
-}
-
 // OllamaAdapter implements LLMAdapter for Ollama local models (placeholder)
 type OllamaAdapter struct {
 	httpClient *http.Client
@@ -309,14 +234,29 @@ func NewOllamaAdapter() *OllamaAdapter {
 
 // GetMove implements LLMAdapter interface for Ollama
 func (o *OllamaAdapter) GetMove(board [][]int, lastMove model.Move, config model.LLMConfig) (*model.LLMMove, error) {
+	return o.RetryMove(board, lastMove, config, nil)
+}
+
+// RetryMove implements MoveCorrector interface for Ollama
+func (o *OllamaAdapter) RetryMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) (*model.LLMMove, error) {
+	return o.requestMove(board, lastMove, config, mistakes, nil)
+}
+
+// StreamMove implements StreamingAdapter interface for Ollama
+func (o *OllamaAdapter) StreamMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
+	return o.requestMove(board, lastMove, config, mistakes, onToken)
+}
+
+// requestMove asks Ollama for a move, streaming the answer to onToken unless it is nil
+func (o *OllamaAdapter) requestMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
 	// Build the prompt for Ollama
-	prompt := o.buildGamePrompt(board, lastMove)
+	prompt := buildMovePrompt(board, lastMove) + jsonAnswerFormat + correctionPrompt(mistakes)
 
 	// Prepare request payload for Ollama API
 	requestBody := map[string]interface{}{
-		"model":  config.ModelName,
+		"model":  stringParam(config, "model", config.ModelName),
 		"prompt": prompt,
-		"stream": false,
+		"stream": onToken != nil,
 		"options": map[string]interface{}{
 			"temperature": 0.7,
 			"num_predict": 1000,
@@ -362,33 +302,30 @@ func (o *OllamaAdapter) GetMove(board [][]int, lastMove model.Move, config model
 	}
 
 	// Parse response
-	var response struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	if !response.Done {
-		return nil, errors.New("incomplete response from Ollama API")
+	var text string
+	var usage *model.LLMUsage
+	if onToken != nil {
+		text, usage, err = readOllamaStream(resp.Body, onToken)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var response ollamaChunk
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		if !response.Done {
+			return nil, errors.New("incomplete response from Ollama API")
+		}
+		text, usage = response.Response, response.usage()
 	}
 
-	// Parse the move from response
-	move, err := o.parseMove(response.Response)
+	// Parse the move from response; the service checks that it is legal
+	move, err := parseMoveJSON(text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse move: %v", err)
-	}
-
-	// Validate move
-	if !o.isValidMove(board, move.X, move.Y) {
-		// If invalid, find a random valid move
-		validMove := o.findValidMove(board)
-		move.X = validMove.X
-		move.Y = validMove.Y
-		move.Reasoning = "原始选择无效，选择了一个有效位置：" + move.Reasoning
+		return nil, &UnparsableMoveError{Err: err, Usage: usage}
 	}
+	move.Usage = usage
 
 	move.Player = 2 // LLM is player 2
 	move.Timestamp = time.Now()
@@ -422,18 +359,26 @@ func (o *OllamaAdapter) GetModelInfo() model.LLMModel {
 	}
 }
 
-// buildGamePrompt creates a prompt describing the current game state for Ollama
-func (o *OllamaAdapter) buildGamePrompt(board [][]int, lastMove model.Move) string {
+// Prompt pieces and the move tool shared by the adapters for providers with structured output
+const (
+	moveSystemPrompt  = "你是一个五子棋专家。请分析当前棋局并选择最佳落子位置。"
+	jsonAnswerFormat  = "请返回JSON格式：{\"x\": 横坐标(0-14), \"y\": 纵坐标(0-14), \"reasoning\": \"你的分析过程\"}"
+	toolAnswerFormat  = "请调用 " + placeStoneTool + " 工具给出落子位置和你的分析过程。"
+	placeStoneTool    = "place_stone"
+	placeStoneSummary = "在棋盘上落下一枚白子"
+)
+
+// buildMovePrompt describes the position to the model; the caller appends how to answer
+func buildMovePrompt(board [][]int, lastMove model.Move) string {
 	var prompt strings.Builder
 
 	prompt.WriteString("当前五子棋棋局状态：\n")
 	prompt.WriteString("棋盘大小：15x15\n")
 	prompt.WriteString("玩家标记：1=人类玩家(黑子), 2=AI(白子), 0=空位\n\n")
 
-	// Add board state
 	prompt.WriteString("棋盘状态：\n")
-	for y := 0; y < 15; y++ {
-		for x := 0; x < 15; x++ {
+	for y := 0; y < len(board); y++ {
+		for x := 0; x < len(board[y]); x++ {
 			if x > 0 {
 				prompt.WriteString(" ")
 			}
@@ -449,58 +394,136 @@ func (o *OllamaAdapter) buildGamePrompt(board [][]int, lastMove model.Move) stri
 	prompt.WriteString("2. 是否需要阻止对手形成五连\n")
 	prompt.WriteString("3. 是否能形成活三、活四等威胁\n")
 	prompt.WriteString("4. 整体战略布局\n\n")
-	prompt.WriteString("请返回JSON格式：{\"x\": 横坐标(0-14), \"y\": 纵坐标(0-14), \"reasoning\": \"你的分析过程\"}")
 
 	return prompt.String()
 }
 
-// parseMove parses the Ollama response to extract move information
-func (o *OllamaAdapter) parseMove(content string) (*model.LLMMove, error) {
-	// Try to find JSON in the response
+// correctionPrompt tells the model what was wrong with its earlier answers this turn
+func correctionPrompt(mistakes []string) string {
+	if len(mistakes) == 0 {
+		return ""
+	}
+	var prompt strings.Builder
+	prompt.WriteString("\n\n注意：你之前的回答无效。\n")
+	for i, mistake := range mistakes {
+		prompt.WriteString(fmt.Sprintf("%d. %s\n", i+1, mistake))
+	}
+	prompt.WriteString("请重新选择一个空位落子。")
+	return prompt.String()
+}
+
+// placeStoneSchema is the JSON schema of the move tool's arguments
+func placeStoneSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"x":         map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 14, "description": "横坐标(0-14)"},
+			"y":         map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 14, "description": "纵坐标(0-14)"},
+			"reasoning": map[string]interface{}{"type": "string", "description": "你的分析过程"},
+		},
+		"required": []string{"x", "y", "reasoning"},
+	}
+}
+
+// parseMoveJSON extracts a move from a reply that contains a JSON object with x, y and
+// reasoning, possibly surrounded by other text
+func parseMoveJSON(content string) (*model.LLMMove, error) {
 	start := strings.Index(content, "{")
 	end := strings.LastIndex(content, "}")
-
 	if start == -1 || end == -1 || start >= end {
 		return nil, errors.New("no valid JSON found in response")
 	}
 
-	jsonStr := content[start : end+1]
-
 	var moveData struct {
-		X         int    `json:"x"`
-		Y         int    `json:"y"`
+		X         *int   `json:"x"`
+		Y         *int   `json:"y"`
 		Reasoning string `json:"reasoning"`
 	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &moveData); err != nil {
+	if err := json.Unmarshal([]byte(content[start:end+1]), &moveData); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %v", err)
 	}
+	if moveData.X == nil || moveData.Y == nil {
+		return nil, errors.New("move has no x or y coordinate")
+	}
 
 	return &model.LLMMove{
-		X:          moveData.X,
-		Y:          moveData.Y,
+		X:          *moveData.X,
+		Y:          *moveData.Y,
 		Reasoning:  moveData.Reasoning,
-		Confidence: 0.8, // Default confidence for Ollama
+		Confidence: 0.8,
 	}, nil
 }
 
-// isValidMove checks if a move is valid
-func (o *OllamaAdapter) isValidMove(board [][]int, x, y int) bool {
-	if x < 0 || x >= 15 || y < 0 || y >= 15 {
-		return false
+// intParam returns an integer parameter of the configuration, or def when it is unset;
+// numbers decoded from JSON arrive as float64
+func intParam(config model.LLMConfig, key string, def int) int {
+	switch value := config.Parameters[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
 	}
-	return board[y][x] == 0
+	return def
 }
 
-// findValidMove finds a random valid move as fallback
-func (o *OllamaAdapter) findValidMove(board [][]int) model.Move {
-	for y := 0; y < 15; y++ {
-		for x := 0; x < 15; x++ {
-			if board[y][x] == 0 {
-				return model.Move{X: x, Y: y}
-			}
+// boolParam returns a boolean parameter of the configuration, or def when it is unset
+func boolParam(config model.LLMConfig, key string, def bool) bool {
+	if value, ok := config.Parameters[key].(bool); ok {
+		return value
+	}
+	return def
+}
+
+// stringParam returns a string parameter of the configuration, or def when it is unset
+func stringParam(config model.LLMConfig, key, def string) string {
+	if value, ok := config.Parameters[key].(string); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// providerError describes a failed provider response, wrapping ErrLLMRateLimited or
+// ErrLLMOverloaded when the provider asks the caller to come back later
+func providerError(resp *http.Response, message string) error {
+	if retry := retryAfter(resp.Header.Get("Retry-After")); retry != "" {
+		message += " (retry after " + retry + ")"
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w (status %d): %s", ErrLLMRateLimited, resp.StatusCode, message)
+	case http.StatusServiceUnavailable, statusOverloaded:
+		return fmt.Errorf("%w (status %d): %s", ErrLLMOverloaded, resp.StatusCode, message)
+	}
+	return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, message)
+}
+
+// retryAfter formats a Retry-After header, which gives either a number of seconds or an
+// HTTP date; a value in neither form is reported as sent
+func retryAfter(header string) string {
+	if header == "" {
+		return ""
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return strconv.Itoa(seconds) + "s"
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		wait := time.Until(date).Round(time.Second)
+		if wait < 0 {
+			wait = 0
 		}
+		return wait.String()
 	}
-	// Should never reach here in a valid game
-	return model.Move{X: 7, Y: 7}
+	return header
+}
+
+// validateEndpoint checks that a configured endpoint, if any, is an absolute http(s) URL
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+	return nil
 }
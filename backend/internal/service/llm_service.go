@@ -3,6 +3,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,15 +13,42 @@ import (
 	"gomoku-backend/internal/model"
 )
 
+// ErrGameNotFound is returned for an unknown game ID
+var ErrGameNotFound = errors.New("game not found")
+
+// ErrLLMThinking is returned for a move sent while the model is still answering the last one
+var ErrLLMThinking = errors.New("the model is still thinking about its move")
+
+// Re-prompting and the engine fallback when a model answers with no legal move
+const (
+	defaultLLMRetries     = 2 // Times a model is asked again, set with the max_retries parameter
+	maxLLMRetries         = 5
+	llmFallbackEngine     = "minimax"
+	llmFallbackTime       = 5 * time.Second
+	llmFallbackDifficulty = Medium // Engine level, set with the fallback_difficulty parameter
+)
+
 // LLMService manages LLM games and model interactions
 type LLMService struct {
 	adapters map[string]LLMAdapter
 	games    map[string]*model.LLMGame
 	configs  map[string]model.LLMConfig
 	cache    CacheInterface
+	usage    map[string]*model.LLMUsage     // Tokens billed per model since startup
+	stats    map[string]*model.LLMMoveStats // Answer quality per model since startup
+	streams  *llmStreamHub
+	thinking map[string]bool // Games whose model is being asked for a move
+	finished []func(ReviewedGame)
 	mutex    sync.RWMutex
 }
 
+// llmTurn is what asking a model for a move cost; it is recorded once the service is locked
+// again
+type llmTurn struct {
+	usage model.LLMUsage
+	stats model.LLMMoveStats
+}
+
 // NewLLMService creates a new LLM service instance
 func NewLLMService() *LLMService {
 	// Initialize cache with default configuration
@@ -36,6 +64,10 @@ func NewLLMService() *LLMService {
 		games:    make(map[string]*model.LLMGame),
 		configs:  make(map[string]model.LLMConfig),
 		cache:    cache,
+		usage:    make(map[string]*model.LLMUsage),
+		stats:    make(map[string]*model.LLMMoveStats),
+		streams:  newLLMStreamHub(),
+		thinking: make(map[string]bool),
 	}
 
 	// Register available adapters
@@ -50,8 +82,9 @@ func NewLLMService() *LLMService {
 // registerAdapters registers all available LLM adapters
 func (s *LLMService) registerAdapters() {
 	s.adapters["deepseek"] = NewDeepSeekAdapter()
-	s.adapters["chatgpt"] = NewChatGPTAdapter()
+	s.adapters["chatgpt"] = NewOpenAIAdapter()
 	s.adapters["ollama"] = NewOllamaAdapter()
+	s.adapters["claude"] = NewAnthropicAdapter()
 }
 
 // setDefaultConfigs sets default configurations for each model
@@ -67,15 +100,29 @@ func (s *LLMService) setDefaultConfigs() {
 		},
 	}
 
-	// ChatGPT default config
+	// OpenAI-compatible default config; point the endpoint at vLLM, LM Studio or a
+	// llama.cpp server to play a local model without a key
 	s.configs["chatgpt"] = model.LLMConfig{
 		ModelName: "chatgpt",
 		APIKey:    "", // To be set by user
-		Endpoint:  "https://api.openai.com/v1/chat/completions",
+		Endpoint:  defaultOpenAIBaseURL,
 		Parameters: map[string]interface{}{
-			"model":       "gpt-3.5-turbo",
+			"model":             defaultOpenAIModel,
+			"temperature":       0.7,
+			"max_tokens":        1000,
+			"structured_output": StructuredTools,
+		},
+	}
+
+	// Anthropic default config
+	s.configs["claude"] = model.LLMConfig{
+		ModelName: "claude",
+		APIKey:    "", // To be set by user
+		Endpoint:  defaultAnthropicEndpoint,
+		Parameters: map[string]interface{}{
+			"model":       defaultAnthropicModel,
 			"temperature": 0.7,
-			"max_tokens":  1000,
+			"max_tokens":  defaultAnthropicMaxTokens,
 		},
 	}
 
@@ -116,6 +163,7 @@ func (s *LLMService) StartGame(modelName string) (*model.LLMGame, error) {
 	// Create new game
 	game := model.NewLLMGame(modelName, "medium")
 	s.games[game.ID] = game
+	s.streams.open(game.ID)
 
 	return game, nil
 }
@@ -128,12 +176,15 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	// Get game
 	game, exists := s.games[gameID]
 	if !exists {
-		return nil, errors.New("game not found")
+		return nil, ErrGameNotFound
 	}
 
 	if game.Status != "playing" {
 		return nil, errors.New("game is not in playing state")
 	}
+	if s.thinking[gameID] {
+		return nil, ErrLLMThinking
+	}
 
 	// Validate human move
 	if !game.Board.IsValidMove(humanMove.X, humanMove.Y) {
@@ -155,6 +206,8 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	if game.Board.CheckWin(humanMove.X, humanMove.Y, 1) {
 		game.Status = "human_win"
 		game.UpdatedAt = time.Now()
+		s.notifyFinished(game)
+		s.endTurn(game, nil)
 		return &model.LLMResponse{
 			Move:       nil,
 			GameStatus: game.Status,
@@ -166,6 +219,8 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	if game.Board.IsBoardFull() {
 		game.Status = "draw"
 		game.UpdatedAt = time.Now()
+		s.notifyFinished(game)
+		s.endTurn(game, nil)
 		return &model.LLMResponse{
 			Move:       nil,
 			GameStatus: game.Status,
@@ -174,6 +229,7 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	}
 
 	// Get LLM move with caching
+	side := game.Board.CurrentPlayer
 	config := s.configs[game.ModelName]
 	adapter := s.adapters[game.ModelName]
 
@@ -188,22 +244,38 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 			var cachedLLMMove model.LLMMove
 			if err := json.Unmarshal(moveData, &cachedLLMMove); err == nil {
 				llmMovePtr = &cachedLLMMove
-				// Update timestamp for current game
+				// Update timestamp for current game; a cached move costs no tokens
 				llmMovePtr.Timestamp = time.Now()
+				llmMovePtr.Usage = nil
 			}
 		}
 	}
 
-	// If not in cache, get from LLM adapter
+	// If not in cache, get from LLM adapter. The service is unlocked while the model answers
+	// so other games go on; this game takes no moves until it is done.
 	if llmMovePtr == nil {
-		move, err := adapter.GetMove(game.Board.Grid, humanMove, config)
+		board := *game.Board
+		board.Grid = copyBoard(game.Board.Grid)
+		var turn llmTurn
+		s.thinking[gameID] = true
+		s.mutex.Unlock()
+		move, err := s.askMove(gameID, &board, side, adapter, humanMove, config, &turn)
+		s.mutex.Lock()
+		delete(s.thinking, gameID)
+
+		s.recordStats(game.ModelName, turn.stats)
+		s.recordUsage(game, &turn.usage)
+		if s.games[gameID] != game {
+			return nil, ErrGameNotFound
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to get LLM move: %v", err)
+			s.streams.end(game.ID, model.LLMStreamEvent{Type: StreamError, Error: err.Error()})
+			return nil, fmt.Errorf("failed to get LLM move: %w", err)
 		}
 		llmMovePtr = move
 
-		// Cache the LLM response
-		if moveData, err := json.Marshal(*llmMovePtr); err == nil {
+		// Cache the LLM response; the engine's fallback moves are not the model's
+		if moveData, err := json.Marshal(*llmMovePtr); err == nil && !llmMovePtr.Fallback {
 			// Determine cache TTL based on game progress
 			moveCount := len(game.Moves)
 			ttl := GetCacheTTL(moveCount)
@@ -211,27 +283,20 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 		}
 	}
 
-	// Validate LLM move
-	if !game.Board.IsValidMove(llmMovePtr.X, llmMovePtr.Y) {
-		// Find a valid move as fallback
-		validMove := s.findValidMove(game.Board.Grid)
-		llmMovePtr.X = validMove.X
-		llmMovePtr.Y = validMove.Y
-		llmMovePtr.Reasoning = "原始选择无效，选择了一个有效位置"
-	}
-
 	// Make LLM move
-	llmMovePtr.Player = 2
+	llmMovePtr.Player = side
 	llmMovePtr.GameID = gameID
 	llmMovePtr.Timestamp = time.Now()
 
 	game.AddMove(*llmMovePtr)
-	game.Board.MakeMove(llmMovePtr.X, llmMovePtr.Y, 2)
+	game.Board.MakeMove(llmMovePtr.X, llmMovePtr.Y, side)
 
 	// Check if LLM wins
-	if game.Board.CheckWin(llmMovePtr.X, llmMovePtr.Y, 2) {
+	if game.Board.CheckWin(llmMovePtr.X, llmMovePtr.Y, side) {
 		game.Status = "ai_win"
 		game.UpdatedAt = time.Now()
+		s.notifyFinished(game)
+		s.endTurn(game, llmMovePtr)
 		return &model.LLMResponse{
 			Move:       llmMovePtr,
 			GameStatus: game.Status,
@@ -243,6 +308,8 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	if game.Board.IsBoardFull() {
 		game.Status = "draw"
 		game.UpdatedAt = time.Now()
+		s.notifyFinished(game)
+		s.endTurn(game, llmMovePtr)
 		return &model.LLMResponse{
 			Move:       llmMovePtr,
 			GameStatus: game.Status,
@@ -251,6 +318,7 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	}
 
 	// Game continues
+	s.endTurn(game, llmMovePtr)
 	return &model.LLMResponse{
 		Move:       llmMovePtr,
 		GameStatus: game.Status,
@@ -258,6 +326,146 @@ func (s *LLMService) MakeMove(gameID string, humanMove model.Move) (*model.LLMRe
 	}, nil
 }
 
+// askMove asks the model to move for side on a copy of the game's board. An illegal or
+// unreadable answer is explained to the model, which is asked again up to the configured
+// number of retries; after that the engine moves instead. Answers are streamed unless the
+// model's stream parameter is false, so a watcher who subscribes while the model is thinking
+// still sees the rest of the answer. The requests and tokens are added to turn. The caller
+// does not hold the lock.
+func (s *LLMService) askMove(gameID string, board *model.Board, side int, adapter LLMAdapter, humanMove model.Move, config model.LLMConfig, turn *llmTurn) (*model.LLMMove, error) {
+	stats := &turn.stats
+	stats.Moves++
+
+	retries := intParam(config, "max_retries", defaultLLMRetries)
+	if retries < 0 {
+		retries = 0
+	} else if retries > maxLLMRetries {
+		retries = maxLLMRetries
+	}
+	corrector, corrects := adapter.(MoveCorrector)
+	streamer, streams := adapter.(StreamingAdapter)
+	streams = streams && boolParam(config, "stream", true)
+	onToken := func(text string) {
+		s.streams.token(gameID, text)
+	}
+
+	usage := &turn.usage
+	var mistakes []string
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		s.streams.begin(gameID, attempt)
+		var move *model.LLMMove
+		var err error
+		if streams {
+			move, err = streamer.StreamMove(board.Grid, humanMove, config, mistakes, onToken)
+		} else if attempt > 1 && corrects {
+			move, err = corrector.RetryMove(board.Grid, humanMove, config, mistakes)
+		} else {
+			move, err = adapter.GetMove(board.Grid, humanMove, config)
+		}
+		stats.Attempts++
+
+		var unparsable *UnparsableMoveError
+		switch {
+		case errors.As(err, &unparsable):
+			addUsage(usage, unparsable.Usage)
+			stats.ParseFailures++
+			mistakes = append(mistakes, "回答中没有可以解析的着法，请严格按照要求的格式给出x和y")
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		addUsage(usage, move.Usage)
+		if !board.IsValidMove(move.X, move.Y) {
+			stats.IllegalMoves++
+			mistakes = append(mistakes, illegalMoveReason(board, move.X, move.Y))
+			continue
+		}
+		billed := *usage
+		move.Usage = &billed
+		move.Attempts = attempt
+		return move, nil
+	}
+
+	stats.Fallbacks++
+	move, err := engineMove(board.Grid, humanMove, side, fallbackDifficulty(config))
+	if err != nil {
+		return nil, err
+	}
+	billed := *usage
+	move.Usage = &billed
+	move.Attempts = retries + 1
+	move.Fallback = true
+	move.Reasoning = fmt.Sprintf("模型%d次都没有给出合法的着法，由引擎代为落子", retries+1)
+	return move, nil
+}
+
+// endTurn sends the terminal stream event of a turn with the move the model played, if
+// any; the caller holds the lock
+func (s *LLMService) endTurn(game *model.LLMGame, move *model.LLMMove) {
+	event := model.LLMStreamEvent{Type: StreamMove, GameStatus: game.Status}
+	if move != nil {
+		played := *move
+		event.Move = &played
+	}
+	s.streams.end(game.ID, event)
+}
+
+// Subscribe returns the live events of a game: each request for a move, the model's answer
+// as it is written, and the move or error that ends the turn. It does not wait for a turn
+// in progress. Call the returned function when done.
+func (s *LLMService) Subscribe(gameID string) (<-chan model.LLMStreamEvent, func(), error) {
+	return s.streams.subscribe(gameID)
+}
+
+// addUsage adds the usage an adapter reported for one request; adapters that report none
+// still made the request
+func addUsage(turn *model.LLMUsage, usage *model.LLMUsage) {
+	if usage == nil {
+		turn.Requests++
+		return
+	}
+	turn.Add(*usage)
+}
+
+// illegalMoveReason tells the model why its move cannot be played
+func illegalMoveReason(board *model.Board, x, y int) string {
+	if x < 0 || x >= board.Size || y < 0 || y >= board.Size {
+		return fmt.Sprintf("(%d, %d) 在棋盘之外，坐标必须在0-%d之间", x, y, board.Size-1)
+	}
+	return fmt.Sprintf("(%d, %d) 已经有棋子了", x, y)
+}
+
+// engineMove picks the move for side when its model never answered legally, with an engine
+// search at the given difficulty
+func engineMove(board [][]int, lastMove model.Move, side int, difficulty Difficulty) (*model.LLMMove, error) {
+	engine, exists := GetEngine(llmFallbackEngine)
+	if !exists {
+		return nil, fmt.Errorf("fallback engine %s is not registered", llmFallbackEngine)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), llmFallbackTime)
+	defer cancel()
+
+	position := Position{Board: copyBoard(board), LastMove: lastMove, ToMove: side}
+	aiMove, err := engine.BestMove(ctx, position, SearchLimits{Difficulty: difficulty})
+	if err != nil {
+		return nil, fmt.Errorf("fallback engine failed: %w", err)
+	}
+	return &model.LLMMove{X: aiMove.X, Y: aiMove.Y}, nil
+}
+
+// fallbackDifficulty returns the engine level set with the fallback_difficulty parameter,
+// one of easy, medium, hard or expert
+func fallbackDifficulty(config model.LLMConfig) Difficulty {
+	name := stringParam(config, "fallback_difficulty", "")
+	for _, difficulty := range []Difficulty{Easy, Medium, Hard, Expert} {
+		if name == difficulty.String() {
+			return difficulty
+		}
+	}
+	return llmFallbackDifficulty
+}
+
 // GetGame retrieves a game by ID
 func (s *LLMService) GetGame(gameID string) (*model.LLMGame, error) {
 	s.mutex.RLock()
@@ -265,12 +473,83 @@ func (s *LLMService) GetGame(gameID string) (*model.LLMGame, error) {
 
 	game, exists := s.games[gameID]
 	if !exists {
-		return nil, errors.New("game not found")
+		return nil, ErrGameNotFound
 	}
 
 	return game, nil
 }
 
+// FinishedGame returns a finished game for review
+func (s *LLMService) FinishedGame(gameID string) (ReviewedGame, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	game, exists := s.games[gameID]
+	if !exists {
+		return ReviewedGame{}, ErrGameNotFound
+	}
+	if game.Status == "playing" {
+		return ReviewedGame{}, ErrGameNotFinished
+	}
+	return reviewedLLMGame(game), nil
+}
+
+// OnGameFinished registers a function called with every game that ends. It is called with
+// the service locked, so it must not block or call back into the service.
+func (s *LLMService) OnGameFinished(handler func(ReviewedGame)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.finished = append(s.finished, handler)
+}
+
+// notifyFinished hands a game that just ended to the registered functions; the caller holds
+// the lock
+func (s *LLMService) notifyFinished(game *model.LLMGame) {
+	reviewed := reviewedLLMGame(game)
+	for _, handler := range s.finished {
+		handler(reviewed)
+	}
+}
+
+// reviewedLLMGame converts a finished LLM game for review
+func reviewedLLMGame(game *model.LLMGame) ReviewedGame {
+	reviewed := ReviewedGame{ID: game.ID, Mode: "llm"}
+	for _, move := range game.Moves {
+		reviewed.Moves = append(reviewed.Moves, model.Move{X: move.X, Y: move.Y, Player: move.Player})
+	}
+	switch game.Status {
+	case "human_win":
+		reviewed.Winner = 1
+	case "ai_win":
+		reviewed.Winner = 2
+	}
+	return reviewed
+}
+
+// RecordHint counts a hint for the human in a game in progress and returns the position to
+// give the hint for, along with the number of hints used so far
+func (s *LLMService) RecordHint(gameID string) (Position, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	game, exists := s.games[gameID]
+	if !exists {
+		return Position{}, 0, ErrGameNotFound
+	}
+	if game.Status != "playing" {
+		return Position{}, 0, errors.New("game is not in playing state")
+	}
+
+	position := Position{Board: copyBoard(game.Board.Grid), LastMove: model.Move{X: -1, Y: -1}, ToMove: game.CurrentPlayer}
+	if last := game.GetLastMove(); last != nil {
+		position.LastMove = model.Move{X: last.X, Y: last.Y, Player: last.Player}
+	}
+	game.Hints++
+	game.UpdatedAt = time.Now()
+	return position, game.Hints, nil
+}
+
 // GetAvailableModels returns list of available LLM models
 func (s *LLMService) GetAvailableModels() []model.LLMModel {
 	s.mutex.RLock()
@@ -282,7 +561,13 @@ func (s *LLMService) GetAvailableModels() []model.LLMModel {
 
 		// Check if model is configured
 		config, exists := s.configs[name]
-		if exists && config.APIKey != "" {
+		if checker, ok := adapter.(ConfigChecker); ok {
+			if exists && checker.IsConfigured(config) {
+				modelInfo.Status = "available"
+			} else {
+				modelInfo.Status = "not_configured"
+			}
+		} else if exists && config.APIKey != "" {
 			modelInfo.Status = "available"
 		} else if !modelInfo.RequiresAPIKey {
 			modelInfo.Status = "available"
@@ -296,6 +581,56 @@ func (s *LLMService) GetAvailableModels() []model.LLMModel {
 	return models
 }
 
+// GetMoveStats returns how well each model has answered since startup
+func (s *LLMService) GetMoveStats() map[string]model.LLMMoveStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stats := make(map[string]model.LLMMoveStats, len(s.stats))
+	for name, counts := range s.stats {
+		stats[name] = *counts
+	}
+	return stats
+}
+
+// GetUsage returns the requests and tokens billed per model since startup
+func (s *LLMService) GetUsage() map[string]model.LLMUsage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	usage := make(map[string]model.LLMUsage, len(s.usage))
+	for name, total := range s.usage {
+		usage[name] = *total
+	}
+	return usage
+}
+
+// recordStats adds the answer counts of a turn to the model's totals; the caller holds the
+// lock
+func (s *LLMService) recordStats(modelName string, turn model.LLMMoveStats) {
+	stats, exists := s.stats[modelName]
+	if !exists {
+		stats = &model.LLMMoveStats{}
+		s.stats[modelName] = stats
+	}
+	stats.Add(turn)
+}
+
+// recordUsage adds the usage of a turn to the game and model totals; the caller holds the
+// lock
+func (s *LLMService) recordUsage(game *model.LLMGame, usage *model.LLMUsage) {
+	if usage == nil {
+		return
+	}
+	game.Usage.Add(*usage)
+	total, exists := s.usage[game.ModelName]
+	if !exists {
+		total = &model.LLMUsage{}
+		s.usage[game.ModelName] = total
+	}
+	total.Add(*usage)
+}
+
 // UpdateConfig updates configuration for a specific model
 func (s *LLMService) UpdateConfig(modelName string, config model.LLMConfig) error {
 	s.mutex.Lock()
@@ -341,10 +676,11 @@ func (s *LLMService) DeleteGame(gameID string) error {
 	defer s.mutex.Unlock()
 
 	if _, exists := s.games[gameID]; !exists {
-		return errors.New("game not found")
+		return ErrGameNotFound
 	}
 
 	delete(s.games, gameID)
+	s.streams.close(gameID)
 	return nil
 }
 
@@ -375,19 +711,6 @@ func (s *LLMService) isValidMove(board [][]int, x, y int) bool {
 	return board[y][x] == 0
 }
 
-// findValidMove finds a random valid move
-func (s *LLMService) findValidMove(board [][]int) model.Move {
-	for y := 0; y < 15; y++ {
-		for x := 0; x < 15; x++ {
-			if board[y][x] == 0 {
-				return model.Move{X: x, Y: y}
-			}
-		}
-	}
-	// Should never reach here in a valid game
-	return model.Move{X: 7, Y: 7}
-}
-
 // checkWin checks if a player has won
 func (s *LLMService) checkWin(board [][]int, x, y, player int) bool {
 	directions := [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
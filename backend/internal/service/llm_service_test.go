@@ -0,0 +1,378 @@
+// Unit tests for re-prompting LLMs that answer with illegal or unreadable moves, and for
+// streaming their answers
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+// scriptedLLM starts a service whose chatgpt model answers with the replies in turn, the
+// last one repeated, and records the prompts it was sent
+func scriptedLLM(t *testing.T, parameters map[string]interface{}, replies ...string) (*LLMService, *[]string, func()) {
+	t.Helper()
+	var prompts []string
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		messages := request["messages"].([]interface{})
+		prompts = append(prompts, messages[1].(map[string]interface{})["content"].(string))
+		reply := replies[len(replies)-1]
+		if len(prompts) <= len(replies) {
+			reply = replies[len(prompts)-1]
+		}
+		return http.StatusOK, `{"choices":[{"message":{"content":` + reply + `}}],
+			"usage":{"prompt_tokens":100,"completion_tokens":10,"total_tokens":110}}`
+	})
+
+	service := NewLLMService()
+	parameters["structured_output"] = StructuredNone
+	if err := service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: parameters}); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	return service, &prompts, server.Close
+}
+
+func TestLLMRetry(t *testing.T) {
+	service, prompts, stop := scriptedLLM(t, map[string]interface{}{},
+		`"{\"x\": 7, \"y\": 7, \"reasoning\": \"centre\"}"`,
+		`"I am not sure"`,
+		`"{\"x\": 8, \"y\": 8, \"reasoning\": \"diagonal\"}"`)
+	defer stop()
+
+	game, err := service.StartGame("chatgpt")
+	if err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	response, err := service.MakeMove(game.ID, model.Move{X: 7, Y: 7})
+	if err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+
+	move := response.Move
+	if move.X != 8 || move.Y != 8 || move.Fallback || move.Attempts != 3 {
+		t.Errorf("Expected the third answer (8, 8), got %+v", move)
+	}
+	if len(*prompts) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(*prompts))
+	}
+	if strings.Contains((*prompts)[0], "无效") {
+		t.Error("Expected no correction in the first prompt")
+	}
+	if !strings.Contains((*prompts)[1], "(7, 7) 已经有棋子了") {
+		t.Errorf("Expected the second prompt to explain the occupied square, got %q", (*prompts)[1])
+	}
+	if !strings.Contains((*prompts)[2], "1. (7, 7)") || !strings.Contains((*prompts)[2], "2. 回答中没有可以解析的着法") {
+		t.Errorf("Expected the third prompt to list both mistakes, got %q", (*prompts)[2])
+	}
+
+	want := model.LLMMoveStats{Moves: 1, Attempts: 3, IllegalMoves: 1, ParseFailures: 1}
+	if stats := service.GetMoveStats()["chatgpt"]; stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+	// Every attempt was billed, the failed ones included
+	if game.Usage.Requests != 3 || game.Usage.TotalTokens != 330 || move.Usage.TotalTokens != 330 {
+		t.Errorf("Expected 3 billed requests, got game %+v and move %+v", game.Usage, move.Usage)
+	}
+}
+
+func TestLLMFallback(t *testing.T) {
+	service, prompts, stop := scriptedLLM(t, map[string]interface{}{"max_retries": 1.0},
+		`"{\"x\": 15, \"y\": 3, \"reasoning\": \"edge\"}"`)
+	defer stop()
+
+	game, err := service.StartGame("chatgpt")
+	if err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	response, err := service.MakeMove(game.ID, model.Move{X: 7, Y: 7})
+	if err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+
+	move := response.Move
+	if !move.Fallback || move.Attempts != 2 || len(*prompts) != 2 {
+		t.Fatalf("Expected the engine to move after 2 attempts, got %+v after %d requests", move, len(*prompts))
+	}
+	if !strings.Contains((*prompts)[1], "(15, 3) 在棋盘之外") {
+		t.Errorf("Expected the second prompt to explain the off-board square, got %q", (*prompts)[1])
+	}
+	// The engine answers next to the centre, not in the first empty corner
+	if dx, dy := move.X-7, move.Y-7; dx < -2 || dx > 2 || dy < -2 || dy > 2 {
+		t.Errorf("Expected an engine move near the centre, got (%d, %d)", move.X, move.Y)
+	}
+	if game.Board.Grid[move.Y][move.X] != 2 {
+		t.Errorf("Expected the fallback move on the board")
+	}
+
+	want := model.LLMMoveStats{Moves: 1, Attempts: 2, IllegalMoves: 2, Fallbacks: 1}
+	if stats := service.GetMoveStats()["chatgpt"]; stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+}
+
+func TestLLMMoveUnlocked(t *testing.T) {
+	asked, release := make(chan struct{}), make(chan struct{})
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		close(asked)
+		<-release
+		return http.StatusOK, `{"choices":[{"message":{"content":"{\"x\": 8, \"y\": 8, \"reasoning\": \"diagonal\"}"}}]}`
+	})
+	defer server.Close()
+	service := NewLLMService()
+	service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: map[string]interface{}{"structured_output": StructuredNone}})
+	game, _ := service.StartGame("chatgpt")
+
+	done := make(chan error)
+	go func() {
+		_, err := service.MakeMove(game.ID, model.Move{X: 7, Y: 7})
+		done <- err
+	}()
+	<-asked
+
+	// Other games go on while the model answers; this one waits for it
+	if _, err := service.StartGame("chatgpt"); err != nil {
+		t.Errorf("Expected a new game while the model answers, got %v", err)
+	}
+	if _, err := service.MakeMove(game.ID, model.Move{X: 0, Y: 0}); err != ErrLLMThinking {
+		t.Errorf("Expected ErrLLMThinking, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	if game.Board.Grid[8][8] != 2 || game.Board.Grid[0][0] != 0 {
+		t.Error("Expected only the model's move after the human's")
+	}
+}
+
+func TestLLMFallbackSide(t *testing.T) {
+	// Black has four in a row; the engine completes it when playing black
+	board := createEmptyBoard()
+	for x := 3; x < 7; x++ {
+		board[7][x] = 1
+	}
+	board[8][3], board[8][4], board[8][5] = 2, 2, 2
+	move, err := engineMove(board, model.Move{X: 5, Y: 8, Player: 2}, 1, Easy)
+	if err != nil {
+		t.Fatalf("engineMove failed: %v", err)
+	}
+	if move.Y != 7 || (move.X != 2 && move.X != 7) {
+		t.Errorf("Expected black to make five on row 7, got (%d, %d)", move.X, move.Y)
+	}
+
+	if difficulty := fallbackDifficulty(model.LLMConfig{}); difficulty != Medium {
+		t.Errorf("Expected the fallback at medium by default, got %s", difficulty)
+	}
+	config := model.LLMConfig{Parameters: map[string]interface{}{"fallback_difficulty": "hard"}}
+	if difficulty := fallbackDifficulty(config); difficulty != Hard {
+		t.Errorf("Expected the configured fallback level, got %s", difficulty)
+	}
+}
+
+func TestLLMStream(t *testing.T) {
+	service, _, stop := scriptedLLM(t, map[string]interface{}{},
+		`"{\"x\": 7, \"y\": 7, \"reasoning\": \"taken\"}"`,
+		`"{\"x\": 8, \"y\": 8, \"reasoning\": \"对角线\"}"`)
+	defer stop()
+
+	game, err := service.StartGame("chatgpt")
+	if err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	events, cancel, err := service.Subscribe(game.ID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer cancel()
+	if _, _, err := service.Subscribe("missing"); err != ErrGameNotFound {
+		t.Errorf("Expected ErrGameNotFound for an unknown game, got %v", err)
+	}
+
+	if _, err := service.MakeMove(game.ID, model.Move{X: 7, Y: 7}); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+
+	// Two requests, each announced and streamed, then the move
+	var starts []int
+	var text strings.Builder
+	var last model.LLMStreamEvent
+	for event := range events {
+		switch event.Type {
+		case StreamStart:
+			starts = append(starts, event.Attempt)
+		case StreamToken:
+			text.WriteString(event.Text)
+		}
+		last = event
+		if event.Type == StreamMove || event.Type == StreamError {
+			break
+		}
+	}
+	if len(starts) != 2 || starts[0] != 1 || starts[1] != 2 {
+		t.Errorf("Expected requests 1 and 2 announced, got %v", starts)
+	}
+	want := `{"x": 7, "y": 7, "reasoning": "taken"}{"x": 8, "y": 8, "reasoning": "对角线"}`
+	if text.String() != want {
+		t.Errorf("Expected the streamed text %q, got %q", want, text.String())
+	}
+	if last.Type != StreamMove || last.Move == nil || last.Move.X != 8 || last.GameStatus != "playing" {
+		t.Errorf("Expected the move (8, 8) as the last event, got %+v", last)
+	}
+
+	// Deleting the game ends the stream
+	if err := service.DeleteGame(game.ID); err != nil {
+		t.Fatalf("DeleteGame failed: %v", err)
+	}
+	if _, open := <-events; open {
+		t.Error("Expected the stream to close with the game")
+	}
+}
+
+func TestLLMStreamParameter(t *testing.T) {
+	var requests []map[string]interface{}
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		requests = append(requests, request)
+		return http.StatusOK, `{"choices":[{"message":{"content":"{\"x\": 8, \"y\": 8, \"reasoning\": \"diagonal\"}"}}]}`
+	})
+	defer server.Close()
+	parameters := map[string]interface{}{"structured_output": StructuredNone}
+	service := NewLLMService()
+	service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: parameters})
+
+	// Nobody watches the first game, which still streams; the second is watched by a model
+	// that must not stream
+	game, _ := service.StartGame("chatgpt")
+	service.MakeMove(game.ID, model.Move{X: 7, Y: 7})
+	parameters["stream"] = false
+	service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: parameters})
+	game, _ = service.StartGame("chatgpt")
+	_, cancel, _ := service.Subscribe(game.ID)
+	defer cancel()
+	service.MakeMove(game.ID, model.Move{X: 6, Y: 6}) // Not the cached position
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if requests[0]["stream"] != true || requests[0]["stream_options"] == nil {
+		t.Errorf("Request 1: expected a stream, got %v", requests[0])
+	}
+	if _, exists := requests[1]["stream"]; exists {
+		t.Errorf("Request 2: expected no stream, got %v", requests[1]["stream"])
+	}
+	if _, exists := requests[1]["stream_options"]; exists {
+		t.Error("Request 2: expected no stream_options")
+	}
+}
+
+func TestLLMStreamLateSubscriber(t *testing.T) {
+	// The page opens its event stream and asks for the move at the same time, so the model
+	// may already be thinking when the watcher arrives
+	asked, answer := make(chan bool), make(chan bool)
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		asked <- true
+		<-answer
+		return http.StatusOK, `{"choices":[{"message":{"content":"{\"x\": 8, \"y\": 8, \"reasoning\": \"diagonal\"}"}}]}`
+	})
+	defer server.Close()
+	service := NewLLMService()
+	service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: map[string]interface{}{"structured_output": StructuredNone}})
+
+	game, _ := service.StartGame("chatgpt")
+	done := make(chan error)
+	go func() {
+		_, err := service.MakeMove(game.ID, model.Move{X: 7, Y: 7})
+		done <- err
+	}()
+	<-asked
+	events, cancel, err := service.Subscribe(game.ID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer cancel()
+	close(answer)
+
+	var text strings.Builder
+	var last model.LLMStreamEvent
+	for event := range events {
+		if event.Type == StreamToken {
+			text.WriteString(event.Text)
+		}
+		last = event
+		if event.Type == StreamMove || event.Type == StreamError {
+			break
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	want := `{"x": 8, "y": 8, "reasoning": "diagonal"}`
+	if text.String() != want {
+		t.Errorf("Expected the streamed text %q, got %q", want, text.String())
+	}
+	if last.Type != StreamMove || last.Move == nil || last.Move.X != 8 {
+		t.Errorf("Expected the move (8, 8) as the last event, got %+v", last)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request["model"] != "qwen2.5" || request["stream"] != true {
+			t.Errorf("Expected a streamed request for the configured model, got %v", request)
+		}
+		for _, piece := range []string{`{\"x\": 6,`, ` \"y\": 9, `, `\"reasoning\": \"ok\"}`} {
+			fmt.Fprintf(w, "{\"response\":\"%s\",\"done\":false}\n", piece)
+		}
+		fmt.Fprint(w, `{"response":"","done":true,"prompt_eval_count":300,"eval_count":25}`+"\n")
+	}))
+	defer server.Close()
+
+	var tokens []string
+	config := model.LLMConfig{ModelName: "ollama", Endpoint: server.URL, Parameters: map[string]interface{}{"model": "qwen2.5"}}
+	move, err := NewOllamaAdapter().StreamMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config, nil, func(text string) {
+		tokens = append(tokens, text)
+	})
+	if err != nil {
+		t.Fatalf("StreamMove failed: %v", err)
+	}
+	if move.X != 6 || move.Y != 9 || len(tokens) != 3 {
+		t.Errorf("Expected (6, 9) in 3 tokens, got %+v from %q", move, tokens)
+	}
+	if move.Usage == nil || move.Usage.TotalTokens != 325 {
+		t.Errorf("Expected the final chunk's token counts, got %+v", move.Usage)
+	}
+
+	// A stream cut short is an error, not a move
+	_, _, err = readOllamaStream(strings.NewReader(`{"response":"{\"x\": 1","done":false}`), func(string) {})
+	if err == nil {
+		t.Error("Expected an error for a stream without its final chunk")
+	}
+}
+
+func TestChatStreamTruncated(t *testing.T) {
+	// The connection drops after a complete move but before [DONE]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{`{\"x\": 6,`, ` \"y\": 9, `, `\"reasoning\": \"ok\"}`} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"%s\"}}]}\n\n", piece)
+		}
+	}))
+	defer server.Close()
+
+	config := model.LLMConfig{Endpoint: server.URL + "/v1", Parameters: map[string]interface{}{"structured_output": StructuredNone}}
+	move, err := NewOpenAIAdapter().StreamMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config, nil, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "[DONE]") {
+		t.Errorf("Expected an incomplete stream error, got %+v, %v", move, err)
+	}
+
+	reply, err := readChatStream(strings.NewReader("data: {\"choices\":[{\"delta\":{\"content\":\"{}\"}}]}\n\ndata: [DONE]\n\n"), func(string) {})
+	if err != nil || reply.Content != "{}" {
+		t.Errorf("Expected the finished stream read, got %+v, %v", reply, err)
+	}
+}
@@ -0,0 +1,314 @@
+// Package service contains live streaming of LLM answers
+// This file reads streamed responses (OpenAI-style server-sent events and Ollama's
+// newline-delimited JSON) and fans each game's tokens out to the browsers watching it.
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gomoku-backend/internal/model"
+)
+
+// Types of LLMStreamEvent
+const (
+	StreamStart = "start" // The model was asked for a move, again on a retry
+	StreamToken = "token" // A piece of the model's answer
+	StreamMove  = "move"  // The move was played; the last event of a turn
+	StreamError = "error" // The turn failed; the last event of a turn
+)
+
+const (
+	llmStreamBuffer = 256     // Events a subscriber may fall behind before it is dropped
+	maxStreamLine   = 1 << 20 // Longest server-sent event line accepted
+)
+
+// chatReply is what a chat-completions response carried
+type chatReply struct {
+	Content   string          // Text of the message
+	Arguments string          // Arguments of the place_stone tool call, if any
+	Usage     *model.LLMUsage // Tokens billed, if the server reported them
+}
+
+// openAIUsage is the usage object of a chat-completions response or final stream chunk
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// usage converts the counts for one request
+func (u *openAIUsage) usage() *model.LLMUsage {
+	if u == nil {
+		return nil
+	}
+	return &model.LLMUsage{
+		Requests:         1,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// decodeChatResponse reads a complete chat-completions response
+func decodeChatResponse(body io.Reader) (chatReply, error) {
+	var response openAIResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return chatReply{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return chatReply{}, errors.New("no choices in response")
+	}
+
+	message := response.Choices[0].Message
+	reply := chatReply{Content: message.Content, Usage: response.Usage.usage()}
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == placeStoneTool {
+			reply.Arguments = call.Function.Arguments
+			break
+		}
+	}
+	return reply, nil
+}
+
+// readChatStream reads a streamed chat-completions response, passing each piece of text or
+// tool arguments to onToken as it arrives. A stream cut off before [DONE] is an error.
+func readChatStream(body io.Reader, onToken func(string)) (chatReply, error) {
+	var reply chatReply
+	var content, arguments strings.Builder
+	done := false
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue // Blank separators, comments and event names
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Function struct {
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return reply, fmt.Errorf("failed to decode stream chunk: %v", err)
+		}
+		if chunk.Error != nil {
+			return reply, fmt.Errorf("stream failed: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			reply.Usage = chunk.Usage.usage()
+		}
+		for _, choice := range chunk.Choices {
+			if text := choice.Delta.Content; text != "" {
+				content.WriteString(text)
+				onToken(text)
+			}
+			// Only the place_stone tool is offered, so every call is to it
+			for _, call := range choice.Delta.ToolCalls {
+				if text := call.Function.Arguments; text != "" {
+					arguments.WriteString(text)
+					onToken(text)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return reply, fmt.Errorf("failed to read stream: %v", err)
+	}
+	if !done {
+		return reply, errors.New("incomplete response: stream ended before [DONE]")
+	}
+
+	reply.Content = content.String()
+	reply.Arguments = arguments.String()
+	return reply, nil
+}
+
+// ollamaChunk is an Ollama generate response, or one line of it when streamed
+type ollamaChunk struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// usage converts the counts of the final chunk
+func (c ollamaChunk) usage() *model.LLMUsage {
+	return &model.LLMUsage{
+		Requests:         1,
+		PromptTokens:     c.PromptEvalCount,
+		CompletionTokens: c.EvalCount,
+		TotalTokens:      c.PromptEvalCount + c.EvalCount,
+	}
+}
+
+// readOllamaStream reads a streamed Ollama generate response, passing each piece of text to
+// onToken as it arrives, and returns the whole text and the usage of the final chunk
+func readOllamaStream(body io.Reader, onToken func(string)) (string, *model.LLMUsage, error) {
+	var text strings.Builder
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk ollamaChunk
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			return "", nil, errors.New("incomplete response from Ollama API")
+		} else if err != nil {
+			return "", nil, fmt.Errorf("failed to decode stream chunk: %v", err)
+		}
+		if chunk.Error != "" {
+			return "", nil, fmt.Errorf("stream failed: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			onToken(chunk.Response)
+		}
+		if chunk.Done {
+			return text.String(), chunk.usage(), nil
+		}
+	}
+}
+
+// llmStream is the live output of one game
+type llmStream struct {
+	thinking    bool            // A model turn is in progress
+	attempt     int             // Request number of the turn in progress
+	text        strings.Builder // Text of the request in progress, replayed to late subscribers
+	subscribers map[chan model.LLMStreamEvent]bool
+}
+
+// llmStreamHub fans each game's events out to its subscribers. It has its own lock because
+// models answer while LLMService is unlocked.
+type llmStreamHub struct {
+	streams map[string]*llmStream
+	mutex   sync.Mutex
+}
+
+// newLLMStreamHub creates an empty hub
+func newLLMStreamHub() *llmStreamHub {
+	return &llmStreamHub{streams: make(map[string]*llmStream)}
+}
+
+// open starts accepting subscribers for a new game
+func (h *llmStreamHub) open(gameID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.streams[gameID] = &llmStream{subscribers: make(map[chan model.LLMStreamEvent]bool)}
+}
+
+// close ends the streams of a deleted game
+func (h *llmStreamHub) close(gameID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if stream, exists := h.streams[gameID]; exists {
+		for ch := range stream.subscribers {
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+		delete(h.streams, gameID)
+	}
+}
+
+// subscribe returns the game's events from now on, with the turn in progress replayed, and
+// a function to call when done with them
+func (h *llmStreamHub) subscribe(gameID string) (<-chan model.LLMStreamEvent, func(), error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	stream, exists := h.streams[gameID]
+	if !exists {
+		return nil, nil, ErrGameNotFound
+	}
+	ch := make(chan model.LLMStreamEvent, llmStreamBuffer)
+	if stream.thinking {
+		ch <- model.LLMStreamEvent{Type: StreamStart, Attempt: stream.attempt}
+		if stream.text.Len() > 0 {
+			ch <- model.LLMStreamEvent{Type: StreamToken, Text: stream.text.String()}
+		}
+	}
+	stream.subscribers[ch] = true
+
+	cancel := func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+
+		if stream.subscribers[ch] {
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel, nil
+}
+
+// begin announces a request for a move
+func (h *llmStreamHub) begin(gameID string, attempt int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if stream, exists := h.streams[gameID]; exists {
+		stream.thinking = true
+		stream.attempt = attempt
+		stream.text.Reset()
+		h.publish(stream, model.LLMStreamEvent{Type: StreamStart, Attempt: attempt})
+	}
+}
+
+// token forwards a piece of the model's answer
+func (h *llmStreamHub) token(gameID, text string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if stream, exists := h.streams[gameID]; exists {
+		stream.text.WriteString(text)
+		h.publish(stream, model.LLMStreamEvent{Type: StreamToken, Text: text})
+	}
+}
+
+// end sends the terminal event of a turn
+func (h *llmStreamHub) end(gameID string, event model.LLMStreamEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if stream, exists := h.streams[gameID]; exists {
+		stream.thinking = false
+		stream.text.Reset()
+		h.publish(stream, event)
+	}
+}
+
+// publish sends an event to every subscriber, dropping those too far behind; the caller
+// holds the lock
+func (h *llmStreamHub) publish(stream *llmStream, event model.LLMStreamEvent) {
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+// Package service contains the Monte Carlo Tree Search engine
+// This file implements UCT tree search with pattern-biased playouts that stop early
+// and fall back to the pattern evaluation after a fixed number of plies
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+const (
+	// mctsExploration is the UCT exploration constant for rewards in [0, 1]
+	mctsExploration = 1.0
+
+	// mctsMaxChildren caps the children of a node to the best-ordered candidates
+	mctsMaxChildren = 12
+
+	// mctsPlayoutDepth is how many plies a playout runs before the evaluation decides it
+	mctsPlayoutDepth = 12
+
+	// mctsEvalScale converts pattern scores to win probabilities
+	mctsEvalScale = 600.0
+)
+
+// MCTSService handles AI move generation with Monte Carlo Tree Search
+type MCTSService struct {
+	boardSize  int
+	iterations map[Difficulty]int
+	timeLimit  time.Duration
+	weights    *evalTable
+
+	statsMutex     sync.Mutex
+	searchTime     time.Duration
+	lastIterations int
+	treeNodes      int
+	winRate        float64
+}
+
+// mctsNode is one node of the search tree; wins are counted for the player who made the move
+type mctsNode struct {
+	move     model.Move
+	player   int
+	parent   *mctsNode
+	children []*mctsNode
+	untried  []model.Move
+	visits   int
+	wins     float64
+	terminal bool
+	won      bool // The move completed five
+}
+
+// mctsSearch holds the state of one move search
+type mctsSearch struct {
+	board   [][]int
+	pb      *patternBoard
+	rand    *rand.Rand
+	visited []bool
+	nodes   int
+}
+
+// NewMCTSService creates a new MCTS engine instance
+func NewMCTSService() *MCTSService {
+	return &MCTSService{
+		boardSize: 15,
+		iterations: map[Difficulty]int{
+			Easy:   300,
+			Medium: 2000,
+			Hard:   8000,
+			Expert: 30000,
+		},
+		timeLimit: 5 * time.Second,
+		weights:   defaultEvalTable,
+	}
+}
+
+// SetEvalWeights sets the weights of the playout evaluation used when a search does not
+// select its own. It must not be called while a search is running.
+func (m *MCTSService) SetEvalWeights(weights EvalWeights) error {
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+	m.weights = weights.table()
+	return nil
+}
+
+// GetMoveForPlayer generates a move for the side to move with the difficulty's iteration budget
+func (m *MCTSService) GetMoveForPlayer(board [][]int, lastMove model.Move, player int, difficulty Difficulty) model.AIMove {
+	return m.GetMoveWithBudget(board, lastMove, player, m.iterations[difficulty], m.timeLimit)
+}
+
+// GetMoveWithBudget runs MCTS until the iteration budget or the time limit is used up.
+// A non-positive budget means no limit of that kind; at least one limit always applies.
+func (m *MCTSService) GetMoveWithBudget(board [][]int, lastMove model.Move, player, iterations int, timeLimit time.Duration) model.AIMove {
+	return m.search(context.Background(), board, lastMove, player, iterations, timeLimit, m.weights)
+}
+
+// Name returns the registry name
+func (m *MCTSService) Name() string {
+	return "mcts"
+}
+
+// Capabilities describes the MCTS engine; the node limit counts playouts
+func (m *MCTSService) Capabilities() EngineCapabilities {
+	return EngineCapabilities{
+		Description: "Monte Carlo Tree Search with UCT and pattern-biased playouts",
+		Difficulty:  true,
+		Nodes:       true,
+		Time:        true,
+		PV:          true,
+		Weights:     true,
+	}
+}
+
+// BestMove searches the position within the limits
+func (m *MCTSService) BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error) {
+	if err := position.Validate(); err != nil {
+		return model.AIMove{}, err
+	}
+	if err := limits.Validate(m.Capabilities()); err != nil {
+		return model.AIMove{}, err
+	}
+
+	iterations := m.iterations[limits.Difficulty]
+	if limits.Nodes > 0 {
+		iterations = int(limits.Nodes)
+	} else if limits.Time > 0 {
+		iterations = 0 // Run until the time is up
+	}
+	weights := m.weights
+	if limits.Weights != nil {
+		weights = limits.Weights.table()
+	}
+	return m.search(ctx, position.Board, position.LastMove, position.ToMove, iterations, limits.Time, weights), nil
+}
+
+// search runs MCTS until the budget is used up or the context is cancelled
+func (m *MCTSService) search(ctx context.Context, board [][]int, lastMove model.Move, player, iterations int, timeLimit time.Duration, weights *evalTable) model.AIMove {
+	start := time.Now()
+	if timeLimit <= 0 {
+		timeLimit = m.timeLimit
+	}
+
+	grid := make([][]int, len(board))
+	for i := range board {
+		grid[i] = make([]int, len(board[i]))
+		copy(grid[i], board[i])
+	}
+	s := &mctsSearch{
+		board:   grid,
+		pb:      newPatternBoard(grid),
+		rand:    rand.New(rand.NewSource(start.UnixNano())),
+		visited: make([]bool, m.boardSize*m.boardSize),
+	}
+	s.pb.weights = weights
+
+	root := s.newNode(model.Move{X: lastMove.X, Y: lastMove.Y}, 3-player, nil)
+	if len(root.untried) == 0 {
+		return model.AIMove{X: 7, Y: 7, Score: -1, Stats: m.recordStats(start, 0, s.nodes, 0)}
+	}
+
+	// A single forced move (win or block) needs no search
+	done := 0
+	if len(root.untried) > 1 {
+		for iterations <= 0 || done < iterations {
+			if done%64 == 0 && (time.Since(start) > timeLimit || ctx.Err() != nil) {
+				break
+			}
+			s.iterate(root)
+			done++
+		}
+	}
+
+	best := root.bestChild()
+	if best == nil {
+		move := root.untried[0]
+		stats := m.recordStats(start, done, s.nodes, 0.5)
+		return model.AIMove{X: move.X, Y: move.Y, PV: []model.Move{{X: move.X, Y: move.Y, Player: player}}, Stats: stats}
+	}
+
+	winRate := best.wins / float64(best.visits)
+	if best.won {
+		winRate = 1
+	}
+	stats := m.recordStats(start, done, s.nodes, winRate)
+
+	// The principal variation follows the most visited children
+	var pv []model.Move
+	for node := best; node != nil; node = node.bestChild() {
+		pv = append(pv, model.Move{X: node.move.X, Y: node.move.Y, Player: node.player})
+	}
+
+	return model.AIMove{
+		X:     best.move.X,
+		Y:     best.move.Y,
+		Score: winRateToScore(winRate),
+		PV:    pv,
+		Stats: stats,
+	}
+}
+
+// GetStats returns statistics of the last search
+func (m *MCTSService) GetStats() map[string]interface{} {
+	m.statsMutex.Lock()
+	defer m.statsMutex.Unlock()
+
+	return mctsStats(m.lastIterations, m.treeNodes, m.winRate, m.searchTime)
+}
+
+// recordStats publishes the counters of a finished search as the last-search statistics
+// and returns them for the caller
+func (m *MCTSService) recordStats(start time.Time, iterations, nodes int, winRate float64) map[string]interface{} {
+	elapsed := time.Since(start)
+
+	m.statsMutex.Lock()
+	m.searchTime = elapsed
+	m.lastIterations = iterations
+	m.treeNodes = nodes
+	m.winRate = winRate
+	m.statsMutex.Unlock()
+
+	return mctsStats(iterations, nodes, winRate, elapsed)
+}
+
+// mctsStats formats the counters of a search
+func mctsStats(iterations, nodes int, winRate float64, elapsed time.Duration) map[string]interface{} {
+	iterationsPerSecond := 0.0
+	if elapsed > 0 {
+		iterationsPerSecond = float64(iterations) / elapsed.Seconds()
+	}
+
+	return map[string]interface{}{
+		"iterations":            iterations,
+		"tree_nodes":            nodes,
+		"win_rate":              winRate,
+		"search_time":           elapsed.String(),
+		"iterations_per_second": iterationsPerSecond,
+	}
+}
+
+// winRateToScore maps a win probability back onto the pattern evaluation scale
+func winRateToScore(p float64) int {
+	switch {
+	case p >= 1:
+		return winScore
+	case p <= 0:
+		return -winScore
+	}
+	return int(math.Round(mctsEvalScale * math.Log(p/(1-p))))
+}
+
+// newNode creates a node for the position after move; its children are moves of the other player
+func (s *mctsSearch) newNode(move model.Move, player int, parent *mctsNode) *mctsNode {
+	s.nodes++
+	node := &mctsNode{move: move, player: player, parent: parent}
+	if parent != nil && s.pb.isFive(move.X, move.Y) {
+		node.terminal = true
+		node.won = true
+		return node
+	}
+	if s.pb.isFull() {
+		node.terminal = true
+		return node
+	}
+
+	node.untried = s.candidates(3-player, mctsMaxChildren)
+	return node
+}
+
+// iterate runs one selection, expansion, playout and backpropagation pass
+func (s *mctsSearch) iterate(root *mctsNode) {
+	node := root
+	var path []*mctsNode
+
+	// Selection: descend through fully expanded nodes
+	for !node.terminal && len(node.untried) == 0 && len(node.children) > 0 {
+		node = node.selectChild()
+		s.play(node.move, node.player)
+		path = append(path, node)
+	}
+
+	// Expansion: add the best remaining candidate
+	if !node.terminal && len(node.untried) > 0 {
+		move := node.untried[0]
+		node.untried = node.untried[1:]
+		s.play(move, 3-node.player)
+		child := s.newNode(move, 3-node.player, node)
+		node.children = append(node.children, child)
+		node = child
+		path = append(path, node)
+	}
+
+	// Simulation: result for the player who made the move into node
+	var result float64
+	switch {
+	case node.won:
+		result = 1
+	case node.terminal:
+		result = 0.5
+	default:
+		result = s.playout(3 - node.player)
+		result = 1 - result
+	}
+
+	// Undo the tree moves
+	for i := len(path) - 1; i >= 0; i-- {
+		s.undo(path[i].move)
+	}
+
+	// Backpropagation: alternate the result between the two players
+	for n := node; n != nil; n = n.parent {
+		n.visits++
+		n.wins += result
+		result = 1 - result
+	}
+}
+
+// selectChild picks the child with the highest UCT value
+func (n *mctsNode) selectChild() *mctsNode {
+	logVisits := math.Log(float64(n.visits))
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	for _, child := range n.children {
+		value := child.wins/float64(child.visits) +
+			mctsExploration*math.Sqrt(logVisits/float64(child.visits))
+		if child.won {
+			value = math.Inf(1) // A winning move is always taken
+		}
+		if value > bestValue {
+			bestValue = value
+			best = child
+		}
+	}
+	return best
+}
+
+// bestChild returns the most visited child, or nil for a leaf
+func (n *mctsNode) bestChild() *mctsNode {
+	var best *mctsNode
+	for _, child := range n.children {
+		if child.won {
+			return child
+		}
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+	return best
+}
+
+// play puts a stone on the search board
+func (s *mctsSearch) play(move model.Move, player int) {
+	s.board[move.Y][move.X] = player
+	s.pb.place(move.X, move.Y, player)
+}
+
+// undo takes a stone off the search board
+func (s *mctsSearch) undo(move model.Move) {
+	s.board[move.Y][move.X] = 0
+	s.pb.remove(move.X, move.Y)
+}
+
+// playout plays pattern-biased random moves from the current position and returns the
+// result for player, the side to move. After mctsPlayoutDepth plies the pattern
+// evaluation decides the result as a win probability.
+func (s *mctsSearch) playout(player int) float64 {
+	var played []model.Move
+	defer func() {
+		for i := len(played) - 1; i >= 0; i-- {
+			s.undo(played[i])
+		}
+	}()
+
+	toMove := player
+	for ply := 0; ply < mctsPlayoutDepth; ply++ {
+		move, ok := s.playoutMove(toMove)
+		if !ok {
+			return 0.5
+		}
+		s.play(move, toMove)
+		played = append(played, move)
+
+		if s.pb.isFive(move.X, move.Y) {
+			if toMove == player {
+				return 1
+			}
+			return 0
+		}
+		toMove = 3 - toMove
+	}
+
+	p := 1 / (1 + math.Exp(-float64(s.pb.evaluate(toMove))/mctsEvalScale))
+	if toMove != player {
+		p = 1 - p
+	}
+	return p
+}
+
+// playoutMove samples a move with probability proportional to its pattern value,
+// always taking a win and always blocking an opponent five
+func (s *mctsSearch) playoutMove(player int) (model.Move, bool) {
+	cells := s.neighbourCells()
+	if len(cells) == 0 {
+		return model.Move{}, false
+	}
+
+	weights := make([]int, len(cells))
+	total := 0
+	block := -1
+	for i, move := range cells {
+		mt := analyzeMove(s.pb, move.X, move.Y, player)
+		if mt.own == ShapeFive {
+			return move, true
+		}
+		if mt.opp == ShapeFive {
+			block = i
+		}
+		weights[i] = mt.attack + mt.defence/2 + 1
+		total += weights[i]
+	}
+	if block >= 0 {
+		return cells[block], true
+	}
+
+	roll := s.rand.Intn(total)
+	for i, weight := range weights {
+		if roll < weight {
+			return cells[i], true
+		}
+		roll -= weight
+	}
+	return cells[len(cells)-1], true
+}
+
+// neighbourCells lists empty cells within two cells of a stone, or the centre on an empty board
+func (s *mctsSearch) neighbourCells() []model.Move {
+	pb := s.pb
+	size := pb.size
+	if pb.stones == 0 {
+		return []model.Move{{X: size / 2, Y: size / 2}}
+	}
+
+	for i := range s.visited {
+		s.visited[i] = false
+	}
+
+	var cells []model.Move
+	for idx, owner := range pb.cells {
+		if owner == 0 {
+			continue
+		}
+		x, y := idx%size, idx/size
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				nx, ny := x+dx, y+dy
+				if !pb.inside(nx, ny) {
+					continue
+				}
+				n := ny*size + nx
+				if pb.cells[n] == 0 && !s.visited[n] {
+					s.visited[n] = true
+					cells = append(cells, model.Move{X: nx, Y: ny})
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// candidates returns up to limit tree moves for the player, best first.
+// Forcing positions are narrowed the same way as in the minimax move ordering.
+func (s *mctsSearch) candidates(player, limit int) []model.Move {
+	cells := s.neighbourCells()
+	scored := make([]scoredMove, 0, len(cells))
+	threats := make([]moveThreats, len(cells))
+	var hasWin, mustBlock, oppOpenThree bool
+
+	for i, move := range cells {
+		mt := analyzeMove(s.pb, move.X, move.Y, player)
+		threats[i] = mt
+		switch {
+		case mt.own == ShapeFive:
+			hasWin = true
+		case mt.opp == ShapeFive:
+			mustBlock = true
+		case mt.opp == ShapeOpenFour:
+			oppOpenThree = true
+		}
+	}
+
+	for i, move := range cells {
+		mt := threats[i]
+		switch {
+		case hasWin:
+			if mt.own != ShapeFive {
+				continue
+			}
+		case mustBlock:
+			if mt.opp != ShapeFive {
+				continue
+			}
+		case oppOpenThree:
+			if !mt.opp.IsFour() && !mt.own.IsFour() {
+				continue
+			}
+		}
+		scored = append(scored, scoredMove{move: move, score: mt.attack + mt.defence/2})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	moves := make([]model.Move, len(scored))
+	for i, sm := range scored {
+		moves[i] = sm.move
+	}
+	return moves
+}
+
+func init() {
+	RegisterEngine(NewMCTSService())
+}
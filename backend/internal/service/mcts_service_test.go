@@ -0,0 +1,73 @@
+// Unit tests for the MCTS engine
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestMCTS_WinDetection(t *testing.T) {
+	m := NewMCTSService()
+	board := createEmptyBoard()
+	for x := 5; x <= 8; x++ {
+		board[7][x] = 2
+	}
+	board[6][6] = 1
+	board[8][8] = 1
+	board[6][8] = 1
+
+	move := m.GetMoveForPlayer(board, model.Move{X: 6, Y: 8}, 2, Medium)
+	if move.Y != 7 || (move.X != 4 && move.X != 9) {
+		t.Errorf("Expected winning move at (4,7) or (9,7), got (%d,%d)", move.X, move.Y)
+	}
+}
+
+func TestMCTS_BlockOpenThree(t *testing.T) {
+	m := NewMCTSService()
+	board := createEmptyBoard()
+	board[7][6] = 1
+	board[7][7] = 1
+	board[7][8] = 1
+	board[8][7] = 2
+	board[6][6] = 2
+
+	move := m.GetMoveForPlayer(board, model.Move{X: 8, Y: 7}, 2, Medium)
+	blocks := map[[2]int]bool{{5, 7}: true, {9, 7}: true, {4, 7}: true, {10, 7}: true}
+	if !blocks[[2]int{move.X, move.Y}] {
+		t.Errorf("Expected a block of the open three, got (%d,%d)", move.X, move.Y)
+	}
+}
+
+func TestMCTS_Budget(t *testing.T) {
+	m := NewMCTSService()
+	board := createCenterBoard()
+
+	move := m.GetMoveWithBudget(board, model.Move{X: 7, Y: 7}, 2, 500, time.Minute)
+	stats := m.GetStats()
+	if stats["iterations"].(int) != 500 {
+		t.Errorf("Expected 500 iterations, got %v", stats["iterations"])
+	}
+	if abs(move.X-7) > 2 || abs(move.Y-7) > 2 {
+		t.Errorf("Expected a move near the centre stone, got (%d,%d)", move.X, move.Y)
+	}
+	if len(move.PV) == 0 || move.PV[0].Player != 2 {
+		t.Errorf("Expected a principal variation starting with white, got %v", move.PV)
+	}
+
+	// A time budget stops an unlimited iteration count
+	start := time.Now()
+	m.GetMoveWithBudget(board, model.Move{X: 7, Y: 7}, 2, 0, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the time budget to stop the search, took %v", elapsed)
+	}
+}
+
+func TestMCTS_EmptyBoard(t *testing.T) {
+	m := NewMCTSService()
+	move := m.GetMoveForPlayer(createEmptyBoard(), model.Move{X: -1, Y: -1}, 1, Easy)
+	if move.X != 7 || move.Y != 7 {
+		t.Errorf("Expected the centre on an empty board, got (%d,%d)", move.X, move.Y)
+	}
+}
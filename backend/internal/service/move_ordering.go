@@ -0,0 +1,197 @@
+// Package service contains move ordering for the enhanced AI search
+// This file ranks candidate moves (TT move, wins, blocks, threats, killers, history)
+// and narrows the candidate list when forcing threats are on the board
+package service
+
+import (
+	"math/rand"
+	"sort"
+
+	"gomoku-backend/internal/model"
+)
+
+const (
+	// maxSearchPly bounds the killer move table
+	maxSearchPly = 64
+
+	// Ordering tiers, from most to least urgent
+	orderTTMove = 1 << 30
+	orderWin    = 1 << 29
+	orderBlock  = 1 << 28
+	orderThreat = 1 << 26
+	orderKiller = 1 << 24
+
+	// historyLimit keeps history scores below the killer tier
+	historyLimit = 1 << 20
+)
+
+// scoredMove is a candidate move with its ordering score
+type scoredMove struct {
+	move  model.Move
+	score int
+}
+
+// moveThreats summarises the shapes both sides would form by playing on one cell
+type moveThreats struct {
+	own, opp Shape // Best shape over the four directions
+	attack   int   // Sum of shape scores for the side to move
+	defence  int   // Sum of shape scores for the opponent
+	broken   int   // Directions in which the side to move forms a broken three or four
+}
+
+// analyzeMove looks up the shapes each side would form at (x, y)
+func analyzeMove(pb *patternBoard, x, y, player int) moveThreats {
+	var mt moveThreats
+	opponent := 3 - player
+	for d := range directions {
+		own := pb.shape(x, y, d, player)
+		opp := pb.shape(x, y, d, opponent)
+		if own > mt.own {
+			mt.own = own
+		}
+		if opp > mt.opp {
+			mt.opp = opp
+		}
+		mt.attack += shapeScores[own]
+		mt.defence += shapeScores[opp]
+		if own == ShapeBrokenThree || own == ShapeBrokenFour {
+			mt.broken++
+		}
+	}
+	return mt
+}
+
+// orderMoves generates candidate moves for the player, best first.
+// When a forcing threat exists only the moves that deal with it are returned.
+func (w *searchWorker) orderMoves(lastMove model.Move, player int, ttMove model.Move, ply int) []model.Move {
+	pb, s := w.pb, w.search
+	candidates := w.ai.getAvailableMoves(w.board, lastMove)
+
+	scored := make([]scoredMove, 0, len(candidates))
+	threats := make([]moveThreats, 0, len(candidates))
+	var hasWin, mustBlock, oppOpenThree bool
+
+	for _, move := range candidates {
+		mt := analyzeMove(pb, move.X, move.Y, player)
+		threats = append(threats, mt)
+		switch {
+		case mt.own == ShapeFive:
+			hasWin = true
+		case mt.opp == ShapeFive:
+			mustBlock = true
+		case mt.opp == ShapeOpenFour:
+			oppOpenThree = true
+		}
+	}
+
+	for i, move := range candidates {
+		mt := threats[i]
+
+		// Narrow the list when the position is forcing
+		switch {
+		case hasWin:
+			if mt.own != ShapeFive {
+				continue
+			}
+		case mustBlock:
+			if mt.opp != ShapeFive {
+				continue
+			}
+		case oppOpenThree:
+			// Against an open three: block it, or counter with a four
+			if !mt.opp.IsFour() && !mt.own.IsFour() {
+				continue
+			}
+		}
+
+		score := mt.attack*s.orderAttack/100 + mt.defence*s.orderDefence/100 + mt.broken*s.orderBroken
+		switch {
+		case move.X == ttMove.X && move.Y == ttMove.Y:
+			score += orderTTMove
+		case mt.own == ShapeFive:
+			score += orderWin
+		case mt.opp == ShapeFive:
+			score += orderBlock
+		case mt.own.IsFour() || mt.own.IsOpenThree() || mt.opp == ShapeOpenFour:
+			score += orderThreat
+		case w.isKiller(move, ply):
+			score += orderKiller
+		default:
+			score += w.history[player][move.Y*pb.size+move.X]
+		}
+
+		scored = append(scored, scoredMove{move: move, score: score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	moves := make([]model.Move, len(scored))
+	for i, sm := range scored {
+		moves[i] = sm.move
+	}
+	return moves
+}
+
+// isKiller reports whether the move caused a cutoff at the same ply elsewhere in the tree
+func (w *searchWorker) isKiller(move model.Move, ply int) bool {
+	if ply >= maxSearchPly {
+		return false
+	}
+	for _, killer := range w.killers[ply] {
+		if killer.X == move.X && killer.Y == move.Y {
+			return true
+		}
+	}
+	return false
+}
+
+// recordCutoff counts a cutoff caused by the index-th move tried and updates
+// killer moves and the history heuristic
+func (w *searchWorker) recordCutoff(move model.Move, player, depth, ply, index int) {
+	w.cutoffs++
+	if index == 0 {
+		w.firstMoveCutoffs++
+	}
+
+	if ply < maxSearchPly && !w.isKiller(move, ply) {
+		w.killers[ply][1] = w.killers[ply][0]
+		w.killers[ply][0] = move
+	}
+
+	cell := move.Y*w.pb.size + move.X
+	w.history[player][cell] += depth * depth
+	if w.history[player][cell] > historyLimit {
+		w.ageHistory()
+	}
+}
+
+// resetOrdering clears killer moves and the history table before a new search.
+// Helper workers start from slightly perturbed history so they explore different orders.
+func (w *searchWorker) resetOrdering() {
+	for ply := range w.killers {
+		w.killers[ply] = [2]model.Move{{X: -1, Y: -1}, {X: -1, Y: -1}}
+	}
+
+	cells := w.pb.size * w.pb.size
+	r := rand.New(rand.NewSource(int64(w.id)))
+	for player := 1; player <= 2; player++ {
+		w.history[player] = make([]int, cells)
+		if w.id == 0 {
+			continue
+		}
+		for cell := range w.history[player] {
+			w.history[player][cell] = r.Intn(16)
+		}
+	}
+}
+
+// ageHistory halves all history scores so that recent cutoffs dominate
+func (w *searchWorker) ageHistory() {
+	for player := range w.history {
+		for cell := range w.history[player] {
+			w.history[player][cell] /= 2
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package service contains the neural evaluation
+// This file implements a small NNUE-style network: a first layer kept up to date
+// incrementally as stones are placed and removed, followed by two small dense layers
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+)
+
+const (
+	// nnueCells is the number of board cells the network is built for
+	nnueCells = 15 * 15
+
+	// nnueInputs counts the input features: own and opponent stones on every cell, then the
+	// number of own and opponent stones forming each shape, all from one side's perspective
+	nnueInputs = 2*nnueCells + 2*int(shapeCount)
+
+	// nnueScoreScale converts the network output, a win-probability logit, to a search score
+	nnueScoreScale = 1000
+
+	// nnueMaxScore keeps network scores well away from the win scores
+	nnueMaxScore = winScore / 4
+
+	// nnueQuantScale is the fixed-point scale of the first layer. Accumulators hold integers so
+	// placing and removing stones in any order leaves exactly the values a rebuild would give.
+	nnueQuantScale = 1 << 12
+
+	// nnueQuantLimit bounds a quantised weight so accumulators cannot overflow
+	nnueQuantLimit = 1 << 20
+
+	// nnueMagic and nnueVersion identify network files
+	nnueMagic   = "ARYANNUE"
+	nnueVersion = 1
+)
+
+// NeuralNetwork is an efficiently updatable evaluation network. The first layer turns each
+// perspective's features into an accumulator; the side to move's and the opponent's
+// accumulators are clipped, concatenated and passed through a hidden layer to a single output.
+type NeuralNetwork struct {
+	hidden        int
+	hidden2       int
+	inputWeights  []float32 // nnueInputs rows of hidden weights
+	inputBias     []float32
+	hiddenWeights []float32 // hidden2 rows of 2*hidden weights
+	hiddenBias    []float32
+	outputWeights []float32
+	outputBias    []float32 // A single value
+	quantWeights  []int32   // inputWeights in fixed point, used by the accumulators
+	quantBias     []int32
+	salt          uint64 // Mixed into transposition keys so networks do not share entries
+}
+
+// NewNeuralNetwork creates a randomly initialised network with the given layer sizes
+func NewNeuralNetwork(hidden, hidden2 int, seed int64) (*NeuralNetwork, error) {
+	if hidden < 1 || hidden2 < 1 || hidden > 1024 || hidden2 > 1024 {
+		return nil, errors.New("layer sizes must be between 1 and 1024")
+	}
+	n := newNeuralNetwork(hidden, hidden2)
+	rng := rand.New(rand.NewSource(seed))
+	initialise := func(weights []float32, fanIn int) {
+		limit := math.Sqrt(6 / float64(fanIn))
+		for i := range weights {
+			weights[i] = float32((rng.Float64()*2 - 1) * limit)
+		}
+	}
+	// Few inputs are active at once, so the first layer starts small
+	initialise(n.inputWeights, 64)
+	initialise(n.hiddenWeights, 2*hidden)
+	initialise(n.outputWeights, hidden2)
+	for i := range n.inputBias {
+		n.inputBias[i] = 0.5
+	}
+	n.weightsChanged()
+	return n, nil
+}
+
+// newNeuralNetwork allocates a zeroed network
+func newNeuralNetwork(hidden, hidden2 int) *NeuralNetwork {
+	return &NeuralNetwork{
+		hidden:        hidden,
+		hidden2:       hidden2,
+		inputWeights:  make([]float32, nnueInputs*hidden),
+		inputBias:     make([]float32, hidden),
+		hiddenWeights: make([]float32, hidden2*2*hidden),
+		hiddenBias:    make([]float32, hidden2),
+		outputWeights: make([]float32, hidden2),
+		outputBias:    make([]float32, 1),
+		quantWeights:  make([]int32, nnueInputs*hidden),
+		quantBias:     make([]int32, hidden),
+	}
+}
+
+// Size returns the sizes of the two hidden layers
+func (n *NeuralNetwork) Size() (hidden, hidden2 int) {
+	return n.hidden, n.hidden2
+}
+
+// parameters returns every weight in file order
+func (n *NeuralNetwork) parameters() [][]float32 {
+	return [][]float32{
+		n.inputWeights, n.inputBias, n.hiddenWeights, n.hiddenBias, n.outputWeights, n.outputBias,
+	}
+}
+
+// weightsChanged rehashes the weights and requantises the first layer after the weights change
+func (n *NeuralNetwork) weightsChanged() {
+	h := fnv.New64a()
+	for _, params := range n.parameters() {
+		binary.Write(h, binary.LittleEndian, params)
+	}
+	n.salt = h.Sum64() | 1
+
+	for i, weight := range n.inputWeights {
+		n.quantWeights[i] = quantise(weight)
+	}
+	for i, bias := range n.inputBias {
+		n.quantBias[i] = quantise(bias)
+	}
+}
+
+// quantise converts a first-layer weight to fixed point
+func quantise(weight float32) int32 {
+	q := math.Round(float64(weight) * nnueQuantScale)
+	return int32(math.Max(-nnueQuantLimit, math.Min(q, nnueQuantLimit)))
+}
+
+// WriteTo writes the network: the magic, version and layer sizes followed by every weight as
+// little-endian float32
+func (n *NeuralNetwork) WriteTo(w io.Writer) (int64, error) {
+	buffer := bufio.NewWriter(w)
+	header := []uint32{nnueVersion, uint32(nnueInputs), uint32(n.hidden), uint32(n.hidden2)}
+	buffer.WriteString(nnueMagic)
+	binary.Write(buffer, binary.LittleEndian, header)
+	written := int64(len(nnueMagic) + 4*len(header))
+	for _, params := range n.parameters() {
+		binary.Write(buffer, binary.LittleEndian, params)
+		written += int64(4 * len(params))
+	}
+	return written, buffer.Flush()
+}
+
+// Save writes the network to a file
+func (n *NeuralNetwork) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := n.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadNeuralNetwork reads a network written by WriteTo
+func ReadNeuralNetwork(r io.Reader) (*NeuralNetwork, error) {
+	buffer := bufio.NewReader(r)
+	magic := make([]byte, len(nnueMagic))
+	if _, err := io.ReadFull(buffer, magic); err != nil || string(magic) != nnueMagic {
+		return nil, errors.New("not a network file")
+	}
+	var header [4]uint32
+	if err := binary.Read(buffer, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header[0] != nnueVersion {
+		return nil, fmt.Errorf("unsupported network version %d", header[0])
+	}
+	if header[1] != uint32(nnueInputs) {
+		return nil, fmt.Errorf("network has %d inputs, want %d", header[1], nnueInputs)
+	}
+	if header[2] < 1 || header[2] > 1024 || header[3] < 1 || header[3] > 1024 {
+		return nil, fmt.Errorf("invalid layer sizes %dx%d", header[2], header[3])
+	}
+
+	n := newNeuralNetwork(int(header[2]), int(header[3]))
+	for _, params := range n.parameters() {
+		if err := binary.Read(buffer, binary.LittleEndian, params); err != nil {
+			return nil, fmt.Errorf("truncated network: %w", err)
+		}
+	}
+	for _, params := range n.parameters() {
+		for _, value := range params {
+			if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+				return nil, errors.New("network contains non-finite weights")
+			}
+		}
+	}
+	n.weightsChanged()
+	return n, nil
+}
+
+// LoadNeuralNetwork reads a network file
+func LoadNeuralNetwork(path string) (*NeuralNetwork, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	n, err := ReadNeuralNetwork(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// stoneFeature returns the input of a player's stone on a cell from a perspective
+func stoneFeature(perspective, player, idx int) int {
+	if player == perspective {
+		return idx
+	}
+	return nnueCells + idx
+}
+
+// shapeFeature returns the input counting a player's shape from a perspective
+func shapeFeature(perspective, player int, shape Shape) int {
+	if player == perspective {
+		return 2*nnueCells + int(shape)
+	}
+	return 2*nnueCells + int(shapeCount) + int(shape)
+}
+
+// nnueAccumulator holds the first layer output from each player's perspective, in fixed point
+type nnueAccumulator struct {
+	network *NeuralNetwork
+	acc     [3][]int32
+	input   []float32 // Scratch space for the clipped accumulators
+}
+
+// attachNetwork makes the board evaluate with the network, building the accumulators from
+// the current stones and shapes. A nil network restores the pattern evaluation.
+func (pb *patternBoard) attachNetwork(n *NeuralNetwork) {
+	if n == nil || pb.size*pb.size != nnueCells {
+		pb.nnue = nil
+		return
+	}
+	a := &nnueAccumulator{
+		network: n,
+		input:   make([]float32, 2*n.hidden),
+	}
+	for perspective := 1; perspective <= 2; perspective++ {
+		a.acc[perspective] = append([]int32(nil), n.quantBias...)
+	}
+	for idx, player := range pb.cells {
+		if player != 0 {
+			a.addStone(player, idx, 1)
+		}
+	}
+	for player := 1; player <= 2; player++ {
+		for shape := ShapeClosedTwo; shape < shapeCount; shape++ {
+			a.addShape(player, shape, pb.counts[player][shape])
+		}
+	}
+	pb.nnue = a
+}
+
+// addStone adds (sign=1) or removes (sign=-1) a stone from both perspectives
+func (a *nnueAccumulator) addStone(player, idx, sign int) {
+	for perspective := 1; perspective <= 2; perspective++ {
+		a.add(perspective, stoneFeature(perspective, player, idx), int32(sign))
+	}
+}
+
+// addShape adjusts a shape count from both perspectives. Unshaped stones are not inputs.
+func (a *nnueAccumulator) addShape(player int, shape Shape, count int) {
+	if shape == ShapeNone || count == 0 {
+		return
+	}
+	for perspective := 1; perspective <= 2; perspective++ {
+		a.add(perspective, shapeFeature(perspective, player, shape), int32(count))
+	}
+}
+
+// add adds a multiple of one input's weights to a perspective's accumulator
+func (a *nnueAccumulator) add(perspective, feature int, value int32) {
+	hidden := a.network.hidden
+	weights := a.network.quantWeights[feature*hidden : (feature+1)*hidden]
+	acc := a.acc[perspective]
+	for i, weight := range weights {
+		acc[i] += value * weight
+	}
+}
+
+// evaluate runs the layers after the accumulator and returns the score for the side to move
+func (a *nnueAccumulator) evaluate(toMove int) int {
+	n := a.network
+	for i, value := range a.acc[toMove] {
+		a.input[i] = clipped(float32(value) / nnueQuantScale)
+	}
+	for i, value := range a.acc[3-toMove] {
+		a.input[n.hidden+i] = clipped(float32(value) / nnueQuantScale)
+	}
+
+	output := n.outputBias[0]
+	for j := 0; j < n.hidden2; j++ {
+		sum := n.hiddenBias[j]
+		for i, weight := range n.hiddenWeights[j*2*n.hidden : (j+1)*2*n.hidden] {
+			sum += weight * a.input[i]
+		}
+		output += n.outputWeights[j] * clipped(sum)
+	}
+
+	score := int(output * nnueScoreScale)
+	return max(-nnueMaxScore, min(score, nnueMaxScore))
+}
+
+// clipped is the clipped ReLU activation, limiting values to [0, 1]
+func clipped(x float32) float32 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
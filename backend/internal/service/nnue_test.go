@@ -0,0 +1,202 @@
+// Unit tests for the neural evaluation and its trainer
+package service
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestNeuralAccumulatorIncremental(t *testing.T) {
+	network, err := NewNeuralNetwork(32, 8, 1)
+	if err != nil {
+		t.Fatalf("NewNeuralNetwork failed: %v", err)
+	}
+	pb := newPatternBoard(createComplexBoard())
+	pb.attachNetwork(network)
+
+	moves := []model.Move{{X: 9, Y: 7}, {X: 5, Y: 7}, {X: 9, Y: 10}, {X: 6, Y: 9}, {X: 7, Y: 10}}
+	for i, move := range moves {
+		pb.place(move.X, move.Y, i%2+1)
+	}
+	pb.remove(moves[4].X, moves[4].Y)
+	pb.remove(moves[1].X, moves[1].Y)
+
+	grid := createEmptyBoard()
+	for idx, player := range pb.cells {
+		grid[idx/15][idx%15] = player
+	}
+	fresh := newPatternBoard(grid)
+	fresh.attachNetwork(network)
+	for player := 1; player <= 2; player++ {
+		for i, value := range pb.nnue.acc[player] {
+			if value != fresh.nnue.acc[player][i] {
+				t.Fatalf("Perspective %d, unit %d: incremental %d, rebuilt %d", player, i, value, fresh.nnue.acc[player][i])
+			}
+		}
+		if pb.evaluate(player) != fresh.evaluate(player) {
+			t.Errorf("Side %d: incremental score %d, rebuilt %d", player, pb.evaluate(player), fresh.evaluate(player))
+		}
+	}
+
+	// Decided positions keep their forced scores
+	four := newPatternBoard(lineBoard("_XXXX_", 1))
+	four.attachNetwork(network)
+	if score := four.evaluate(1); score != winScore-1 {
+		t.Errorf("Expected a four to score %d, got %d", winScore-1, score)
+	}
+}
+
+func TestNeuralAccumulatorLongSequence(t *testing.T) {
+	network, _ := NewNeuralNetwork(32, 8, 3)
+	pb := newPatternBoard(createEmptyBoard())
+	pb.attachNetwork(network)
+	want := [3]int{0, pb.evaluate(1), pb.evaluate(2)}
+
+	// Thousands of placements and removals, as in a long search, must leave no drift
+	rng := rand.New(rand.NewSource(4))
+	var placed []int
+	for step := 0; step < 5000; step++ {
+		if len(placed) > 0 && (len(placed) >= 40 || rng.Intn(2) == 0) {
+			idx := placed[len(placed)-1]
+			placed = placed[:len(placed)-1]
+			pb.remove(idx%15, idx/15)
+			continue
+		}
+		idx := rng.Intn(nnueCells)
+		if pb.cells[idx] == 0 {
+			pb.place(idx%15, idx/15, len(placed)%2+1)
+			placed = append(placed, idx)
+		}
+	}
+	for len(placed) > 0 {
+		idx := placed[len(placed)-1]
+		placed = placed[:len(placed)-1]
+		pb.remove(idx%15, idx/15)
+	}
+
+	for player := 1; player <= 2; player++ {
+		if score := pb.evaluate(player); score != want[player] {
+			t.Errorf("Side %d: empty board scored %d after the sequence, %d before", player, score, want[player])
+		}
+	}
+}
+
+func TestNeuralNetworkSaveLoad(t *testing.T) {
+	network, _ := NewNeuralNetwork(16, 4, 2)
+	var buffer bytes.Buffer
+	written, err := network.WriteTo(&buffer)
+	if err != nil || written != int64(buffer.Len()) {
+		t.Fatalf("WriteTo wrote %d of %d bytes: %v", written, buffer.Len(), err)
+	}
+
+	loaded, err := ReadNeuralNetwork(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadNeuralNetwork failed: %v", err)
+	}
+	if loaded.salt != network.salt {
+		t.Error("Expected the loaded network to have the same weights")
+	}
+	if hidden, hidden2 := loaded.Size(); hidden != 16 || hidden2 != 4 {
+		t.Errorf("Expected 16x4 layers, got %dx%d", hidden, hidden2)
+	}
+
+	if _, err := ReadNeuralNetwork(bytes.NewReader(buffer.Bytes()[:buffer.Len()-1])); err == nil {
+		t.Error("Expected a truncated file to be rejected")
+	}
+	if _, err := ReadNeuralNetwork(bytes.NewReader([]byte("NOTANNUE"))); err == nil {
+		t.Error("Expected a file with the wrong magic to be rejected")
+	}
+}
+
+func TestTrainNeuralNetwork(t *testing.T) {
+	// Black builds five on a row while white scatters stones; mirror the game for white wins
+	var samples []NetworkSample
+	for row := 3; row < 12; row++ {
+		var moves []model.Move
+		for i := 0; i < 5; i++ {
+			moves = append(moves, model.Move{X: 3 + i, Y: row})
+			if i < 4 {
+				moves = append(moves, model.Move{X: 2 + 3*i, Y: (row + 4) % 15})
+			}
+		}
+		extracted, err := ExtractNetworkSamples(moves, 0)
+		if err != nil {
+			t.Fatalf("ExtractNetworkSamples failed: %v", err)
+		}
+		samples = append(samples, extracted...)
+	}
+	if len(samples) == 0 {
+		t.Fatal("Expected quiet positions")
+	}
+
+	network, _ := NewNeuralNetwork(16, 8, 3)
+	salt := network.salt
+	epochs := 0
+	result, err := TrainNeuralNetwork(network, samples, NetworkTrainOptions{
+		Epochs:       30,
+		BatchSize:    8,
+		LearningRate: 0.01,
+		Lambda:       0.5,
+		Progress:     func(int, float64, float64) { epochs++ },
+	})
+	if err != nil {
+		t.Fatalf("TrainNeuralNetwork failed: %v", err)
+	}
+	if result.TrainLoss >= result.InitialLoss {
+		t.Errorf("Expected the loss to fall, got %.4f -> %.4f", result.InitialLoss, result.TrainLoss)
+	}
+	if epochs != 30 || result.Epochs != 30 {
+		t.Errorf("Expected progress after each of 30 epochs, got %d", epochs)
+	}
+	if network.salt == salt {
+		t.Error("Expected training to change the transposition salt")
+	}
+
+	if _, err := TrainNeuralNetwork(network, nil, NetworkTrainOptions{}); err == nil {
+		t.Error("Expected training without samples to fail")
+	}
+	if _, err := TrainNeuralNetwork(network, samples, NetworkTrainOptions{Lambda: 2}); err == nil {
+		t.Error("Expected an invalid lambda to be rejected")
+	}
+}
+
+func TestNeuralNetworkPerDifficulty(t *testing.T) {
+	ai := NewEnhancedAIService()
+	network, _ := NewNeuralNetwork(16, 8, 4)
+	ai.SetNeuralNetwork(Medium, network)
+	if ai.NeuralNetwork(Medium) != network || ai.NeuralNetwork(Hard) != nil {
+		t.Fatal("Expected the network at medium only")
+	}
+
+	board := createCenterBoard()
+	position := Position{Board: board, LastMove: model.Move{X: 7, Y: 7}, ToMove: 2}
+	neural, err := ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Medium, Depth: 2})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	ai.ClearTranspositionTable()
+	pattern, _ := ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Hard, Depth: 2})
+	if neural.Score == pattern.Score {
+		t.Errorf("Expected the network to change the search score, both gave %d", neural.Score)
+	}
+
+	// Tactics do not depend on the evaluation
+	win := createEmptyBoard()
+	for x := 5; x <= 8; x++ {
+		win[7][x] = 2
+	}
+	win[6][6] = 1
+	move := ai.GetAIMove(win, model.Move{X: 6, Y: 6}, Medium)
+	if (move.X != 9 && move.X != 4) || move.Y != 7 {
+		t.Errorf("Expected the winning move with the network, got (%d,%d)", move.X, move.Y)
+	}
+
+	ai.SetNeuralNetwork(Medium, nil)
+	if ai.NeuralNetwork(Medium) != nil {
+		t.Error("Expected nil to restore the pattern evaluation")
+	}
+}
@@ -0,0 +1,270 @@
+// Package service contains the neural evaluation trainer
+// This file turns games into network samples and fits the network to them with Adam
+package service
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"gomoku-backend/internal/model"
+)
+
+// nnueFeature is an active network input and its value
+type nnueFeature struct {
+	index uint16
+	value float32
+}
+
+// NetworkSample is a quiet position reduced to the network inputs, with the game result and
+// the pattern evaluation from the side to move's point of view
+type NetworkSample struct {
+	features [2][]nnueFeature // The side to move's perspective, then the opponent's
+	Result   float64          // 1 win, 0.5 draw, 0 loss
+	Pattern  int
+}
+
+// NetworkTrainOptions controls training
+type NetworkTrainOptions struct {
+	Epochs       int     // Passes over the training samples; 0 means 10
+	BatchSize    int     // Samples per update; 0 means 256
+	LearningRate float64 // Adam step size; 0 means 0.001
+	Lambda       float64 // Share of the target taken from the pattern evaluation instead of the result
+	Validation   float64 // Fraction of the samples held out to measure progress
+	Seed         int64   // Shuffling seed
+	Progress     func(epoch int, trainLoss, validationLoss float64)
+}
+
+// NetworkTrainResult is the outcome of a training run. Losses are mean squared errors of the
+// predicted win probability; the validation losses are zero without a validation set.
+type NetworkTrainResult struct {
+	InitialLoss    float64
+	TrainLoss      float64
+	ValidationLoss float64
+	Epochs         int
+}
+
+// networkFeatures lists the active inputs from a perspective
+func (pb *patternBoard) networkFeatures(perspective int) []nnueFeature {
+	var features []nnueFeature
+	for idx, player := range pb.cells {
+		if player != 0 {
+			features = append(features, nnueFeature{uint16(stoneFeature(perspective, player, idx)), 1})
+		}
+	}
+	for player := 1; player <= 2; player++ {
+		for shape := ShapeClosedTwo; shape < shapeCount; shape++ {
+			if count := pb.counts[player][shape]; count != 0 {
+				features = append(features, nnueFeature{uint16(shapeFeature(perspective, player, shape)), float32(count)})
+			}
+		}
+	}
+	return features
+}
+
+// ExtractNetworkSamples replays a game and returns its quiet positions as network samples,
+// leaving out the first skip positions like ExtractTuningPositions
+func ExtractNetworkSamples(moves []model.Move, skip int) ([]NetworkSample, error) {
+	var samples []NetworkSample
+	err := replayGame(moves, skip, func(pb *patternBoard, toMove int, result float64) {
+		if _, quiet := pb.evalFeatures(toMove); !quiet || pb.size*pb.size != nnueCells {
+			return
+		}
+		samples = append(samples, NetworkSample{
+			features: [2][]nnueFeature{pb.networkFeatures(toMove), pb.networkFeatures(3 - toMove)},
+			Result:   result,
+			Pattern:  pb.evaluate(toMove),
+		})
+	})
+	return samples, err
+}
+
+// target blends the game result with the win probability the pattern evaluation predicts
+func (s *NetworkSample) target(lambda float64) float64 {
+	return (1-lambda)*s.Result + lambda*sigmoid(float64(s.Pattern)/nnueScoreScale)
+}
+
+// sigmoid maps a logit to a probability
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// networkPass holds the activations of one sample for backpropagation
+type networkPass struct {
+	z1, a1 []float32
+	z2, a2 []float32
+}
+
+// forward computes the win probability of a sample, keeping the activations
+func (n *NeuralNetwork) forward(sample *NetworkSample, pass *networkPass) float64 {
+	for side, features := range sample.features {
+		acc := pass.z1[side*n.hidden : (side+1)*n.hidden]
+		copy(acc, n.inputBias)
+		for _, f := range features {
+			for i, weight := range n.inputWeights[int(f.index)*n.hidden : int(f.index+1)*n.hidden] {
+				acc[i] += f.value * weight
+			}
+		}
+	}
+	for i, value := range pass.z1 {
+		pass.a1[i] = clipped(value)
+	}
+
+	output := n.outputBias[0]
+	for j := 0; j < n.hidden2; j++ {
+		sum := n.hiddenBias[j]
+		for i, weight := range n.hiddenWeights[j*2*n.hidden : (j+1)*2*n.hidden] {
+			sum += weight * pass.a1[i]
+		}
+		pass.z2[j], pass.a2[j] = sum, clipped(sum)
+		output += n.outputWeights[j] * pass.a2[j]
+	}
+	return sigmoid(float64(output))
+}
+
+// backward adds the gradient of the sample's squared error to grad
+func (n *NeuralNetwork) backward(sample *NetworkSample, pass *networkPass, p, target float64, grad *NeuralNetwork) {
+	dOutput := float32(2 * (p - target) * p * (1 - p))
+	grad.outputBias[0] += dOutput
+
+	dA1 := make([]float32, 2*n.hidden)
+	for j := 0; j < n.hidden2; j++ {
+		grad.outputWeights[j] += dOutput * pass.a2[j]
+		if pass.z2[j] <= 0 || pass.z2[j] >= 1 {
+			continue
+		}
+		dZ2 := dOutput * n.outputWeights[j]
+		grad.hiddenBias[j] += dZ2
+		row := j * 2 * n.hidden
+		for i := range dA1 {
+			grad.hiddenWeights[row+i] += dZ2 * pass.a1[i]
+			dA1[i] += dZ2 * n.hiddenWeights[row+i]
+		}
+	}
+
+	for side, features := range sample.features {
+		dAcc := dA1[side*n.hidden : (side+1)*n.hidden]
+		for i := range dAcc {
+			if z := pass.z1[side*n.hidden+i]; z <= 0 || z >= 1 {
+				dAcc[i] = 0
+			}
+			grad.inputBias[i] += dAcc[i]
+		}
+		for _, f := range features {
+			row := grad.inputWeights[int(f.index)*n.hidden : int(f.index+1)*n.hidden]
+			for i, d := range dAcc {
+				row[i] += f.value * d
+			}
+		}
+	}
+}
+
+// networkLoss returns the mean squared error of the predicted win probabilities
+func (n *NeuralNetwork) networkLoss(samples []NetworkSample, lambda float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	pass := n.newPass()
+	total := 0.0
+	for i := range samples {
+		diff := n.forward(&samples[i], pass) - samples[i].target(lambda)
+		total += diff * diff
+	}
+	return total / float64(len(samples))
+}
+
+// newPass allocates activation buffers
+func (n *NeuralNetwork) newPass() *networkPass {
+	return &networkPass{
+		z1: make([]float32, 2*n.hidden),
+		a1: make([]float32, 2*n.hidden),
+		z2: make([]float32, n.hidden2),
+		a2: make([]float32, n.hidden2),
+	}
+}
+
+// TrainNeuralNetwork fits the network to the samples by minimising the squared error between
+// its predicted win probability and the target, using mini-batch Adam
+func TrainNeuralNetwork(n *NeuralNetwork, samples []NetworkSample, options NetworkTrainOptions) (NetworkTrainResult, error) {
+	if len(samples) == 0 {
+		return NetworkTrainResult{}, errors.New("no samples to train on")
+	}
+	if options.Lambda < 0 || options.Lambda > 1 {
+		return NetworkTrainResult{}, errors.New("lambda must be between 0 and 1")
+	}
+	if options.Validation < 0 || options.Validation >= 1 {
+		return NetworkTrainResult{}, errors.New("validation fraction must be below 1")
+	}
+	epochs, batchSize, rate := options.Epochs, options.BatchSize, options.LearningRate
+	if epochs <= 0 {
+		epochs = 10
+	}
+	if batchSize <= 0 {
+		batchSize = 256
+	}
+	if rate <= 0 {
+		rate = 0.001
+	}
+
+	rng := rand.New(rand.NewSource(options.Seed))
+	shuffled := append([]NetworkSample(nil), samples...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	held := int(float64(len(shuffled)) * options.Validation)
+	validation, training := shuffled[:held], shuffled[held:]
+	if len(training) == 0 {
+		return NetworkTrainResult{}, errors.New("no samples left after the validation split")
+	}
+
+	result := NetworkTrainResult{InitialLoss: n.networkLoss(training, options.Lambda)}
+	grad := newNeuralNetwork(n.hidden, n.hidden2)
+	moment := newNeuralNetwork(n.hidden, n.hidden2)
+	velocity := newNeuralNetwork(n.hidden, n.hidden2)
+	params, grads := n.parameters(), grad.parameters()
+	moments, velocities := moment.parameters(), velocity.parameters()
+	pass := n.newPass()
+	const beta1, beta2, epsilon = 0.9, 0.999, 1e-8
+	step := 0
+
+	for result.Epochs < epochs {
+		result.Epochs++
+		rng.Shuffle(len(training), func(i, j int) {
+			training[i], training[j] = training[j], training[i]
+		})
+
+		for start := 0; start < len(training); start += batchSize {
+			batch := training[start:min(start+batchSize, len(training))]
+			for _, g := range grads {
+				clear(g)
+			}
+			for i := range batch {
+				p := n.forward(&batch[i], pass)
+				n.backward(&batch[i], pass, p, batch[i].target(options.Lambda), grad)
+			}
+
+			step++
+			correction1 := 1 - math.Pow(beta1, float64(step))
+			correction2 := 1 - math.Pow(beta2, float64(step))
+			scale := float32(1 / float64(len(batch)))
+			for k, values := range params {
+				for i := range values {
+					g := float64(grads[k][i] * scale)
+					m := beta1*float64(moments[k][i]) + (1-beta1)*g
+					v := beta2*float64(velocities[k][i]) + (1-beta2)*g*g
+					moments[k][i], velocities[k][i] = float32(m), float32(v)
+					values[i] -= float32(rate * (m / correction1) / (math.Sqrt(v/correction2) + epsilon))
+				}
+			}
+		}
+
+		result.TrainLoss = n.networkLoss(training, options.Lambda)
+		result.ValidationLoss = n.networkLoss(validation, options.Lambda)
+		if options.Progress != nil {
+			options.Progress(result.Epochs, result.TrainLoss, result.ValidationLoss)
+		}
+	}
+
+	n.weightsChanged()
+	return result, nil
+}
@@ -0,0 +1,266 @@
+// Package service contains the OpenAI-compatible LLM adapter
+// This file talks to any server that implements the OpenAI chat-completions API: OpenAI
+// itself, vLLM, LM Studio, the llama.cpp server and others. The move comes back through a
+// function call, a JSON-mode reply or plain text, depending on what the server supports.
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// Ways of asking an OpenAI-compatible server for a structured move
+const (
+	StructuredTools = "tools" // Force a call of the place_stone function
+	StructuredJSON  = "json"  // JSON mode: response_format json_object
+	StructuredNone  = "none"  // Plain text that contains the JSON object
+)
+
+// Defaults for the OpenAI-compatible adapter
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+	openAIHost           = "api.openai.com"
+)
+
+// OpenAIAdapter implements LLMAdapter for the OpenAI chat-completions API and servers
+// compatible with it
+type OpenAIAdapter struct {
+	httpClient *http.Client
+}
+
+// NewOpenAIAdapter creates a new OpenAI-compatible adapter
+func NewOpenAIAdapter() *OpenAIAdapter {
+	return &OpenAIAdapter{
+		httpClient: &http.Client{
+			Timeout: 90 * time.Second, // Local servers can be slow to answer
+		},
+	}
+}
+
+// openAIResponse is the part of a chat-completions response the adapter reads
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// GetMove implements LLMAdapter interface for OpenAI-compatible servers
+func (o *OpenAIAdapter) GetMove(board [][]int, lastMove model.Move, config model.LLMConfig) (*model.LLMMove, error) {
+	return o.RetryMove(board, lastMove, config, nil)
+}
+
+// RetryMove implements MoveCorrector interface for OpenAI-compatible servers
+func (o *OpenAIAdapter) RetryMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string) (*model.LLMMove, error) {
+	return o.requestMove(board, lastMove, config, mistakes, nil)
+}
+
+// StreamMove implements StreamingAdapter interface for OpenAI-compatible servers
+func (o *OpenAIAdapter) StreamMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
+	return o.requestMove(board, lastMove, config, mistakes, onToken)
+}
+
+// requestMove asks the server for a move, streaming the answer to onToken unless it is nil
+func (o *OpenAIAdapter) requestMove(board [][]int, lastMove model.Move, config model.LLMConfig, mistakes []string, onToken func(string)) (*model.LLMMove, error) {
+	if err := o.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	structured := stringParam(config, "structured_output", StructuredTools)
+
+	requestBody := o.buildRequest(board, lastMove, config, structured, mistakes)
+	if onToken != nil {
+		requestBody["stream"] = true
+		requestBody["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", chatCompletionsURL(config.Endpoint), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+config.APIKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, providerError(resp, openAIErrorMessage(body))
+	}
+
+	var reply chatReply
+	if onToken != nil {
+		reply, err = readChatStream(resp.Body, onToken)
+	} else {
+		reply, err = decodeChatResponse(resp.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Servers that ignore tool_choice answer in text, so fall back to the content
+	content := reply.Content
+	if reply.Arguments != "" {
+		content = reply.Arguments
+	}
+	usage := reply.Usage
+	if usage == nil {
+		usage = &model.LLMUsage{Requests: 1}
+	}
+	move, err := parseMoveJSON(content)
+	if err != nil {
+		return nil, &UnparsableMoveError{Err: err, Usage: usage}
+	}
+	move.Usage = usage
+	move.Player = 2 // LLM is player 2
+	move.Timestamp = time.Now()
+
+	return move, nil
+}
+
+// buildRequest assembles the chat-completions payload for the structured output mode
+func (o *OpenAIAdapter) buildRequest(board [][]int, lastMove model.Move, config model.LLMConfig, structured string, mistakes []string) map[string]interface{} {
+	prompt := buildMovePrompt(board, lastMove)
+	if structured == StructuredTools {
+		prompt += toolAnswerFormat
+	} else {
+		prompt += jsonAnswerFormat
+	}
+	prompt += correctionPrompt(mistakes)
+
+	requestBody := map[string]interface{}{
+		"model": stringParam(config, "model", defaultOpenAIModel),
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": moveSystemPrompt},
+			{"role": "user", "content": prompt},
+		},
+		"temperature": 0.7,
+		"max_tokens":  1000,
+	}
+	if params, ok := config.Parameters["temperature"]; ok {
+		requestBody["temperature"] = params
+	}
+	if params, ok := config.Parameters["max_tokens"]; ok {
+		requestBody["max_tokens"] = params
+	}
+
+	switch structured {
+	case StructuredTools:
+		requestBody["tools"] = []map[string]interface{}{{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        placeStoneTool,
+				"description": placeStoneSummary,
+				"parameters":  placeStoneSchema(),
+			},
+		}}
+		requestBody["tool_choice"] = map[string]interface{}{
+			"type":     "function",
+			"function": map[string]interface{}{"name": placeStoneTool},
+		}
+	case StructuredJSON:
+		requestBody["response_format"] = map[string]interface{}{"type": "json_object"}
+	}
+	return requestBody
+}
+
+// ValidateConfig validates OpenAI-compatible configuration
+func (o *OpenAIAdapter) ValidateConfig(config model.LLMConfig) error {
+	if err := validateEndpoint(config.Endpoint); err != nil {
+		return err
+	}
+	if config.APIKey == "" && isOpenAIEndpoint(config.Endpoint) {
+		return errors.New("API key is required for the OpenAI API")
+	}
+	switch stringParam(config, "structured_output", StructuredTools) {
+	case StructuredTools, StructuredJSON, StructuredNone:
+	default:
+		return fmt.Errorf("structured_output must be %s, %s or %s", StructuredTools, StructuredJSON, StructuredNone)
+	}
+	return nil
+}
+
+// IsConfigured implements ConfigChecker: only the OpenAI API itself needs a key
+func (o *OpenAIAdapter) IsConfigured(config model.LLMConfig) bool {
+	return o.ValidateConfig(config) == nil
+}
+
+// GetModelInfo returns OpenAI-compatible model information
+func (o *OpenAIAdapter) GetModelInfo() model.LLMModel {
+	return model.LLMModel{
+		Name:           "chatgpt",
+		DisplayName:    "OpenAI Compatible",
+		Provider:       "openai",
+		RequiresAPIKey: false,
+		DefaultParams: map[string]interface{}{
+			"model":             defaultOpenAIModel,
+			"temperature":       0.7,
+			"max_tokens":        1000,
+			"structured_output": StructuredTools,
+		},
+		Status: "available",
+	}
+}
+
+// chatCompletionsURL accepts either a base URL such as http://localhost:8000/v1 or the full
+// chat-completions URL
+func chatCompletionsURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if endpoint == "" {
+		endpoint = defaultOpenAIBaseURL
+	}
+	if strings.HasSuffix(endpoint, "/chat/completions") {
+		return endpoint
+	}
+	return endpoint + "/chat/completions"
+}
+
+// isOpenAIEndpoint reports whether the endpoint is the OpenAI API, which the empty endpoint
+// defaults to
+func isOpenAIEndpoint(endpoint string) bool {
+	if endpoint == "" {
+		return true
+	}
+	parsed, err := url.Parse(endpoint)
+	return err == nil && parsed.Hostname() == openAIHost
+}
+
+// openAIErrorMessage returns the message of an OpenAI-style error body, or the body itself
+func openAIErrorMessage(body []byte) string {
+	var apiError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
+		return apiError.Error.Message
+	}
+	return string(body)
+}
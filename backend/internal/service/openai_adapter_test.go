@@ -0,0 +1,296 @@
+// Unit tests for the OpenAI-compatible adapter against a stub server
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// openAIStub serves chat completions with the reply built for each decoded request
+func openAIStub(t *testing.T, reply func(request map[string]interface{}) (int, string)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		var request map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		request["authorization"] = r.Header.Get("Authorization")
+		status, body := reply(request)
+		if stream, _ := request["stream"].(bool); stream && status == http.StatusOK {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range streamChunks(t, body) {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+// streamChunks splits a chat completion into the chunks a server streams it as: the text a
+// few characters at a time, then the usage
+func streamChunks(t *testing.T, body string) []string {
+	var response openAIResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to decode stub reply: %v", err)
+	}
+	message := response.Choices[0].Message
+	text, tool := message.Content, false
+	if len(message.ToolCalls) > 0 {
+		text, tool = message.ToolCalls[0].Function.Arguments, true
+	}
+
+	var chunks []string
+	runes := []rune(text)
+	for start := 0; start < len(runes); start += 4 {
+		piece, _ := json.Marshal(string(runes[start:min(start+4, len(runes))]))
+		if tool {
+			chunks = append(chunks, fmt.Sprintf(`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":%s}}]}}]}`, piece))
+		} else {
+			chunks = append(chunks, fmt.Sprintf(`{"choices":[{"delta":{"content":%s}}]}`, piece))
+		}
+	}
+	if response.Usage != nil {
+		usage, _ := json.Marshal(response.Usage)
+		chunks = append(chunks, fmt.Sprintf(`{"choices":[],"usage":%s}`, usage))
+	}
+	return chunks
+}
+
+func TestOpenAIAdapterTools(t *testing.T) {
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		if request["model"] != "qwen2.5-7b" {
+			t.Errorf("Expected the configured model, got %v", request["model"])
+		}
+		if request["authorization"] != "" {
+			t.Errorf("Expected no authorization without a key, got %v", request["authorization"])
+		}
+		if _, ok := request["tools"]; !ok {
+			t.Error("Expected the place_stone tool in the request")
+		}
+		return http.StatusOK, `{"choices":[{"message":{"content":null,"tool_calls":[{"type":"function",
+			"function":{"name":"place_stone","arguments":"{\"x\":8,\"y\":6,\"reasoning\":\"block\"}"}}]}}],
+			"usage":{"prompt_tokens":500,"completion_tokens":40,"total_tokens":540}}`
+	})
+	defer server.Close()
+
+	config := model.LLMConfig{
+		Endpoint:   server.URL + "/v1",
+		Parameters: map[string]interface{}{"model": "qwen2.5-7b"},
+	}
+	move, err := NewOpenAIAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config)
+	if err != nil {
+		t.Fatalf("GetMove failed: %v", err)
+	}
+	if move.X != 8 || move.Y != 6 || move.Reasoning != "block" || move.Player != 2 {
+		t.Errorf("Unexpected move %+v", move)
+	}
+	want := model.LLMUsage{Requests: 1, PromptTokens: 500, CompletionTokens: 40, TotalTokens: 540}
+	if move.Usage == nil || *move.Usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, move.Usage)
+	}
+}
+
+func TestOpenAIAdapterJSONMode(t *testing.T) {
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		if request["authorization"] != "Bearer sk-test" {
+			t.Errorf("Expected the key as a bearer token, got %v", request["authorization"])
+		}
+		if _, ok := request["tools"]; ok {
+			t.Error("Expected no tools in JSON mode")
+		}
+		format, _ := request["response_format"].(map[string]interface{})
+		if format["type"] != "json_object" {
+			t.Errorf("Expected JSON mode, got %v", request["response_format"])
+		}
+		return http.StatusOK, `{"choices":[{"message":{"content":"{\"x\": 3, \"y\": 4, \"reasoning\": \"centre\"}"}}]}`
+	})
+	defer server.Close()
+
+	config := model.LLMConfig{
+		APIKey:     "sk-test",
+		Endpoint:   server.URL + "/v1/chat/completions",
+		Parameters: map[string]interface{}{"structured_output": StructuredJSON},
+	}
+	move, err := NewOpenAIAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config)
+	if err != nil {
+		t.Fatalf("GetMove failed: %v", err)
+	}
+	if move.X != 3 || move.Y != 4 {
+		t.Errorf("Expected (3, 4), got (%d, %d)", move.X, move.Y)
+	}
+	if move.Usage == nil || *move.Usage != (model.LLMUsage{Requests: 1}) {
+		t.Errorf("Expected one request without token counts, got %+v", move.Usage)
+	}
+}
+
+func TestOpenAIAdapterErrors(t *testing.T) {
+	// A server that ignores tool_choice and answers in prose still yields the move
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"choices":[{"message":{"content":"I play {\"x\": 1, \"y\": 2, \"reasoning\": \"edge\"} here"}}]}`
+	})
+	config := model.LLMConfig{Endpoint: server.URL + "/v1/"}
+	move, err := NewOpenAIAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, config)
+	server.Close()
+	if err != nil || move.X != 1 || move.Y != 2 {
+		t.Errorf("Expected (1, 2) from the content, got %+v, %v", move, err)
+	}
+
+	server = openAIStub(t, func(request map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"choices":[{"message":{"content":"no idea"}}]}`
+	})
+	_, err = NewOpenAIAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, model.LLMConfig{Endpoint: server.URL + "/v1"})
+	server.Close()
+	if err == nil || !strings.Contains(err.Error(), "failed to parse move") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	server = openAIStub(t, func(request map[string]interface{}) (int, string) {
+		return http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`
+	})
+	_, err = NewOpenAIAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, model.LLMConfig{APIKey: "sk-bad", Endpoint: server.URL + "/v1"})
+	server.Close()
+	if err == nil || !strings.Contains(err.Error(), "status 401: Incorrect API key provided") {
+		t.Errorf("Expected the API error message, got %v", err)
+	}
+}
+
+func TestDeepSeekAdapterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+		detail string
+	}{
+		{"rate limit", http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached for requests","type":"rate_limit_error"}}`, ErrLLMRateLimited, "Rate limit reached for requests (retry after 20s)"},
+		{"busy", http.StatusServiceUnavailable, `{"error":{"message":"Server overloaded, please retry shortly","type":"service_unavailable_error"}}`, ErrLLMOverloaded, "Server overloaded"},
+		{"bad key", http.StatusUnauthorized, `{"error":{"message":"Authentication Fails (no such user)","type":"authentication_error"}}`, nil, "status 401: Authentication Fails (no such user)"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "20")
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+		_, err := NewDeepSeekAdapter().GetMove(createGrid(15), model.Move{X: 7, Y: 7, Player: 1}, model.LLMConfig{APIKey: "sk-test", Endpoint: server.URL + "/v1/chat/completions"})
+		server.Close()
+		if err == nil || !strings.Contains(err.Error(), tt.detail) {
+			t.Errorf("%s: expected an error with %q, got %v", tt.name, tt.detail, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+		if tt.want == nil && (errors.Is(err, ErrLLMRateLimited) || errors.Is(err, ErrLLMOverloaded)) {
+			t.Errorf("%s: expected a permanent error, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	later := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	tests := map[string]string{
+		"":                              "",
+		"20":                            "20s",
+		later:                           "1m30s",
+		"soon, probably":                "soon, probably",
+		"Mon, 02 Jan 2006 15:04:05 GMT": "0s",
+	}
+	for header, want := range tests {
+		got := retryAfter(header)
+		// The date is formatted to the second, so the wait may come out a second short
+		if got != want && !(header == later && got == "1m29s") {
+			t.Errorf("retryAfter(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestOpenAIAdapterConfig(t *testing.T) {
+	adapter := NewOpenAIAdapter()
+	tests := []struct {
+		name   string
+		config model.LLMConfig
+		valid  bool
+	}{
+		{"OpenAI without key", model.LLMConfig{}, false},
+		{"OpenAI with key", model.LLMConfig{APIKey: "sk-test", Endpoint: "https://api.openai.com/v1"}, true},
+		{"local server", model.LLMConfig{Endpoint: "http://localhost:1234/v1"}, true},
+		{"bad endpoint", model.LLMConfig{Endpoint: "localhost:1234"}, false},
+		{"bad mode", model.LLMConfig{Endpoint: "http://localhost:8080", Parameters: map[string]interface{}{"structured_output": "xml"}}, false},
+	}
+	for _, tt := range tests {
+		if err := adapter.ValidateConfig(tt.config); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+
+	urls := map[string]string{
+		"":                          "https://api.openai.com/v1/chat/completions",
+		"http://localhost:8000/v1":  "http://localhost:8000/v1/chat/completions",
+		"http://localhost:8080/v1/": "http://localhost:8080/v1/chat/completions",
+		"https://example.com/v1/chat/completions": "https://example.com/v1/chat/completions",
+	}
+	for endpoint, want := range urls {
+		if got := chatCompletionsURL(endpoint); got != want {
+			t.Errorf("chatCompletionsURL(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestLLMServiceUsage(t *testing.T) {
+	server := openAIStub(t, func(request map[string]interface{}) (int, string) {
+		messages := request["messages"].([]interface{})
+		prompt := messages[1].(map[string]interface{})["content"].(string)
+		// Answer on the top row, away from the human's stones
+		x := 6
+		if strings.Contains(prompt, "(6, 7)") {
+			x = 5
+		}
+		return http.StatusOK, fmt.Sprintf(`{"choices":[{"message":{"tool_calls":[{"function":{"name":"place_stone",
+			"arguments":"{\"x\":%d,\"y\":0,\"reasoning\":\"\"}"}}]}}],
+			"usage":{"prompt_tokens":100,"completion_tokens":10,"total_tokens":110}}`, x)
+	})
+	defer server.Close()
+
+	service := NewLLMService()
+	if err := service.UpdateConfig("chatgpt", model.LLMConfig{Endpoint: server.URL + "/v1"}); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	for _, info := range service.GetAvailableModels() {
+		if info.Name == "chatgpt" && info.Status != "available" {
+			t.Errorf("Expected a keyless local server to be available, got %s", info.Status)
+		}
+	}
+
+	game, err := service.StartGame("chatgpt")
+	if err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	for _, x := range []int{7, 6} {
+		if _, err := service.MakeMove(game.ID, model.Move{X: x, Y: 7}); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+	}
+
+	want := model.LLMUsage{Requests: 2, PromptTokens: 200, CompletionTokens: 20, TotalTokens: 220}
+	if game.Usage != want {
+		t.Errorf("Expected game usage %+v, got %+v", want, game.Usage)
+	}
+	if usage := service.GetUsage()["chatgpt"]; usage != want {
+		t.Errorf("Expected model usage %+v, got %+v", want, usage)
+	}
+}
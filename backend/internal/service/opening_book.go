@@ -0,0 +1,562 @@
+// Package service contains the opening book used by the enhanced AI
+// This file implements a symmetry-aware position→moves store with weights,
+// weighted random selection and importers for Gomocup opening lists and Renlib libraries
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// Supported opening book file formats
+const (
+	BookFormatGomocup = "gomocup" // One opening per line: x,y pairs relative to the centre
+	BookFormatRenlib  = "renlib"  // Renlib .lib binary move tree
+)
+
+// BookMove is a candidate move stored in the opening book
+type BookMove struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Weight int `json:"weight"`
+}
+
+// BookPosition is one book position with its candidate moves, in canonical orientation
+type BookPosition struct {
+	Stones []model.Move `json:"stones"`
+	Moves  []BookMove   `json:"moves"`
+}
+
+// OpeningBook maps positions to weighted candidate moves.
+// Positions are stored once per symmetry class, so a line learnt in one orientation
+// is also played in its seven rotated and mirrored forms.
+type OpeningBook struct {
+	mutex     sync.RWMutex
+	boardSize int
+	positions map[uint64]*BookPosition
+	rand      *rand.Rand
+}
+
+// NewOpeningBook creates an empty opening book for a 15x15 board
+func NewOpeningBook() *OpeningBook {
+	return &OpeningBook{
+		boardSize: 15,
+		positions: make(map[uint64]*BookPosition),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// defaultOpeningLines are built-in lines relative to the centre; the weight applies to the last move
+var defaultOpeningLines = []struct {
+	moves  [][2]int
+	weight int
+}{
+	{[][2]int{{0, 0}}, 1},                    // Black opens in the centre
+	{[][2]int{{0, 0}, {0, -1}}, 3},           // Direct reply
+	{[][2]int{{0, 0}, {1, -1}}, 2},           // Indirect (diagonal) reply
+	{[][2]int{{0, 0}, {0, -1}, {1, -1}}, 3},  // Direct: diagonal third move
+	{[][2]int{{0, 0}, {0, -1}, {1, 0}}, 2},   // Direct: side third move
+	{[][2]int{{0, 0}, {1, -1}, {1, 0}}, 3},   // Indirect: adjacent to both stones
+	{[][2]int{{0, 0}, {1, -1}, {0, -1}}, 3},  // Indirect: adjacent to both stones
+	{[][2]int{{0, 0}, {1, -1}, {-1, -1}}, 1}, // Indirect: long diagonal shape
+	{[][2]int{{0, 0}, {0, -1}, {-1, 1}}, 1},  // Direct: open diagonal
+	{[][2]int{{0, 0}, {0, -1}, {1, -1}, {-1, 1}}, 2},
+	{[][2]int{{0, 0}, {1, -1}, {1, 0}, {-1, 0}}, 2},
+}
+
+// NewDefaultOpeningBook creates an opening book holding the built-in lines
+func NewDefaultOpeningBook() *OpeningBook {
+	book := NewOpeningBook()
+	center := book.boardSize / 2
+	for _, line := range defaultOpeningLines {
+		moves := make([]model.Move, len(line.moves))
+		for i, offset := range line.moves {
+			moves[i] = model.Move{X: center + offset[0], Y: center + offset[1]}
+		}
+		board, err := book.BoardFromMoves(moves[:len(moves)-1])
+		if err == nil {
+			book.Add(board, moves[len(moves)-1], line.weight)
+		}
+	}
+	return book
+}
+
+// transform maps (x, y) through one of the eight board symmetries
+func transform(x, y, sym, size int) (int, int) {
+	m := size - 1
+	switch sym {
+	case 1:
+		return m - x, y
+	case 2:
+		return x, m - y
+	case 3:
+		return m - x, m - y
+	case 4:
+		return y, x
+	case 5:
+		return m - y, x
+	case 6:
+		return y, m - x
+	case 7:
+		return m - y, m - x
+	default:
+		return x, y
+	}
+}
+
+// inverseSymmetry returns the symmetry that undoes sym
+func inverseSymmetry(sym int) int {
+	switch sym {
+	case 5:
+		return 6
+	case 6:
+		return 5
+	default:
+		return sym
+	}
+}
+
+// canonical returns the hash of the board's canonical orientation and the symmetry that produces it
+func (b *OpeningBook) canonical(board [][]int) (uint64, int) {
+	var hashes [8]uint64
+	for y := 0; y < b.boardSize; y++ {
+		for x := 0; x < b.boardSize; x++ {
+			player := board[y][x]
+			if player == 0 {
+				continue
+			}
+			for sym := range hashes {
+				tx, ty := transform(x, y, sym, b.boardSize)
+				hashes[sym] ^= zobristKeys[player][ty*b.boardSize+tx]
+			}
+		}
+	}
+
+	best := 0
+	for sym := 1; sym < len(hashes); sym++ {
+		if hashes[sym] < hashes[best] {
+			best = sym
+		}
+	}
+	return hashes[best], best
+}
+
+// sideToMove returns the player to move, assuming black (1) opened
+func sideToMove(board [][]int) int {
+	counts := [3]int{}
+	for _, row := range board {
+		for _, cell := range row {
+			counts[cell]++
+		}
+	}
+	if counts[1] > counts[2] {
+		return 2
+	}
+	return 1
+}
+
+// validBoard reports whether the board has the book's dimensions and only valid cell values
+func (b *OpeningBook) validBoard(board [][]int) bool {
+	if len(board) != b.boardSize {
+		return false
+	}
+	for _, row := range board {
+		if len(row) != b.boardSize {
+			return false
+		}
+		for _, cell := range row {
+			if cell < 0 || cell > 2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Add adds weight to a candidate move for the position, creating the entry if needed
+func (b *OpeningBook) Add(board [][]int, move model.Move, weight int) error {
+	if !b.validBoard(board) {
+		return fmt.Errorf("board must be %dx%d", b.boardSize, b.boardSize)
+	}
+	if move.X < 0 || move.X >= b.boardSize || move.Y < 0 || move.Y >= b.boardSize || board[move.Y][move.X] != 0 {
+		return fmt.Errorf("move (%d,%d) is not an empty cell", move.X, move.Y)
+	}
+	if weight <= 0 {
+		return errors.New("weight must be positive")
+	}
+
+	key, sym := b.canonical(board)
+	x, y := transform(move.X, move.Y, sym, b.boardSize)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	position, exists := b.positions[key]
+	if !exists {
+		position = &BookPosition{Stones: b.canonicalStones(board, sym)}
+		b.positions[key] = position
+	}
+	for i := range position.Moves {
+		if position.Moves[i].X == x && position.Moves[i].Y == y {
+			position.Moves[i].Weight += weight
+			return nil
+		}
+	}
+	position.Moves = append(position.Moves, BookMove{X: x, Y: y, Weight: weight})
+	return nil
+}
+
+// canonicalStones lists the stones of the board in canonical orientation
+func (b *OpeningBook) canonicalStones(board [][]int, sym int) []model.Move {
+	stones := []model.Move{}
+	for y := 0; y < b.boardSize; y++ {
+		for x := 0; x < b.boardSize; x++ {
+			if board[y][x] != 0 {
+				tx, ty := transform(x, y, sym, b.boardSize)
+				stones = append(stones, model.Move{X: tx, Y: ty, Player: board[y][x]})
+			}
+		}
+	}
+	return stones
+}
+
+// Remove deletes a candidate move from the position, or the whole position when move.X is negative.
+// It reports whether anything was removed.
+func (b *OpeningBook) Remove(board [][]int, move model.Move) bool {
+	if !b.validBoard(board) {
+		return false
+	}
+	key, sym := b.canonical(board)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	position, exists := b.positions[key]
+	if !exists {
+		return false
+	}
+	if move.X < 0 {
+		delete(b.positions, key)
+		return true
+	}
+
+	x, y := transform(move.X, move.Y, sym, b.boardSize)
+	for i, candidate := range position.Moves {
+		if candidate.X == x && candidate.Y == y {
+			position.Moves = append(position.Moves[:i], position.Moves[i+1:]...)
+			if len(position.Moves) == 0 {
+				delete(b.positions, key)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the book moves for the position in the board's own orientation, heaviest first
+func (b *OpeningBook) Lookup(board [][]int) []BookMove {
+	if !b.validBoard(board) {
+		return nil
+	}
+	key, sym := b.canonical(board)
+	inverse := inverseSymmetry(sym)
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	position, exists := b.positions[key]
+	if !exists {
+		return nil
+	}
+
+	moves := make([]BookMove, 0, len(position.Moves))
+	for _, candidate := range position.Moves {
+		x, y := transform(candidate.X, candidate.Y, inverse, b.boardSize)
+		// Guard against hash collisions with a different position
+		if board[y][x] == 0 {
+			moves = append(moves, BookMove{X: x, Y: y, Weight: candidate.Weight})
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Weight > moves[j].Weight
+	})
+	return moves
+}
+
+// Pick chooses a book move for the player with probability proportional to its weight.
+// It returns false when the position is not in the book or it is not the player's turn.
+func (b *OpeningBook) Pick(board [][]int, player int) (model.Move, bool) {
+	if !b.validBoard(board) || sideToMove(board) != player {
+		return model.Move{}, false
+	}
+	moves := b.Lookup(board)
+	if len(moves) == 0 {
+		return model.Move{}, false
+	}
+
+	total := 0
+	for _, candidate := range moves {
+		total += candidate.Weight
+	}
+
+	b.mutex.Lock()
+	roll := b.rand.Intn(total)
+	b.mutex.Unlock()
+
+	for _, candidate := range moves {
+		if roll < candidate.Weight {
+			return model.Move{X: candidate.X, Y: candidate.Y, Player: player}, true
+		}
+		roll -= candidate.Weight
+	}
+	return model.Move{}, false
+}
+
+// Len returns the number of positions in the book
+func (b *OpeningBook) Len() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return len(b.positions)
+}
+
+// Positions returns up to limit book positions, fewest stones first (limit <= 0 returns all)
+func (b *OpeningBook) Positions(limit int) []BookPosition {
+	type keyedPosition struct {
+		position BookPosition
+		layout   string
+	}
+
+	b.mutex.RLock()
+	keyed := make([]keyedPosition, 0, len(b.positions))
+	for _, position := range b.positions {
+		keyed = append(keyed, keyedPosition{
+			position: BookPosition{
+				Stones: append([]model.Move(nil), position.Stones...),
+				Moves:  append([]BookMove(nil), position.Moves...),
+			},
+			layout: fmt.Sprint(position.Stones),
+		})
+	}
+	b.mutex.RUnlock()
+
+	// Order deterministically: fewest stones first, then by stone layout
+	sort.Slice(keyed, func(i, j int) bool {
+		si, sj := len(keyed[i].position.Stones), len(keyed[j].position.Stones)
+		if si != sj {
+			return si < sj
+		}
+		return keyed[i].layout < keyed[j].layout
+	})
+
+	positions := make([]BookPosition, len(keyed))
+	for i := range keyed {
+		positions[i] = keyed[i].position
+	}
+	if limit > 0 && len(positions) > limit {
+		positions = positions[:limit]
+	}
+	return positions
+}
+
+// Clear removes every position from the book
+func (b *OpeningBook) Clear() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.positions = make(map[uint64]*BookPosition)
+}
+
+// BoardFromMoves builds the board reached by playing the moves alternately, black first
+func (b *OpeningBook) BoardFromMoves(moves []model.Move) ([][]int, error) {
+	board := make([][]int, b.boardSize)
+	for i := range board {
+		board[i] = make([]int, b.boardSize)
+	}
+	for i, move := range moves {
+		if move.X < 0 || move.X >= b.boardSize || move.Y < 0 || move.Y >= b.boardSize {
+			return nil, fmt.Errorf("move %d (%d,%d) is off the board", i+1, move.X, move.Y)
+		}
+		if board[move.Y][move.X] != 0 {
+			return nil, fmt.Errorf("move %d (%d,%d) is on an occupied cell", i+1, move.X, move.Y)
+		}
+		board[move.Y][move.X] = i%2 + 1
+	}
+	return board, nil
+}
+
+// AddLine adds every position along the line with its next move, so the book follows the whole line
+func (b *OpeningBook) AddLine(moves []model.Move, weight int) error {
+	board, err := b.BoardFromMoves(moves)
+	if err != nil {
+		return err
+	}
+	for i := len(moves) - 1; i >= 0; i-- {
+		board[moves[i].Y][moves[i].X] = 0
+		if err := b.Add(board, moves[i], weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile imports an opening file, choosing the format from its extension (.lib is Renlib).
+// It returns the number of lines imported.
+func (b *OpeningBook) LoadFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	format := BookFormatGomocup
+	if strings.EqualFold(filepath.Ext(path), ".lib") {
+		format = BookFormatRenlib
+	}
+	return b.Load(file, format)
+}
+
+// Load imports openings in the given format and returns the number of lines imported
+func (b *OpeningBook) Load(r io.Reader, format string) (int, error) {
+	switch format {
+	case BookFormatGomocup:
+		return b.loadGomocup(r)
+	case BookFormatRenlib:
+		return b.loadRenlib(r)
+	default:
+		return 0, fmt.Errorf("unsupported opening book format: %s", format)
+	}
+}
+
+// loadGomocup reads a Gomocup opening list. Each line holds comma-separated x,y pairs
+// relative to the board centre, played alternately from black; blank lines and lines
+// starting with '#' or '//' are skipped.
+func (b *OpeningBook) loadGomocup(r io.Reader) (int, error) {
+	return scanGomocupLines(r, b.boardSize, func(moves []model.Move) error {
+		return b.AddLine(moves, 1)
+	})
+}
+
+// scanGomocupLines parses Gomocup opening lines of x,y pairs relative to the centre and
+// passes each line's moves to fn. It returns the number of lines read.
+func scanGomocupLines(r io.Reader, boardSize int, fn func(moves []model.Move) error) (int, error) {
+	center := boardSize / 2
+	scanner := bufio.NewScanner(r)
+	lines := 0
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+		if len(fields)%2 != 0 {
+			return lines, fmt.Errorf("line %d: odd number of coordinates", lineNumber)
+		}
+
+		moves := make([]model.Move, 0, len(fields)/2)
+		for i := 0; i < len(fields); i += 2 {
+			dx, errX := strconv.Atoi(fields[i])
+			dy, errY := strconv.Atoi(fields[i+1])
+			if errX != nil || errY != nil {
+				return lines, fmt.Errorf("line %d: invalid coordinate", lineNumber)
+			}
+			moves = append(moves, model.Move{X: center + dx, Y: center + dy})
+		}
+
+		if err := fn(moves); err != nil {
+			return lines, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		lines++
+	}
+
+	return lines, scanner.Err()
+}
+
+// Renlib node flags
+const (
+	renlibDown       = 0x80 // The node has a child, stored next
+	renlibRight      = 0x40 // The node has a sibling, stored after its subtree
+	renlibOldComment = 0x20 // A zero-terminated comment follows
+	renlibComment    = 0x08 // A zero-terminated comment follows
+	renlibHeaderSize = 20
+)
+
+// renlibMagic starts every Renlib file
+var renlibMagic = []byte{0xFF, 'R', 'e', 'n', 'L', 'i', 'b', 0xFF}
+
+// loadRenlib reads a Renlib library. After a 20-byte header the file is a pre-order move tree
+// of 2-byte nodes: the position byte ((y << 4) | (x + 1), 0 for no move) and a flag byte.
+// Every root-to-leaf path is imported as one line.
+func (b *OpeningBook) loadRenlib(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < renlibHeaderSize || !bytes.Equal(data[:len(renlibMagic)], renlibMagic) {
+		return 0, errors.New("not a Renlib file")
+	}
+
+	var path []model.Move
+	var branches []int // Path lengths where pending siblings attach
+	lines := 0
+
+	for pos := renlibHeaderSize; pos+1 < len(data); {
+		cell, flags := data[pos], data[pos+1]
+		pos += 2
+
+		// Skip comments attached to the node
+		if flags&(renlibComment|renlibOldComment) != 0 {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return lines, errors.New("unterminated Renlib comment")
+			}
+			pos += end + 1
+			if (end+1)%2 != 0 {
+				pos++
+			}
+		}
+
+		if flags&renlibRight != 0 {
+			branches = append(branches, len(path))
+		}
+		if cell != 0 {
+			path = append(path, model.Move{X: int(cell&0x0F) - 1, Y: int(cell >> 4)})
+		}
+
+		if flags&renlibDown != 0 {
+			continue
+		}
+
+		// Leaf: import the line and return to the most recent pending sibling
+		if len(path) > 0 {
+			if err := b.AddLine(path, 1); err != nil {
+				return lines, err
+			}
+			lines++
+		}
+		if len(branches) == 0 {
+			break
+		}
+		path = path[:branches[len(branches)-1]]
+		branches = branches[:len(branches)-1]
+	}
+
+	return lines, nil
+}
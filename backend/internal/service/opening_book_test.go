@@ -0,0 +1,173 @@
+// Unit tests for the opening book
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestOpeningBookSymmetry(t *testing.T) {
+	book := NewOpeningBook()
+
+	// Black in the centre, white directly above; book the diagonal third move
+	board := createCenterBoard()
+	board[6][7] = 2
+	if err := book.Add(board, model.Move{X: 8, Y: 6}, 1); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// The same shape rotated a quarter turn: white to the right of the centre
+	rotated := createCenterBoard()
+	rotated[7][8] = 2
+	moves := book.Lookup(rotated)
+	if len(moves) != 1 {
+		t.Fatalf("Expected one book move for the rotated position, got %d", len(moves))
+	}
+
+	// The booked move must keep its shape: diagonally adjacent to both stones
+	move := moves[0]
+	if rotated[move.Y][move.X] != 0 || abs(move.X-8) > 1 || abs(move.Y-7) > 1 || abs(move.X-7) > 1 || abs(move.Y-7) > 1 {
+		t.Errorf("Rotated book move (%d,%d) does not match the booked shape", move.X, move.Y)
+	}
+	if move.X == 7 || move.Y == 7 {
+		t.Errorf("Expected a diagonal third move, got (%d,%d)", move.X, move.Y)
+	}
+
+	if book.Len() != 1 {
+		t.Errorf("Expected one position stored, got %d", book.Len())
+	}
+}
+
+func TestOpeningBookWeightedPick(t *testing.T) {
+	book := NewOpeningBook()
+	board := createCenterBoard()
+	book.Add(board, model.Move{X: 7, Y: 6}, 9)
+	book.Add(board, model.Move{X: 8, Y: 6}, 1)
+
+	counts := map[bool]int{}
+	for i := 0; i < 1000; i++ {
+		move, ok := book.Pick(board, 2)
+		if !ok {
+			t.Fatal("Expected a book move")
+		}
+		counts[move.X == move.Y || move.X+move.Y == 14]++ // Diagonal replies
+	}
+
+	if counts[false] < 800 || counts[true] < 40 {
+		t.Errorf("Expected roughly 9:1 direct:diagonal picks, got %d:%d", counts[false], counts[true])
+	}
+
+	// Black is not to move here
+	if _, ok := book.Pick(board, 1); ok {
+		t.Error("Expected no book move for the side not to move")
+	}
+}
+
+func TestOpeningBookRemove(t *testing.T) {
+	book := NewDefaultOpeningBook()
+	board := createEmptyBoard()
+
+	if moves := book.Lookup(board); len(moves) != 1 || moves[0].X != 7 || moves[0].Y != 7 {
+		t.Fatalf("Expected the default book to open in the centre, got %v", moves)
+	}
+	if !book.Remove(board, model.Move{X: 7, Y: 7}) {
+		t.Fatal("Expected the centre move to be removed")
+	}
+	if moves := book.Lookup(board); len(moves) != 0 {
+		t.Errorf("Expected no moves after removal, got %v", moves)
+	}
+	if book.Remove(board, model.Move{X: -1, Y: -1}) {
+		t.Error("Expected removing a missing position to fail")
+	}
+}
+
+func TestOpeningBookLoadGomocup(t *testing.T) {
+	book := NewOpeningBook()
+	input := `# two openings
+0,0, 1,0, 0,1
+0, 0, -1, -1
+`
+	lines, err := book.Load(strings.NewReader(input), BookFormatGomocup)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+
+	// Both lines start in the centre, so the empty board has one move with weight 2
+	moves := book.Lookup(createEmptyBoard())
+	if len(moves) != 1 || moves[0].Weight != 2 {
+		t.Errorf("Expected the centre with weight 2, got %v", moves)
+	}
+
+	// The reply to the centre comes from both lines
+	if moves := book.Lookup(createCenterBoard()); len(moves) != 2 {
+		t.Errorf("Expected 2 replies to the centre, got %v", moves)
+	}
+
+	if _, err := book.Load(strings.NewReader("0,0, 1"), BookFormatGomocup); err == nil {
+		t.Error("Expected an error for an odd number of coordinates")
+	}
+}
+
+func TestOpeningBookLoadRenlib(t *testing.T) {
+	// Tree: h8 -> (i8 -> i7) | (i9) ; cells are (y << 4) | (x + 1)
+	cell := func(x, y int) byte { return byte(y<<4 | (x + 1)) }
+	var data bytes.Buffer
+	data.Write(renlibMagic)
+	data.Write(bytes.Repeat([]byte{0xFF}, renlibHeaderSize-len(renlibMagic)))
+	data.Write([]byte{cell(7, 7), renlibDown})
+	data.Write([]byte{cell(8, 7), renlibDown | renlibRight | renlibComment})
+	data.WriteString("main line\x00")
+	data.Write([]byte{cell(8, 6), 0})
+	data.Write([]byte{cell(8, 8), 0})
+
+	book := NewOpeningBook()
+	lines, err := book.Load(&data, BookFormatRenlib)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+
+	if moves := book.Lookup(createCenterBoard()); len(moves) != 2 {
+		t.Errorf("Expected 2 replies to the centre, got %v", moves)
+	}
+
+	board := createCenterBoard()
+	board[7][8] = 2
+	if moves := book.Lookup(board); len(moves) != 1 {
+		t.Errorf("Expected 1 third move after the direct reply, got %v", moves)
+	}
+
+	if _, err := book.Load(strings.NewReader("not a library"), BookFormatRenlib); err == nil {
+		t.Error("Expected an error for a file without the Renlib header")
+	}
+}
+
+func TestGetAIMove_OpeningBook(t *testing.T) {
+	ai := NewEnhancedAIService()
+	ai.SetOpeningBook(NewDefaultOpeningBook())
+
+	move := ai.GetAIMove(createCenterBoard(), model.Move{X: 7, Y: 7}, Hard)
+	if !move.FromBook {
+		t.Fatal("Expected the reply to the centre to come from the book")
+	}
+	if abs(move.X-7) > 1 || abs(move.Y-7) > 1 {
+		t.Errorf("Expected a book move next to the centre, got (%d,%d)", move.X, move.Y)
+	}
+	if stats := ai.GetStats(); stats["nodes_searched"].(uint64) != 0 {
+		t.Errorf("Expected no search for a book move, got %v nodes", stats["nodes_searched"])
+	}
+
+	// Out of book the engine searches as usual
+	move = ai.GetAIMove(createComplexBoard(), model.Move{X: 7, Y: 7}, Medium)
+	if move.FromBook {
+		t.Error("Expected a searched move outside the book")
+	}
+}
@@ -0,0 +1,400 @@
+// Package service contains the pattern-table evaluator used by the enhanced AI
+// This file implements line-shape classification through a precomputed lookup table
+// and an incrementally updated board evaluation
+package service
+
+// Shape classifies what a stone forms along a single line
+type Shape uint8
+
+const (
+	ShapeNone        Shape = iota
+	ShapeClosedTwo         // 眠二: can become a closed three
+	ShapeOpenTwo           // 活二: can become an open three
+	ShapeClosedThree       // 眠三: can become a four, but not an open four
+	ShapeBrokenThree       // 跳活三: split open three such as _XX_X_
+	ShapeOpenThree         // 活三: contiguous open three such as __XXX_
+	ShapeBrokenFour        // 跳冲四: split four such as X_XXX or XX_XX
+	ShapeFour              // 冲四: contiguous four with one open end
+	ShapeOpenFour          // 活四: two distinct completion points
+	ShapeFive              // 五连 (overlines count as five)
+	shapeCount
+)
+
+// shapeNames are used for logging and API output
+var shapeNames = [shapeCount]string{
+	"none", "closed_two", "open_two", "closed_three", "broken_three",
+	"open_three", "broken_four", "four", "open_four", "five",
+}
+
+// String returns the shape name
+func (s Shape) String() string {
+	if s < shapeCount {
+		return shapeNames[s]
+	}
+	return "unknown"
+}
+
+// IsFour reports whether the shape threatens to make five on the next move
+func (s Shape) IsFour() bool {
+	return s == ShapeFour || s == ShapeBrokenFour || s == ShapeOpenFour
+}
+
+// IsOpenThree reports whether the shape can become an open four on the next move
+func (s Shape) IsOpenThree() bool {
+	return s == ShapeOpenThree || s == ShapeBrokenThree
+}
+
+const (
+	// winScore is the score of a completed five
+	winScore = 100000
+
+	// patternReach is how many cells each side of the centre a line window covers
+	patternReach = 4
+
+	// cellWall marks an off-board cell inside a line window
+	cellWall = 3
+
+	// tempoBonus is the value of having the move in a quiet position
+	tempoBonus = 150
+)
+
+// directions are the four line directions: horizontal, vertical, diagonal \ and diagonal /
+var directions = [4][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
+
+// shapeScores are the per-stone, per-direction scores of each shape. They are the default
+// evaluation weights and also rank moves during move ordering.
+var shapeScores = [shapeCount]int{
+	ShapeNone:        0,
+	ShapeClosedTwo:   10,
+	ShapeOpenTwo:     50,
+	ShapeClosedThree: 100,
+	ShapeBrokenThree: 800,
+	ShapeOpenThree:   1000,
+	ShapeBrokenFour:  1000,
+	ShapeFour:        1000,
+	ShapeOpenFour:    10000,
+	ShapeFive:        winScore,
+}
+
+// shapeTable maps [player-1][window key] to the shape the player forms at the window centre.
+// A window key packs the 8 neighbours (4 on each side) of a cell along one direction,
+// 2 bits each: 0 empty, 1 black, 2 white, 3 wall.
+var shapeTable = buildShapeTable()
+
+// windowSlot returns the bit offset of the neighbour at the given signed distance from the centre
+func windowSlot(offset int) uint {
+	if offset < 0 {
+		return uint(offset+patternReach) * 2
+	}
+	return uint(offset+patternReach-1) * 2
+}
+
+// buildShapeTable classifies every possible window for both players
+func buildShapeTable() *[2][1 << 16]Shape {
+	table := new([2][1 << 16]Shape)
+	for player := 1; player <= 2; player++ {
+		done := make([]bool, 1<<16)
+		for key := 0; key < 1<<16; key++ {
+			classifyWindow(table, done, player, uint16(key))
+		}
+	}
+	return table
+}
+
+// classifyWindow computes (and memoises) the shape for one window key
+func classifyWindow(table *[2][1 << 16]Shape, done []bool, player int, key uint16) Shape {
+	if done[key] {
+		return table[player-1][key]
+	}
+
+	var cells [2*patternReach + 1]int
+	for offset := -patternReach; offset <= patternReach; offset++ {
+		if offset == 0 {
+			cells[patternReach] = player
+			continue
+		}
+		cells[offset+patternReach] = int(key>>windowSlot(offset)) & 3
+	}
+
+	shape := ShapeNone
+	run := windowRun(cells, player)
+	if run >= 5 {
+		shape = ShapeFive
+	} else {
+		completions := 0
+		for i, cell := range cells {
+			if cell != 0 {
+				continue
+			}
+			cells[i] = player
+			if windowRun(cells, player) >= 5 {
+				completions++
+			}
+			cells[i] = 0
+		}
+
+		switch {
+		case completions >= 2:
+			shape = ShapeOpenFour
+		case completions == 1 && run == 4:
+			shape = ShapeFour
+		case completions == 1:
+			shape = ShapeBrokenFour
+		default:
+			// Grade by the best shape reachable with one more stone
+			var makesOpenFour, makesFour, makesOpenThree, makesClosedThree bool
+			for i, cell := range cells {
+				if cell != 0 {
+					continue
+				}
+				next := key | uint16(player)<<windowSlot(i-patternReach)
+				switch s := classifyWindow(table, done, player, next); {
+				case s == ShapeOpenFour:
+					makesOpenFour = true
+				case s.IsFour():
+					makesFour = true
+				case s.IsOpenThree():
+					makesOpenThree = true
+				case s == ShapeClosedThree:
+					makesClosedThree = true
+				}
+			}
+
+			switch {
+			case makesOpenFour && run == 3:
+				shape = ShapeOpenThree
+			case makesOpenFour:
+				shape = ShapeBrokenThree
+			case makesFour:
+				shape = ShapeClosedThree
+			case makesOpenThree:
+				shape = ShapeOpenTwo
+			case makesClosedThree:
+				shape = ShapeClosedTwo
+			}
+		}
+	}
+
+	table[player-1][key] = shape
+	done[key] = true
+	return shape
+}
+
+// windowRun counts the player's contiguous stones through the window centre
+func windowRun(cells [2*patternReach + 1]int, player int) int {
+	run := 1
+	for i := patternReach + 1; i < len(cells) && cells[i] == player; i++ {
+		run++
+	}
+	for i := patternReach - 1; i >= 0 && cells[i] == player; i-- {
+		run++
+	}
+	return run
+}
+
+// patternBoard keeps line-window keys and evaluation terms up to date as stones are placed
+type patternBoard struct {
+	size       int
+	cells      []int
+	keys       [][4]uint16
+	centrality [3]int
+	counts     [3][shapeCount]int
+	hash       uint64
+	stones     int
+	weights    *evalTable
+	nnue       *nnueAccumulator // Neural evaluation replacing the weights when set
+}
+
+// newPatternBoard builds a pattern board from a grid
+func newPatternBoard(board [][]int) *patternBoard {
+	size := len(board)
+	pb := &patternBoard{
+		size:    size,
+		cells:   make([]int, size*size),
+		keys:    make([][4]uint16, size*size),
+		weights: defaultEvalTable,
+	}
+
+	// Mark off-board neighbours as walls
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			idx := y*size + x
+			for d, dir := range directions {
+				for offset := -patternReach; offset <= patternReach; offset++ {
+					if offset == 0 {
+						continue
+					}
+					if !pb.inside(x+dir[0]*offset, y+dir[1]*offset) {
+						pb.keys[idx][d] |= cellWall << windowSlot(offset)
+					}
+				}
+			}
+		}
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if board[y][x] != 0 {
+				pb.place(x, y, board[y][x])
+			}
+		}
+	}
+
+	return pb
+}
+
+// inside reports whether (x, y) is on the board
+func (pb *patternBoard) inside(x, y int) bool {
+	return x >= 0 && x < pb.size && y >= 0 && y < pb.size
+}
+
+// at returns the stone at (x, y)
+func (pb *patternBoard) at(x, y int) int {
+	return pb.cells[y*pb.size+x]
+}
+
+// shape returns the shape the player forms (or would form, on an empty cell) at (x, y) in direction d
+func (pb *patternBoard) shape(x, y, d, player int) Shape {
+	return shapeTable[player-1][pb.keys[y*pb.size+x][d]]
+}
+
+// place puts a stone on an empty cell and updates the evaluation incrementally
+func (pb *patternBoard) place(x, y, player int) {
+	idx := y*pb.size + x
+	pb.cells[idx] = player
+	pb.hash ^= zobristKeys[player][idx]
+	pb.stones++
+	pb.centrality[player] += centrality(x, y, pb.size)
+	if pb.nnue != nil {
+		pb.nnue.addStone(player, idx, 1)
+	}
+	for d := range directions {
+		pb.account(player, shapeTable[player-1][pb.keys[idx][d]], 1)
+	}
+	pb.updateNeighbours(x, y, uint16(player))
+}
+
+// remove takes a stone off the board and updates the evaluation incrementally
+func (pb *patternBoard) remove(x, y int) {
+	idx := y*pb.size + x
+	player := pb.cells[idx]
+	for d := range directions {
+		pb.account(player, shapeTable[player-1][pb.keys[idx][d]], -1)
+	}
+	if pb.nnue != nil {
+		pb.nnue.addStone(player, idx, -1)
+	}
+	pb.centrality[player] -= centrality(x, y, pb.size)
+	pb.cells[idx] = 0
+	pb.hash ^= zobristKeys[player][idx]
+	pb.stones--
+	pb.updateNeighbours(x, y, 0)
+}
+
+// updateNeighbours rewrites the window slot for (x, y) in every cell that can see it
+func (pb *patternBoard) updateNeighbours(x, y int, code uint16) {
+	for d, dir := range directions {
+		for offset := -patternReach; offset <= patternReach; offset++ {
+			nx, ny := x+dir[0]*offset, y+dir[1]*offset
+			if offset == 0 || !pb.inside(nx, ny) {
+				continue
+			}
+			idx := ny*pb.size + nx
+			// (x, y) sits at -offset from the neighbour's point of view
+			slot := windowSlot(-offset)
+			before := pb.keys[idx][d]
+			pb.keys[idx][d] = before&^(3<<slot) | code<<slot
+
+			// Only a neighbouring stone whose shape changed alters the evaluation
+			if owner := pb.cells[idx]; owner != 0 {
+				old, shape := shapeTable[owner-1][before], shapeTable[owner-1][pb.keys[idx][d]]
+				if old != shape {
+					pb.account(owner, old, -1)
+					pb.account(owner, shape, 1)
+				}
+			}
+		}
+	}
+}
+
+// account adds (sign=1) or removes (sign=-1) one stone's shape along one direction
+func (pb *patternBoard) account(player int, shape Shape, sign int) {
+	pb.counts[player][shape] += sign
+	if pb.nnue != nil {
+		pb.nnue.addShape(player, shape, sign)
+	}
+}
+
+// isFive reports whether the stone at (x, y) completes five in any direction
+func (pb *patternBoard) isFive(x, y int) bool {
+	player := pb.at(x, y)
+	if player == 0 {
+		return false
+	}
+	for d := range directions {
+		if pb.shape(x, y, d, player) == ShapeFive {
+			return true
+		}
+	}
+	return false
+}
+
+// isFull reports whether every cell is occupied
+func (pb *patternBoard) isFull() bool {
+	return pb.stones == pb.size*pb.size
+}
+
+// hasFour reports whether the player has a stone that is one move away from five
+func (pb *patternBoard) hasFour(player int) bool {
+	c := &pb.counts[player]
+	return c[ShapeFour]+c[ShapeBrokenFour]+c[ShapeOpenFour] > 0
+}
+
+// evaluate scores the position from the point of view of the side to move
+func (pb *patternBoard) evaluate(toMove int) int {
+	if score, forced := pb.forcedScore(toMove); forced {
+		return score
+	}
+	if pb.nnue != nil {
+		return pb.nnue.evaluate(toMove)
+	}
+
+	w := pb.weights
+	opponent := 3 - toMove
+	own, other := w.center*pb.centrality[toMove], w.center*pb.centrality[opponent]
+	for s := ShapeClosedTwo; s < shapeCount; s++ {
+		own += w.shapes[s] * pb.counts[toMove][s]
+		other += w.shapes[s] * pb.counts[opponent][s]
+	}
+	score := w.tempo + w.scale(toMove, own) - w.scale(opponent, other)
+	if pb.hasThreeThreat(toMove) {
+		score += w.threeThreat
+	}
+	return score
+}
+
+// forcedScore returns the score of positions decided by an existing four
+func (pb *patternBoard) forcedScore(toMove int) (int, bool) {
+	// The side to move completes any existing four immediately
+	if pb.hasFour(toMove) {
+		return winScore - 1, true
+	}
+	// An opponent open four cannot be blocked at both ends
+	if pb.counts[3-toMove][ShapeOpenFour] > 0 {
+		return -(winScore - 2), true
+	}
+	return 0, false
+}
+
+// hasThreeThreat reports whether the side to move has an open three that becomes an
+// unanswerable open four, because the opponent has no four to interpose
+func (pb *patternBoard) hasThreeThreat(toMove int) bool {
+	return !pb.hasFour(3-toMove) &&
+		pb.counts[toMove][ShapeOpenThree]+pb.counts[toMove][ShapeBrokenThree] > 0
+}
+
+// centrality counts the rings between a cell and the edge, preferring stones near the middle
+func centrality(x, y, size int) int {
+	center := size / 2
+	return center - max(abs(x-center), abs(y-center))
+}
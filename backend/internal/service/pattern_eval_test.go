@@ -0,0 +1,146 @@
+// Unit tests for the pattern-table evaluator
+package service
+
+import (
+	"testing"
+)
+
+// lineBoard places a horizontal pattern on row 7 starting at column 3.
+// 'X' is a stone of the player under test, 'O' an opponent stone, '_' empty.
+func lineBoard(pattern string, player int) [][]int {
+	board := createEmptyBoard()
+	for i, c := range pattern {
+		switch c {
+		case 'X':
+			board[7][3+i] = player
+		case 'O':
+			board[7][3+i] = 3 - player
+		}
+	}
+	return board
+}
+
+func TestShapeClassification(t *testing.T) {
+	tests := []struct {
+		pattern string
+		col     int // column within the pattern to classify
+		want    Shape
+	}{
+		{"_XXXXX_", 1, ShapeFive},
+		{"_XXXX_", 1, ShapeOpenFour},
+		{"OXXXX_", 1, ShapeFour},
+		{"_XXXXO", 4, ShapeFour},
+		{"_X_XXX_", 1, ShapeBrokenFour},
+		{"_XX_XX_", 2, ShapeBrokenFour},
+		{"__XXX__", 3, ShapeOpenThree},
+		{"_X_XX_", 1, ShapeBrokenThree},
+		{"_XX_X_", 4, ShapeBrokenThree},
+		{"OXXX__", 2, ShapeClosedThree},
+		{"OXX_X_", 1, ShapeClosedThree},
+		{"OXXX_O", 1, ShapeNone},
+		{"__XX__", 2, ShapeOpenTwo},
+		{"__X_X__", 2, ShapeOpenTwo},
+		{"OXX___", 1, ShapeClosedTwo},
+		{"__X___", 2, ShapeNone},
+	}
+
+	for _, player := range []int{1, 2} {
+		for _, test := range tests {
+			t.Run(test.pattern, func(t *testing.T) {
+				pb := newPatternBoard(lineBoard(test.pattern, player))
+				got := pb.shape(3+test.col, 7, 0, player)
+				if got != test.want {
+					t.Errorf("player %d: expected %v, got %v", player, test.want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestShapeAtBoardEdge(t *testing.T) {
+	board := createEmptyBoard()
+	for x := 0; x < 4; x++ {
+		board[7][x] = 2
+	}
+
+	pb := newPatternBoard(board)
+	if got := pb.shape(0, 7, 0, 2); got != ShapeFour {
+		t.Errorf("expected four against the edge, got %v", got)
+	}
+
+	// A three against the edge can only become a closed four
+	pb.remove(3, 7)
+	if got := pb.shape(0, 7, 0, 2); got != ShapeClosedThree {
+		t.Errorf("expected closed three against the edge, got %v", got)
+	}
+}
+
+func TestShapeOnEmptyCell(t *testing.T) {
+	pb := newPatternBoard(lineBoard("_XX_XX_", 1))
+
+	// Filling the gap completes five for the owner and blocks it for the opponent
+	if got := pb.shape(6, 7, 0, 1); got != ShapeFive {
+		t.Errorf("expected five when filling the gap, got %v", got)
+	}
+	if got := pb.shape(6, 7, 0, 2); got != ShapeNone {
+		t.Errorf("expected no shape for the opponent, got %v", got)
+	}
+}
+
+func TestPatternBoardIncremental(t *testing.T) {
+	board := createComplexBoard()
+	pb := newPatternBoard(board)
+
+	moves := [][3]int{{7, 9, 2}, {6, 9, 1}, {9, 6, 2}, {12, 12, 1}, {0, 0, 2}}
+	for _, m := range moves {
+		pb.place(m[0], m[1], m[2])
+		board[m[1]][m[0]] = m[2]
+
+		fresh := newPatternBoard(board)
+		if pb.centrality != fresh.centrality || pb.counts != fresh.counts {
+			t.Fatalf("incremental state diverged after placing (%d,%d)", m[0], m[1])
+		}
+	}
+
+	for i := len(moves) - 1; i >= 0; i-- {
+		m := moves[i]
+		pb.remove(m[0], m[1])
+		board[m[1]][m[0]] = 0
+	}
+
+	fresh := newPatternBoard(createComplexBoard())
+	if pb.centrality != fresh.centrality || pb.counts != fresh.counts || pb.keys[7*15+7] != fresh.keys[7*15+7] {
+		t.Error("incremental state diverged after undoing all moves")
+	}
+}
+
+func TestPatternEvaluate(t *testing.T) {
+	// Side to move with a four wins immediately
+	pb := newPatternBoard(lineBoard("OXXXX_", 2))
+	if score := pb.evaluate(2); score < winScore-1 {
+		t.Errorf("expected winning score for side with a four, got %d", score)
+	}
+
+	// Facing an open four without a four of one's own is lost
+	pb = newPatternBoard(lineBoard("_XXXX_", 1))
+	if score := pb.evaluate(2); score > -(winScore - 2) {
+		t.Errorf("expected losing score against an open four, got %d", score)
+	}
+
+	// An open three outweighs a scattered position
+	pb = newPatternBoard(lineBoard("__XXX__", 2))
+	if pb.evaluate(2) <= pb.evaluate(1) {
+		t.Errorf("expected open three to favour its owner")
+	}
+}
+
+func BenchmarkPatternBoardPlaceRemove(b *testing.B) {
+	pb := newPatternBoard(createComplexBoard())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pb.place(12, 3, 2)
+		pb.evaluate(1)
+		pb.remove(12, 3)
+	}
+}
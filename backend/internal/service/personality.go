@@ -0,0 +1,192 @@
+// Package service contains AI playing-style personalities
+// This file defines named styles that reweight attack against defence in the evaluation,
+// bias move ordering and play from their own opening repertoire
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"gomoku-backend/internal/model"
+)
+
+// Personality is a named playing style for the minimax engine
+type Personality struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Attack and Defence weigh the engine's own and the opponent's material in percent
+	Attack  int `json:"attack"`
+	Defence int `json:"defence"`
+	// OrderAttack and OrderDefence weigh the shapes a move forms for each side when ordering
+	// moves, in percent; the neutral style uses 100 and 50. OrderBroken is added per broken
+	// three or four the move forms.
+	OrderAttack  int         `json:"orderAttack"`
+	OrderDefence int         `json:"orderDefence"`
+	OrderBroken  int         `json:"orderBroken"`
+	Weights      EvalWeights `json:"weights"`
+	// Repertoire lists opening lines as offsets from the centre, black first
+	Repertoire [][][2]int `json:"repertoire"`
+}
+
+// personalities are the built-in styles
+var personalities = []Personality{
+	{
+		Name:         "attacker",
+		Description:  "Aggressive attacker: builds its own threats and answers yours only when it must",
+		Attack:       130,
+		Defence:      75,
+		OrderAttack:  150,
+		OrderDefence: 25,
+		Weights:      DefaultEvalWeights(),
+		Repertoire: [][][2]int{
+			{{0, 0}, {0, -1}, {1, -1}, {-1, 1}},
+			{{0, 0}, {1, -1}, {1, 0}, {-1, 0}},
+			{{0, 0}, {0, -1}, {1, 0}},
+		},
+	},
+	{
+		Name:         "defender",
+		Description:  "Solid defender: shuts down your threats first and waits for mistakes",
+		Attack:       85,
+		Defence:      130,
+		OrderAttack:  70,
+		OrderDefence: 120,
+		Weights:      DefaultEvalWeights(),
+		Repertoire: [][][2]int{
+			{{0, 0}, {0, -1}, {-1, 1}},
+			{{0, 0}, {1, -1}, {0, -1}},
+			{{0, 0}, {1, -1}, {-1, -1}},
+		},
+	},
+	{
+		Name:         "trickster",
+		Description:  "Trickster: favours broken shapes that are easy to overlook",
+		Attack:       110,
+		Defence:      95,
+		OrderAttack:  100,
+		OrderDefence: 50,
+		OrderBroken:  600,
+		Weights:      tricksterWeights(),
+		Repertoire: [][][2]int{
+			{{0, 0}, {1, -1}, {-1, -1}, {2, 0}},
+			{{0, 0}, {0, -1}, {2, 0}},
+			{{0, 0}, {2, -2}},
+		},
+	},
+}
+
+// tricksterWeights value broken shapes like their solid counterparts
+func tricksterWeights() EvalWeights {
+	weights := DefaultEvalWeights()
+	weights.Name = "trickster"
+	weights.BrokenThree = weights.OpenThree + 200
+	weights.BrokenFour = weights.Four + 200
+	return weights
+}
+
+// Personalities returns the built-in styles
+func Personalities() []Personality {
+	return append([]Personality(nil), personalities...)
+}
+
+// GetPersonality returns the style with the given name
+func GetPersonality(name string) (Personality, bool) {
+	for _, personality := range personalities {
+		if personality.Name == name {
+			return personality, true
+		}
+	}
+	return Personality{}, false
+}
+
+// Validate checks the percentages, weights and repertoire
+func (p Personality) Validate() error {
+	switch {
+	case p.Name == "":
+		return errors.New("personality needs a name")
+	case p.Attack < 1 || p.Attack > 400 || p.Defence < 1 || p.Defence > 400:
+		return errors.New("attack and defence must be between 1 and 400")
+	case p.OrderAttack < 0 || p.OrderDefence < 0 || p.OrderBroken < 0:
+		return errors.New("move ordering weights must not be negative")
+	}
+	if err := p.Weights.Validate(); err != nil {
+		return err
+	}
+	book := NewOpeningBook()
+	for _, line := range p.Repertoire {
+		if _, err := book.BoardFromMoves(repertoireMoves(book, line)); err != nil {
+			return fmt.Errorf("repertoire: %w", err)
+		}
+	}
+	return nil
+}
+
+// table compiles the evaluation for the engine playing player
+func (p Personality) table(player int) *evalTable {
+	t := p.Weights.table()
+	t.self, t.attack, t.defence = player, p.Attack, p.Defence
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d,%d,%d", player, p.Attack, p.Defence)
+	t.salt ^= h.Sum64()
+	return t
+}
+
+// OpeningBook returns a book holding the repertoire lines
+func (p Personality) OpeningBook() *OpeningBook {
+	book := NewOpeningBook()
+	for _, line := range p.Repertoire {
+		book.AddLine(repertoireMoves(book, line), 1)
+	}
+	return book
+}
+
+// repertoireMoves places a line given as offsets from the centre on the board
+func repertoireMoves(book *OpeningBook, line [][2]int) []model.Move {
+	center := book.boardSize / 2
+	moves := make([]model.Move, len(line))
+	for i, offset := range line {
+		moves[i] = model.Move{X: center + offset[0], Y: center + offset[1], Player: i%2 + 1}
+	}
+	return moves
+}
+
+// NewPersonalityAI creates a minimax engine playing in the personality's style from its own
+// repertoire
+func NewPersonalityAI(personality Personality) (*EnhancedAIService, error) {
+	ai := NewEnhancedAIService()
+	if err := ai.SetPersonality(&personality); err != nil {
+		return nil, err
+	}
+	ai.SetOpeningBook(personality.OpeningBook())
+	return ai, nil
+}
+
+// personalityEngines holds one shared engine per built-in style, created on first use
+var personalityEngines = struct {
+	mutex   sync.Mutex
+	engines map[string]*EnhancedAIService
+}{engines: make(map[string]*EnhancedAIService)}
+
+// PersonalityAI returns the shared engine of the built-in style with the given name
+func PersonalityAI(name string) (*EnhancedAIService, bool) {
+	personality, exists := GetPersonality(name)
+	if !exists {
+		return nil, false
+	}
+
+	personalityEngines.mutex.Lock()
+	defer personalityEngines.mutex.Unlock()
+
+	if ai, ok := personalityEngines.engines[name]; ok {
+		return ai, true
+	}
+	ai, err := NewPersonalityAI(personality)
+	if err != nil {
+		return nil, false
+	}
+	personalityEngines.engines[name] = ai
+	return ai, true
+}
@@ -0,0 +1,172 @@
+// Unit tests for AI playing-style personalities
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestPersonalities(t *testing.T) {
+	names := make(map[string]bool)
+	for _, personality := range Personalities() {
+		if err := personality.Validate(); err != nil {
+			t.Errorf("Personality %s: %v", personality.Name, err)
+		}
+		if names[personality.Name] {
+			t.Errorf("Personality %s listed twice", personality.Name)
+		}
+		names[personality.Name] = true
+	}
+	for _, name := range []string{"attacker", "defender", "trickster"} {
+		if !names[name] {
+			t.Errorf("Expected the %s personality", name)
+		}
+	}
+	if _, ok := GetPersonality("berserker"); ok {
+		t.Error("Expected an unknown personality to be rejected")
+	}
+
+	invalid, _ := GetPersonality("attacker")
+	invalid.Repertoire = [][][2]int{{{0, 0}, {0, 0}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected a repertoire line on an occupied cell to be rejected")
+	}
+}
+
+func TestPersonalityEvaluation(t *testing.T) {
+	// Black has an open three and nothing else is on the board
+	board := lineBoard("_XXX_", 1)
+	attacker, _ := GetPersonality("attacker")
+	defender, _ := GetPersonality("defender")
+
+	evaluate := func(table *evalTable, toMove int) int {
+		pb := newPatternBoard(board)
+		pb.weights = table
+		return pb.evaluate(toMove)
+	}
+
+	neutral := evaluate(defaultEvalTable, 1)
+	if got := evaluate(attacker.table(1), 1); got <= neutral {
+		t.Errorf("Expected the attacker to value its own three above %d, got %d", neutral, got)
+	}
+	if got := evaluate(defender.table(1), 1); got >= neutral {
+		t.Errorf("Expected the defender to value its own three below %d, got %d", neutral, got)
+	}
+
+	// Seen by white the same three is the opponent's: the defender fears it more
+	neutral = evaluate(defaultEvalTable, 2)
+	if got := evaluate(defender.table(2), 2); got >= neutral {
+		t.Errorf("Expected the defender to fear the opponent's three more than %d, got %d", neutral, got)
+	}
+	if got := evaluate(attacker.table(2), 2); got <= neutral {
+		t.Errorf("Expected the attacker to fear the opponent's three less than %d, got %d", neutral, got)
+	}
+
+	if attacker.table(1).salt == attacker.table(2).salt || attacker.table(1).salt == defaultEvalTable.salt {
+		t.Error("Expected each side of a personality to key its own transposition entries")
+	}
+}
+
+func TestPersonalityAIPlaysRepertoire(t *testing.T) {
+	ai, ok := PersonalityAI("defender")
+	if !ok {
+		t.Fatal("Expected the defender engine")
+	}
+	if again, _ := PersonalityAI("defender"); again != ai {
+		t.Error("Expected the engine to be shared")
+	}
+	if ai.Personality() == nil || ai.Personality().Name != "defender" {
+		t.Error("Expected the engine to play in the defender's style")
+	}
+
+	board := createEmptyBoard()
+	board[7][7] = 1
+	position := Position{Board: board, LastMove: model.Move{X: 7, Y: 7, Player: 1}, ToMove: 2}
+	move, err := ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Medium})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if !move.FromBook {
+		t.Errorf("Expected a repertoire reply, got (%d,%d)", move.X, move.Y)
+	}
+
+	// Out of book the personality still finds the win
+	board = lineBoard("_XXXX_", 1)
+	board[10][10], board[11][11], board[12][12] = 2, 2, 2
+	position = Position{Board: board, LastMove: model.Move{X: 12, Y: 12, Player: 2}, ToMove: 1}
+	move, _ = ai.BestMove(context.Background(), position, SearchLimits{Difficulty: Medium})
+	if move.Y != 7 || (move.X != 3 && move.X != 8) {
+		t.Errorf("Expected the five on row 7, got (%d,%d)", move.X, move.Y)
+	}
+}
+
+func TestGameServiceAISeat(t *testing.T) {
+	gs := NewGameService()
+	room, _ := gs.CreateRoom("ai room", "alice", 2)
+	human := room.Players[0]
+
+	if _, _, err := gs.AddAIPlayer(room.ID, "berserker", Medium); err == nil {
+		t.Error("Expected an unknown personality to be rejected")
+	}
+	_, seat, err := gs.AddAIPlayer(room.ID, "attacker", Medium)
+	if err != nil {
+		t.Fatalf("AddAIPlayer failed: %v", err)
+	}
+	if !seat.IsAI || !seat.IsReady || seat.PlayerNumber != 2 || seat.Personality != "attacker" {
+		t.Errorf("Expected a ready AI seat playing white, got %+v", seat)
+	}
+
+	gs.SetPlayerReady(room.ID, human.ID, true)
+	if err := gs.StartGame(room.ID); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	if _, move, _ := gs.PlayAIMove(context.Background(), room.ID); move != nil {
+		t.Error("Expected the AI to wait for black")
+	}
+
+	gs.MakeMove(room.ID, human.ID, 7, 7)
+	_, move, err := gs.PlayAIMove(context.Background(), room.ID)
+	if err != nil || move == nil {
+		t.Fatalf("Expected the AI to reply, got %v", err)
+	}
+	if move.PlayerID != seat.ID || room.Game.Board[move.Y][move.X] != 2 || room.Game.CurrentPlayer != human.ID {
+		t.Errorf("Expected a white move handing the turn back, got %+v", move)
+	}
+
+	// A room left to AI seats is closed
+	gs.LeaveRoom(room.ID, human.ID)
+	if gs.GetRoom(room.ID) != nil || len(gs.aiSeats) != 0 {
+		t.Error("Expected the room and its AI seat to be removed")
+	}
+}
+
+func TestHubPlaysOneAILoopPerRoom(t *testing.T) {
+	gs := NewGameService()
+	hub := NewHub(gs)
+	room, _ := gs.CreateRoom("ai room", "alice", 2)
+	human := room.Players[0]
+	gs.AddAIPlayer(room.ID, "attacker", Easy)
+	gs.SetPlayerReady(room.ID, human.ID, true)
+	gs.StartGame(room.ID)
+	gs.MakeMove(room.ID, human.ID, 7, 7)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.PlayAIMoves(room.ID)
+		}()
+	}
+	wg.Wait()
+
+	if moves := len(room.Game.Moves); moves != 2 || room.Game.CurrentPlayer != human.ID {
+		t.Errorf("Expected the AI to answer once, got %d moves", moves)
+	}
+	if len(hub.aiLoops) != 0 {
+		t.Errorf("Expected no AI loop left running, got %v", hub.aiLoops)
+	}
+}
@@ -0,0 +1,460 @@
+// Package service contains the "find the win" puzzle trainer
+// This file cuts forced-win positions out of finished games and engine self-play, rates
+// them by the length of their solution and checks a player's moves against the engine's
+// most stubborn defence.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gomoku-backend/internal/model"
+)
+
+// Puzzle attempt states
+const (
+	PuzzleContinue = "continue"
+	PuzzleSolved   = "solved"
+	PuzzleFailed   = "failed"
+	// PuzzleUndecided means the solver ran out of nodes checking a move, which proves
+	// neither a win nor a loss
+	PuzzleUndecided = "undecided"
+)
+
+const (
+	minPuzzleLength   = 3      // Attacker moves in the shortest puzzle, the five included
+	maxPuzzleLength   = 7      // ... in the longest
+	puzzleSolveNodes  = 20000  // Solver budget when cutting a puzzle out of a game
+	puzzleVerifyNodes = 200000 // Solver budget for checking each move of an attempt
+	maxPuzzles        = 1000
+	puzzleQueueSize   = 32
+)
+
+// ErrPuzzleNotFound is returned for an unknown puzzle ID
+var ErrPuzzleNotFound = errors.New("puzzle not found")
+
+// ErrPuzzleQueueFull is returned when too many games are waiting to be searched for puzzles
+var ErrPuzzleQueueFull = errors.New("too many games waiting for puzzle generation")
+
+// Puzzle is a position where the side to move has a forced win
+type Puzzle struct {
+	ID         string       `json:"id"`
+	Board      [][]int      `json:"board"`
+	ToMove     int          `json:"toMove"` // The side that wins
+	LastMove   model.Move   `json:"lastMove"`
+	Kind       string       `json:"kind"`   // vcf: fours only; vct: fours and open threes
+	Length     int          `json:"length"` // Moves of the side to move in the shortest win, the five included
+	Rating     int          `json:"rating"`
+	Source     string       `json:"source"` // selfplay, llm or pvp
+	GameID     string       `json:"gameId,omitempty"`
+	MoveNumber int          `json:"moveNumber"` // Moves played in the game before the puzzle
+	Solution   []model.Move `json:"-"`          // Shortest win against the most stubborn defence
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+// PuzzleAttempt is the verdict on a player's moves in a puzzle
+type PuzzleAttempt struct {
+	PuzzleID string       `json:"puzzleId"`
+	Status   string       `json:"status"`          // continue, solved, failed or undecided
+	Moves    []model.Move `json:"moves"`           // The player's moves and the engine's replies, in order
+	Reply    *model.Move  `json:"reply,omitempty"` // Engine's reply to the last move while the puzzle goes on
+	FailedAt int          `json:"failedAt"`        // 1-based index of the first wrong move; 0 if none
+	Message  string       `json:"message"`
+	Solution []model.Move `json:"solution,omitempty"` // Shown once the puzzle is solved or failed
+}
+
+// PuzzleFilter selects puzzles; zero fields match every puzzle
+type PuzzleFilter struct {
+	Kind      string
+	MinLength int
+	MaxLength int
+}
+
+// puzzleJob is a finished game to search for puzzles, or a request for a self-play game
+type puzzleJob struct {
+	game     ReviewedGame
+	selfPlay bool
+}
+
+// PuzzleService generates puzzles one game at a time in the background and serves them in
+// order of rating
+type PuzzleService struct {
+	ai      *EnhancedAIService
+	limits  SearchLimits
+	queue   chan puzzleJob
+	puzzles map[string]*Puzzle
+	order   []*Puzzle // By rating, then ID
+	created []string  // Puzzle IDs, oldest first
+	seen    map[uint64]bool
+	mutex   sync.RWMutex
+	// verifyNodes is the solver budget for each move of an attempt
+	verifyNodes int
+}
+
+// NewPuzzleService creates the service and starts its worker. Self-play games are played
+// within the limits.
+func NewPuzzleService(limits SearchLimits) *PuzzleService {
+	s := &PuzzleService{
+		ai:      NewEnhancedAIService(),
+		limits:  limits,
+		queue:   make(chan puzzleJob, puzzleQueueSize),
+		puzzles: make(map[string]*Puzzle),
+		seen:    make(map[uint64]bool),
+
+		verifyNodes: puzzleVerifyNodes,
+	}
+	go s.run()
+	return s
+}
+
+// Submit queues a finished game to be searched for puzzles. Games without a winner have
+// none and are ignored.
+func (s *PuzzleService) Submit(game ReviewedGame) error {
+	if game.Winner == 0 {
+		return nil
+	}
+	if err := validateReviewedGame(game); err != nil {
+		return err
+	}
+	select {
+	case s.queue <- puzzleJob{game: game}:
+		return nil
+	default:
+		return ErrPuzzleQueueFull
+	}
+}
+
+// GameFinished submits a game that just ended; it is registered with the game services and
+// never blocks
+func (s *PuzzleService) GameFinished(game ReviewedGame) {
+	if err := s.Submit(game); err != nil {
+		log.Printf("Puzzle generation skipped game %s: %v", game.ID, err)
+	}
+}
+
+// SelfPlay queues engine self-play games to generate puzzles from
+func (s *PuzzleService) SelfPlay(games int) error {
+	for i := 0; i < games; i++ {
+		select {
+		case s.queue <- puzzleJob{selfPlay: true}:
+		default:
+			return ErrPuzzleQueueFull
+		}
+	}
+	return nil
+}
+
+// Next returns the first puzzle matching the filter after the puzzle with the given ID, in
+// order of rating, wrapping around to the easiest. An empty ID starts from the easiest.
+func (s *PuzzleService) Next(filter PuzzleFilter, afterID string) (Puzzle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	start := 0
+	if after, exists := s.puzzles[afterID]; exists {
+		start = sort.Search(len(s.order), func(i int) bool { return !puzzleLess(s.order[i], after) }) + 1
+	}
+	for i := 0; i < len(s.order); i++ {
+		puzzle := s.order[(start+i)%len(s.order)]
+		if filter.matches(puzzle) {
+			return copyPuzzle(puzzle), nil
+		}
+	}
+	return Puzzle{}, ErrPuzzleNotFound
+}
+
+// GetPuzzle returns the puzzle with the given ID
+func (s *PuzzleService) GetPuzzle(puzzleID string) (Puzzle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	puzzle, exists := s.puzzles[puzzleID]
+	if !exists {
+		return Puzzle{}, ErrPuzzleNotFound
+	}
+	return copyPuzzle(puzzle), nil
+}
+
+// Count returns the number of puzzles kept
+func (s *PuzzleService) Count() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.puzzles)
+}
+
+// Attempt replays the player's moves, each answered by the engine's most stubborn defence.
+// A move that does not keep a forced win within the puzzle's length fails the attempt; a
+// five solves it. Each move is checked within its own node budget, and a move the solver
+// cannot decide in time leaves the attempt undecided rather than failed. The engine's
+// replies are not part of the moves sent.
+func (s *PuzzleService) Attempt(puzzleID string, moves []model.Move) (PuzzleAttempt, error) {
+	puzzle, err := s.GetPuzzle(puzzleID)
+	if err != nil {
+		return PuzzleAttempt{}, err
+	}
+	if len(moves) == 0 {
+		return PuzzleAttempt{}, errors.New("no moves to check")
+	}
+	if len(moves) > puzzle.Length {
+		return PuzzleAttempt{}, fmt.Errorf("the puzzle is solved in %d moves", puzzle.Length)
+	}
+
+	attacker, defender := puzzle.ToMove, 3-puzzle.ToMove
+	solver := newThreatSolver(puzzle.Board, attacker, puzzle.Kind == ThreatVCT, s.verifyNodes)
+	attempt := PuzzleAttempt{PuzzleID: puzzle.ID, Status: PuzzleContinue, Moves: []model.Move{}}
+	for i, move := range moves {
+		if !solver.pb.inside(move.X, move.Y) || solver.pb.at(move.X, move.Y) != 0 {
+			return PuzzleAttempt{}, fmt.Errorf("invalid move %d at %d,%d", i+1, move.X, move.Y)
+		}
+		move.Player = attacker
+		solver.pb.place(move.X, move.Y, attacker)
+		attempt.Moves = append(attempt.Moves, move)
+		if solver.pb.isFive(move.X, move.Y) {
+			attempt.Status, attempt.Reply = PuzzleSolved, nil
+			attempt.Message = fmt.Sprintf("Solved: five in a row with move %d", i+1)
+			attempt.Solution = puzzle.Solution
+			return attempt, nil
+		}
+		solver.nodes, solver.aborted = 0, false
+		reply, ok := s.defend(solver, puzzle.Length-i-1)
+		if solver.aborted {
+			attempt.Status, attempt.Reply = PuzzleUndecided, nil
+			attempt.Message = fmt.Sprintf("Move %d at %s could not be checked in time", i+1, cellName(move.X, move.Y))
+			return attempt, nil
+		}
+		if !ok {
+			attempt.Status, attempt.Reply, attempt.FailedAt = PuzzleFailed, nil, i+1
+			attempt.Message = fmt.Sprintf("Move %d at %s lets the win slip away", i+1, cellName(move.X, move.Y))
+			attempt.Solution = puzzle.Solution
+			return attempt, nil
+		}
+		reply.Player = defender
+		solver.pb.place(reply.X, reply.Y, defender)
+		attempt.Moves = append(attempt.Moves, reply)
+	}
+	reply := attempt.Moves[len(attempt.Moves)-1]
+	attempt.Reply = &reply
+	attempt.Message = "Correct, keep going"
+	return attempt, nil
+}
+
+// defend returns the defender's reply that holds out longest, if the attacker still wins
+// within depth more moves
+func (s *PuzzleService) defend(solver *threatSolver, depth int) (model.Move, bool) {
+	replies, forcing := solver.replies()
+	if !forcing {
+		return model.Move{}, false
+	}
+
+	best, bestLength := model.Move{}, 0
+	for _, reply := range replies {
+		solver.pb.place(reply.X, reply.Y, reply.Player)
+		length := 0
+		for d := 1; d <= depth; d++ {
+			if _, ok := solver.attack(d); ok {
+				length = d
+				break
+			}
+			if solver.aborted {
+				break
+			}
+		}
+		solver.pb.remove(reply.X, reply.Y)
+		if length == 0 {
+			return model.Move{}, false
+		}
+		if length > bestLength {
+			best, bestLength = reply, length
+		}
+	}
+	return best, true
+}
+
+// run generates puzzles from queued games until the program exits
+func (s *PuzzleService) run() {
+	for job := range s.queue {
+		game := job.game
+		if job.selfPlay {
+			var err error
+			if game, err = s.selfPlayGame(context.Background()); err != nil {
+				log.Printf("Puzzle self-play failed: %v", err)
+				continue
+			}
+		}
+		if puzzle, ok := extractPuzzle(game); ok {
+			s.add(puzzle)
+		}
+	}
+}
+
+// selfPlayGame plays the engine against itself from a few random opening stones, so every
+// game goes its own way
+func (s *PuzzleService) selfPlayGame(ctx context.Context) (ReviewedGame, error) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	board := createGrid(15)
+	game := ReviewedGame{ID: "selfplay_" + uuid.New().String(), Mode: "selfplay"}
+	lastMove := model.Move{X: -1, Y: -1}
+	opening := 2 + rng.Intn(3)
+
+	for len(game.Moves) < 15*15 {
+		player := len(game.Moves)%2 + 1
+		var move model.Move
+		if len(game.Moves) < opening {
+			// Random stones within three lines of the centre
+			for {
+				move = model.Move{X: 4 + rng.Intn(7), Y: 4 + rng.Intn(7), Player: player}
+				if board[move.Y][move.X] == 0 {
+					break
+				}
+			}
+		} else {
+			aiMove, err := s.ai.BestMove(ctx, Position{Board: copyBoard(board), LastMove: lastMove, ToMove: player}, s.limits)
+			if err != nil {
+				return ReviewedGame{}, err
+			}
+			move = model.Move{X: aiMove.X, Y: aiMove.Y, Player: player}
+		}
+
+		board[move.Y][move.X] = player
+		game.Moves = append(game.Moves, move)
+		lastMove = move
+		if fiveInRow(board, move.X, move.Y, player) {
+			game.Winner = player
+			break
+		}
+	}
+	return game, nil
+}
+
+// extractPuzzle walks back from the winning move over the winner's turns and returns the
+// earliest position from which the winner still had a forced win of a puzzle's length
+func extractPuzzle(game ReviewedGame) (Puzzle, bool) {
+	if game.Winner == 0 {
+		return Puzzle{}, false
+	}
+
+	var puzzle Puzzle
+	found := false
+	for turn := len(game.Moves) - 1; turn >= 0; turn-- {
+		if turn%2+1 != game.Winner {
+			continue
+		}
+		board, err := openingBoard(game.Moves[:turn])
+		if err != nil {
+			return Puzzle{}, false
+		}
+		kind, line, ok := solveThreats(board, game.Winner, maxPuzzleLength)
+		if !ok {
+			break
+		}
+		if length := (len(line) + 1) / 2; length >= minPuzzleLength {
+			puzzle = Puzzle{
+				Board:      board,
+				ToMove:     game.Winner,
+				LastMove:   model.Move{X: -1, Y: -1},
+				Kind:       kind,
+				Length:     length,
+				Rating:     puzzleRating(kind, length),
+				Source:     game.Mode,
+				GameID:     game.ID,
+				MoveNumber: turn,
+				Solution:   line,
+			}
+			if turn > 0 {
+				last := game.Moves[turn-1]
+				puzzle.LastMove = model.Move{X: last.X, Y: last.Y, Player: 3 - game.Winner}
+			}
+			found = true
+		}
+	}
+	return puzzle, found
+}
+
+// solveThreats looks for the shortest forced win by fours, then by fours and threes
+func solveThreats(board [][]int, attacker, maxLength int) (string, []model.Move, bool) {
+	if line, ok := newThreatSolver(board, attacker, false, puzzleSolveNodes).solve(maxLength); ok {
+		return ThreatVCF, line, true
+	}
+	if line, ok := newThreatSolver(board, attacker, true, puzzleSolveNodes).solve(maxLength); ok {
+		return ThreatVCT, line, true
+	}
+	return "", nil, false
+}
+
+// puzzleRating grows with the length of the solution; threes make a win harder to see than
+// fours alone
+func puzzleRating(kind string, length int) int {
+	rating := 800 + 150*length
+	if kind == ThreatVCT {
+		rating += 300
+	}
+	return rating
+}
+
+// add stores a new puzzle unless its position is already known, forgetting the oldest
+// puzzles beyond the limit
+func (s *PuzzleService) add(puzzle Puzzle) (Puzzle, bool) {
+	key := newPatternBoard(puzzle.Board).hash ^ uint64(puzzle.ToMove)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.seen[key] {
+		return Puzzle{}, false
+	}
+	s.seen[key] = true
+	puzzle.ID = "puzzle_" + uuid.New().String()
+	puzzle.CreatedAt = time.Now()
+	s.puzzles[puzzle.ID] = &puzzle
+	s.created = append(s.created, puzzle.ID)
+	i := sort.Search(len(s.order), func(i int) bool { return !puzzleLess(s.order[i], &puzzle) })
+	s.order = append(s.order, nil)
+	copy(s.order[i+1:], s.order[i:])
+	s.order[i] = &puzzle
+
+	for len(s.created) > maxPuzzles {
+		oldest := s.puzzles[s.created[0]]
+		s.created = s.created[1:]
+		delete(s.puzzles, oldest.ID)
+		delete(s.seen, newPatternBoard(oldest.Board).hash^uint64(oldest.ToMove))
+		for i, p := range s.order {
+			if p == oldest {
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				break
+			}
+		}
+	}
+	return copyPuzzle(&puzzle), true
+}
+
+// puzzleLess orders puzzles by rating, then ID
+func puzzleLess(a, b *Puzzle) bool {
+	if a.Rating != b.Rating {
+		return a.Rating < b.Rating
+	}
+	return a.ID < b.ID
+}
+
+// matches reports whether the puzzle passes the filter
+func (f PuzzleFilter) matches(puzzle *Puzzle) bool {
+	return (f.Kind == "" || f.Kind == puzzle.Kind) &&
+		(f.MinLength == 0 || puzzle.Length >= f.MinLength) &&
+		(f.MaxLength == 0 || puzzle.Length <= f.MaxLength)
+}
+
+// copyPuzzle copies a puzzle so callers cannot change the stored board
+func copyPuzzle(puzzle *Puzzle) Puzzle {
+	result := *puzzle
+	result.Board = copyBoard(puzzle.Board)
+	result.Solution = append([]model.Move{}, puzzle.Solution...)
+	return result
+}
@@ -0,0 +1,243 @@
+// Unit tests for the threat-space solver and the puzzle trainer
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// fourThreeBoard gives black a closed three on row 7 and an open two on column 6; (6,7)
+// makes a four and an open three at once
+func fourThreeBoard() [][]int {
+	board := createEmptyBoard()
+	board[7][2] = 2
+	board[7][3], board[7][4], board[7][5] = 1, 1, 1
+	board[8][6], board[9][6] = 1, 1
+	return board
+}
+
+func TestThreatSolver(t *testing.T) {
+	kind, line, ok := solveThreats(fourThreeBoard(), 1, maxPuzzleLength)
+	if !ok || kind != ThreatVCF {
+		t.Fatalf("Expected a VCF, got %q (%v)", kind, ok)
+	}
+	if len(line) != 5 || line[0] != (model.Move{X: 6, Y: 7, Player: 1}) || line[1] != (model.Move{X: 7, Y: 7, Player: 2}) {
+		t.Errorf("Expected the four-three at (6,7) blocked at (7,7), got %+v", line)
+	}
+	if _, _, ok := solveThreats(fourThreeBoard(), 2, maxPuzzleLength); ok {
+		t.Error("Expected no forced win for white")
+	}
+
+	// Two open twos crossing at (7,7) need a double three: only a VCT wins
+	board := createEmptyBoard()
+	board[7][5], board[7][6] = 1, 1
+	board[5][7], board[6][7] = 1, 1
+	kind, line, ok = solveThreats(board, 1, maxPuzzleLength)
+	if !ok || kind != ThreatVCT || (len(line)+1)/2 != 3 || line[0].X != 7 || line[0].Y != 7 {
+		t.Errorf("Expected a three-move VCT starting at (7,7), got %q %+v", kind, line)
+	}
+
+	// A white four elsewhere on the board breaks the attack
+	board[0][0], board[0][1], board[0][2], board[0][3] = 2, 2, 2, 2
+	if _, _, ok := solveThreats(board, 1, maxPuzzleLength); ok {
+		t.Error("Expected the defender's four to refute the attack")
+	}
+}
+
+// addTestPuzzle stores a position black wins as a puzzle
+func addTestPuzzle(t *testing.T, s *PuzzleService, board [][]int) Puzzle {
+	t.Helper()
+	kind, line, ok := solveThreats(board, 1, maxPuzzleLength)
+	if !ok {
+		t.Fatal("Expected the position to be won")
+	}
+	length := (len(line) + 1) / 2
+	puzzle, _ := s.add(Puzzle{Board: board, ToMove: 1, Kind: kind, Length: length, Rating: puzzleRating(kind, length), Solution: line})
+	return puzzle
+}
+
+func TestPuzzleAttempt(t *testing.T) {
+	s := NewPuzzleService(SearchLimits{Difficulty: Easy})
+	puzzle := addTestPuzzle(t, s, fourThreeBoard())
+
+	attempt, err := s.Attempt(puzzle.ID, []model.Move{{X: 0, Y: 14}})
+	if err != nil {
+		t.Fatalf("Attempt failed: %v", err)
+	}
+	if attempt.Status != PuzzleFailed || attempt.FailedAt != 1 || len(attempt.Solution) == 0 {
+		t.Errorf("Expected a quiet move to fail, got %s", attempt.Status)
+	}
+
+	attempt, _ = s.Attempt(puzzle.ID, []model.Move{{X: 6, Y: 7}})
+	if attempt.Status != PuzzleContinue || attempt.Reply == nil || *attempt.Reply != (model.Move{X: 7, Y: 7, Player: 2}) {
+		t.Fatalf("Expected the engine to block the four, got %+v", attempt)
+	}
+	if attempt.Solution != nil {
+		t.Error("Expected the solution to stay hidden while the puzzle goes on")
+	}
+
+	// Any open four wins, not only the stored line
+	attempt, _ = s.Attempt(puzzle.ID, []model.Move{{X: 6, Y: 7}, {X: 6, Y: 10}})
+	if attempt.Status != PuzzleContinue || len(attempt.Moves) != 4 {
+		t.Fatalf("Expected the open four to be answered, got %+v", attempt)
+	}
+	reply := attempt.Reply
+	win := model.Move{X: 6, Y: 6}
+	if reply.Y == 6 {
+		win.Y = 11
+	}
+	attempt, _ = s.Attempt(puzzle.ID, []model.Move{{X: 6, Y: 7}, {X: 6, Y: 10}, win})
+	if attempt.Status != PuzzleSolved || attempt.Reply != nil {
+		t.Errorf("Expected the five to solve the puzzle, got %s", attempt.Status)
+	}
+
+	if _, err := s.Attempt(puzzle.ID, []model.Move{{X: 7, Y: 7}, {X: 6, Y: 7}}); err != nil {
+		t.Fatalf("Attempt failed: %v", err)
+	}
+	if _, err := s.Attempt(puzzle.ID, []model.Move{{X: 3, Y: 7}}); err == nil {
+		t.Error("Expected a move on an occupied cell to be rejected")
+	}
+	if _, err := s.Attempt("puzzle_unknown", []model.Move{{X: 6, Y: 7}}); !errors.Is(err, ErrPuzzleNotFound) {
+		t.Errorf("Expected ErrPuzzleNotFound, got %v", err)
+	}
+}
+
+// longVCTBoard gives black a five-move win by continuous threats
+func longVCTBoard() [][]int {
+	board := createEmptyBoard()
+	board[4][5], board[4][6], board[4][7], board[5][4], board[10][5] = 2, 2, 2, 2, 2
+	board[4][8], board[6][4], board[6][7], board[7][4], board[8][7], board[8][9], board[9][9] = 1, 1, 1, 1, 1, 1, 1
+	return board
+}
+
+func TestPuzzleAttemptBudget(t *testing.T) {
+	s := NewPuzzleService(SearchLimits{Difficulty: Easy})
+	puzzle := addTestPuzzle(t, s, longVCTBoard())
+	if puzzle.Kind != ThreatVCT || puzzle.Length != 5 {
+		t.Fatalf("Expected a five-move VCT, got %s in %d", puzzle.Kind, puzzle.Length)
+	}
+	var moves []model.Move
+	for i := 0; i < len(puzzle.Solution); i += 2 {
+		moves = append(moves, puzzle.Solution[i])
+	}
+
+	// A correct move the solver cannot check in time is not a failure
+	s.verifyNodes = 100
+	attempt, err := s.Attempt(puzzle.ID, moves[:1])
+	if err != nil {
+		t.Fatalf("Attempt failed: %v", err)
+	}
+	if attempt.Status != PuzzleUndecided || attempt.FailedAt != 0 || attempt.Solution != nil {
+		t.Errorf("Expected the move to be undecided, got %s at %d", attempt.Status, attempt.FailedAt)
+	}
+
+	// Every move has its own budget, so the whole line is checked
+	s.verifyNodes = puzzleVerifyNodes
+	attempt, _ = s.Attempt(puzzle.ID, moves)
+	if attempt.Status != PuzzleSolved || len(attempt.Moves) != len(puzzle.Solution) {
+		t.Errorf("Expected the solution to solve the puzzle, got %s: %s", attempt.Status, attempt.Message)
+	}
+}
+
+func TestPuzzleNext(t *testing.T) {
+	s := NewPuzzleService(SearchLimits{Difficulty: Easy})
+	if _, err := s.Next(PuzzleFilter{}, ""); !errors.Is(err, ErrPuzzleNotFound) {
+		t.Errorf("Expected no puzzle yet, got %v", err)
+	}
+
+	vcf := addTestPuzzle(t, s, fourThreeBoard())
+	board := createEmptyBoard()
+	board[7][5], board[7][6] = 1, 1
+	board[5][7], board[6][7] = 1, 1
+	vct := addTestPuzzle(t, s, board)
+	if repeated := addTestPuzzle(t, s, fourThreeBoard()); repeated.ID != "" || s.Count() != 2 {
+		t.Errorf("Expected a repeated position to be stored once, got %d puzzles", s.Count())
+	}
+
+	first, _ := s.Next(PuzzleFilter{}, "")
+	second, _ := s.Next(PuzzleFilter{}, first.ID)
+	third, _ := s.Next(PuzzleFilter{}, second.ID)
+	if first.ID != vcf.ID || second.ID != vct.ID || third.ID != vcf.ID {
+		t.Errorf("Expected the easier VCF, then the VCT, then to wrap around")
+	}
+	if next, _ := s.Next(PuzzleFilter{Kind: ThreatVCT}, ""); next.ID != vct.ID {
+		t.Error("Expected the kind filter to pick the VCT")
+	}
+	if _, err := s.Next(PuzzleFilter{MinLength: maxPuzzleLength}, ""); !errors.Is(err, ErrPuzzleNotFound) {
+		t.Errorf("Expected no puzzle that long, got %v", err)
+	}
+}
+
+func TestPuzzleFromFinishedGame(t *testing.T) {
+	// Black builds the four-three, makes it and wins on column 6
+	moves := []model.Move{
+		{X: 3, Y: 7}, {X: 2, Y: 7}, {X: 4, Y: 7}, {X: 0, Y: 0}, {X: 5, Y: 7}, {X: 14, Y: 0},
+		{X: 6, Y: 8}, {X: 0, Y: 14}, {X: 6, Y: 9}, {X: 14, Y: 14},
+		{X: 6, Y: 7}, {X: 7, Y: 7}, {X: 6, Y: 6}, {X: 6, Y: 5}, {X: 6, Y: 10},
+	}
+	game := ReviewedGame{ID: "pvp_test", Mode: "pvp", Moves: moves, Winner: 1}
+	puzzle, ok := extractPuzzle(game)
+	if !ok {
+		t.Fatal("Expected a puzzle from the game")
+	}
+	if puzzle.ToMove != 1 || puzzle.Length < minPuzzleLength || puzzle.MoveNumber > 10 || puzzle.Source != "pvp" {
+		t.Errorf("Expected a black puzzle from move 10 or earlier, got %+v", puzzle)
+	}
+	board, _ := openingBoard(moves[:puzzle.MoveNumber])
+	for y := range board {
+		for x := range board[y] {
+			if board[y][x] != puzzle.Board[y][x] {
+				t.Fatalf("Expected the puzzle board to be the game after %d moves", puzzle.MoveNumber)
+			}
+		}
+	}
+	if _, ok := extractPuzzle(ReviewedGame{Moves: moves}); ok {
+		t.Error("Expected no puzzle from a game without a winner")
+	}
+
+	// PVP games feed the puzzle service as they finish
+	s := NewPuzzleService(SearchLimits{Difficulty: Easy})
+	gs := NewGameService()
+	gs.OnGameFinished(s.GameFinished)
+	room, _ := gs.CreateRoom("puzzles", "alice", 2)
+	_, bob, _ := gs.JoinRoom(room.ID, "bob")
+	gs.SetPlayerReady(room.ID, room.Players[0].ID, true)
+	gs.SetPlayerReady(room.ID, bob.ID, true)
+	if err := gs.StartGame(room.ID); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	for i, move := range moves {
+		player := room.Players[i%2].ID
+		if _, _, err := gs.MakeMove(room.ID, player, move.X, move.Y); err != nil {
+			t.Fatalf("MakeMove %d failed: %v", i+1, err)
+		}
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for s.Count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.Count() != 1 {
+		t.Error("Expected the finished PVP game to yield a puzzle")
+	}
+}
+
+func TestPuzzleSelfPlay(t *testing.T) {
+	s := NewPuzzleService(SearchLimits{Difficulty: Easy, Depth: 2})
+	game, err := s.selfPlayGame(context.Background())
+	if err != nil {
+		t.Fatalf("selfPlayGame failed: %v", err)
+	}
+	if err := validateReviewedGame(game); err != nil {
+		t.Fatalf("Expected a valid game, got %v", err)
+	}
+	last := game.Moves[len(game.Moves)-1]
+	board, _ := openingBoard(game.Moves[:len(game.Moves)-1])
+	board[last.Y][last.X] = last.Player
+	if game.Winner != 0 && !fiveInRow(board, last.X, last.Y, game.Winner) {
+		t.Errorf("Expected the winner to have made five, got %d moves", len(game.Moves))
+	}
+}
@@ -0,0 +1,48 @@
+// Package service contains the thread budget shared by all enhanced AI searches
+// This file caps helper goroutines globally so concurrent games don't starve each other
+package service
+
+import (
+	"runtime"
+	"sync"
+)
+
+// threadBudget hands out helper thread slots across all concurrent searches
+type threadBudget struct {
+	mutex sync.Mutex
+	limit int
+	inUse int
+}
+
+// searchThreads is the process-wide helper thread budget, one slot per CPU by default
+var searchThreads = &threadBudget{limit: runtime.NumCPU()}
+
+// SetMaxSearchThreads sets how many helper threads all searches may use together.
+// Every search always keeps its own main thread, so 0 makes every search single-threaded.
+func SetMaxSearchThreads(n int) {
+	searchThreads.mutex.Lock()
+	defer searchThreads.mutex.Unlock()
+
+	searchThreads.limit = max(n, 0)
+}
+
+// acquire reserves up to n helper threads and returns how many were granted
+func (tb *threadBudget) acquire(n int) int {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	granted := min(n, tb.limit-tb.inUse)
+	if granted < 0 {
+		granted = 0
+	}
+	tb.inUse += granted
+	return granted
+}
+
+// release returns helper threads to the budget
+func (tb *threadBudget) release(n int) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	tb.inUse -= n
+}
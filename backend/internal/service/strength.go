@@ -0,0 +1,234 @@
+// Package service contains human-like strength levels
+// This file weakens the minimax engine to calibrated levels: it caps the search, picks among
+// the best few moves with a softmax over their scores and now and then overlooks a threat
+// away from the last move
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gomoku-backend/internal/model"
+)
+
+// StrengthLevel is a human-like playing strength
+type StrengthLevel struct {
+	Level       int     `json:"level"` // 1 (weakest) and up
+	Name        string  `json:"name"`
+	Elo         int     `json:"elo"`         // Measured by cmd/calibrate, level 1 anchored at 600
+	Depth       int     `json:"depth"`       // Search depth cap
+	Nodes       int64   `json:"nodes"`       // Node cap per search
+	Candidates  int     `json:"candidates"`  // Best moves the choice is made among
+	Temperature float64 `json:"temperature"` // Softmax temperature in evaluation points; 0 always plays the best
+	Oversight   float64 `json:"oversight"`   // Chance of overlooking each threat away from the last move
+	Vision      int     `json:"vision"`      // Threats with a point this close to the last move are always seen
+}
+
+// strengthLevels are ordered from weakest to strongest. The Elo ratings come from cmd/calibrate
+// matches between neighbouring levels.
+var strengthLevels = []StrengthLevel{
+	{Level: 1, Name: "Beginner", Elo: 600, Depth: 1, Nodes: 5000, Candidates: 10, Temperature: 3000, Oversight: 0.9, Vision: 0},
+	{Level: 2, Name: "Novice", Elo: 781, Depth: 1, Nodes: 5000, Candidates: 10, Temperature: 1500, Oversight: 0.7, Vision: 0},
+	{Level: 3, Name: "Casual", Elo: 1048, Depth: 1, Nodes: 5000, Candidates: 8, Temperature: 800, Oversight: 0.5, Vision: 1},
+	{Level: 4, Name: "Learner", Elo: 1076, Depth: 1, Nodes: 5000, Candidates: 6, Temperature: 400, Oversight: 0.35, Vision: 1},
+	{Level: 5, Name: "Club", Elo: 1304, Depth: 2, Nodes: 10000, Candidates: 5, Temperature: 250, Oversight: 0.25, Vision: 2},
+	{Level: 6, Name: "Improver", Elo: 1339, Depth: 2, Nodes: 10000, Candidates: 4, Temperature: 120, Oversight: 0.15, Vision: 2},
+	{Level: 7, Name: "Intermediate", Elo: 1372, Depth: 2, Nodes: 20000, Candidates: 3, Temperature: 50, Oversight: 0.08, Vision: 2},
+	{Level: 8, Name: "Advanced", Elo: 1496, Depth: 3, Nodes: 40000, Candidates: 3, Temperature: 30, Oversight: 0.04, Vision: 3},
+	{Level: 9, Name: "Strong", Elo: 1545, Depth: 3, Nodes: 60000, Candidates: 2, Temperature: 15, Oversight: 0.02, Vision: 3},
+	{Level: 10, Name: "Expert", Elo: 1602, Depth: 4, Nodes: 100000, Candidates: 1},
+	{Level: 11, Name: "Master", Elo: 1740, Depth: 6, Nodes: 300000, Candidates: 1},
+	{Level: 12, Name: "Grandmaster", Elo: 1807, Depth: 8, Nodes: 800000, Candidates: 1},
+}
+
+// StrengthLevels returns the levels from weakest to strongest
+func StrengthLevels() []StrengthLevel {
+	return append([]StrengthLevel(nil), strengthLevels...)
+}
+
+// GetStrengthLevel returns the level with the given number
+func GetStrengthLevel(level int) (StrengthLevel, bool) {
+	if level < 1 || level > len(strengthLevels) {
+		return StrengthLevel{}, false
+	}
+	return strengthLevels[level-1], true
+}
+
+// Limits returns the search limits the level plays with
+func (l StrengthLevel) Limits() SearchLimits {
+	return SearchLimits{Difficulty: Medium, Depth: l.Depth, Nodes: l.Nodes}
+}
+
+// Validate checks the level's settings
+func (l StrengthLevel) Validate() error {
+	switch {
+	case l.Depth < 1 || l.Nodes < 0:
+		return errors.New("depth must be positive and nodes not negative")
+	case l.Candidates < 1 || l.Candidates > MaxMultiPV:
+		return fmt.Errorf("candidates must be between 1 and %d", MaxMultiPV)
+	case l.Temperature < 0:
+		return errors.New("temperature must not be negative")
+	case l.Oversight < 0 || l.Oversight > 1:
+		return errors.New("oversight must be between 0 and 1")
+	case l.Vision < 0:
+		return errors.New("vision must not be negative")
+	}
+	return nil
+}
+
+// HumanEngine plays like a human of the given strength on top of the minimax engine
+type HumanEngine struct {
+	ai    *EnhancedAIService
+	level StrengthLevel
+	rng   *rand.Rand
+	mutex sync.Mutex // Guards rng
+}
+
+// NewHumanEngine creates an engine playing at the level, searching with ai. The seed makes
+// its choices reproducible.
+func NewHumanEngine(ai *EnhancedAIService, level StrengthLevel, seed int64) (*HumanEngine, error) {
+	if err := level.Validate(); err != nil {
+		return nil, err
+	}
+	return &HumanEngine{ai: ai, level: level, rng: rand.New(rand.NewSource(seed))}, nil
+}
+
+// Name returns the registry name
+func (h *HumanEngine) Name() string {
+	return fmt.Sprintf("level-%d", h.level.Level)
+}
+
+// Capabilities reports that the level sets its own limits
+func (h *HumanEngine) Capabilities() EngineCapabilities {
+	return EngineCapabilities{Description: "Human-like play at calibrated strength level " + h.level.Name}
+}
+
+// Level returns the strength level the engine plays at
+func (h *HumanEngine) Level() StrengthLevel {
+	return h.level
+}
+
+// BestMove picks a move the way a player of the level would. Distant threats may be
+// overlooked: the search then runs as if their stones were not on the board. Among the best
+// candidates a move is drawn with probability growing with its score.
+func (h *HumanEngine) BestMove(ctx context.Context, position Position, limits SearchLimits) (model.AIMove, error) {
+	if err := position.Validate(); err != nil {
+		return model.AIMove{}, err
+	}
+	if err := limits.Validate(h.Capabilities()); err != nil {
+		return model.AIMove{}, err
+	}
+
+	h.mutex.Lock()
+	seen, overlooked := h.overlook(position)
+	roll := h.rng.Float64()
+	h.mutex.Unlock()
+
+	analysis, err := h.ai.Analyze(ctx, seen, h.level.Limits(), h.level.Candidates)
+	if err != nil {
+		return model.AIMove{}, err
+	}
+
+	// Moves onto overlooked stones are not playable
+	var candidates []CandidateMove
+	for _, candidate := range analysis.Candidates {
+		if position.Board[candidate.Y][candidate.X] == 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 && overlooked {
+		// Everything the distorted view suggested is taken, so look at the real board
+		if analysis, err = h.ai.Analyze(ctx, position, h.level.Limits(), h.level.Candidates); err != nil {
+			return model.AIMove{}, err
+		}
+		candidates = analysis.Candidates
+	}
+	if len(candidates) == 0 {
+		return model.AIMove{}, errors.New("no legal move left")
+	}
+
+	chosen := candidates[softmaxChoice(candidates, h.level.Temperature, roll)]
+	stats := map[string]interface{}{
+		"nodes_searched": analysis.Nodes,
+		"depth":          analysis.Depth,
+		"candidates":     len(candidates),
+		"search_time":    (time.Duration(analysis.TimeMs) * time.Millisecond).String(),
+	}
+	return model.AIMove{X: chosen.X, Y: chosen.Y, Score: chosen.Score, PV: chosen.PV, Stats: stats}, nil
+}
+
+// overlook returns the position as the player sees it: each four or open three whose points
+// all lie farther than the level's vision from the last move is missed with the level's
+// oversight chance. It also reports whether anything was missed. The caller holds the mutex.
+func (h *HumanEngine) overlook(position Position) (Position, bool) {
+	if h.level.Oversight == 0 || position.LastMove.X < 0 {
+		return position, false
+	}
+
+	var hidden []model.Move
+	for _, side := range findThreats(position.Board) {
+		for _, threat := range append(side.Fours, side.OpenThrees...) {
+			if !threatIsDistant(threat, position.LastMove, h.level.Vision) || h.rng.Float64() >= h.level.Oversight {
+				continue
+			}
+			hidden = append(hidden, threat.Stones...)
+		}
+	}
+	if len(hidden) == 0 {
+		return position, false
+	}
+
+	seen := position
+	seen.Board = copyBoard(position.Board)
+	for _, stone := range hidden {
+		seen.Board[stone.Y][stone.X] = 0
+	}
+	return seen, true
+}
+
+// threatIsDistant reports whether every point of the threat is farther than radius from the move
+func threatIsDistant(threat Threat, move model.Move, radius int) bool {
+	for _, point := range threat.Points {
+		if max(abs(point.X-move.X), abs(point.Y-move.Y)) <= radius {
+			return false
+		}
+	}
+	return true
+}
+
+// softmaxChoice draws a candidate index with weights exp((score-best)/temperature), using
+// roll in [0, 1). A zero temperature always picks the best candidate.
+func softmaxChoice(candidates []CandidateMove, temperature, roll float64) int {
+	if temperature == 0 || len(candidates) == 1 {
+		return 0
+	}
+
+	best := candidates[0].Score
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, candidate := range candidates {
+		// Proven results are far outside the evaluation scale, so they are not worth softening
+		if abs(candidate.Score) >= winScore || abs(best) >= winScore {
+			if candidate.Score == best {
+				weights[i] = 1
+			}
+		} else {
+			weights[i] = math.Exp(float64(candidate.Score-best) / temperature)
+		}
+		total += weights[i]
+	}
+
+	target := roll * total
+	for i, weight := range weights {
+		if target < weight {
+			return i
+		}
+		target -= weight
+	}
+	return 0
+}
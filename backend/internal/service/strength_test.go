@@ -0,0 +1,106 @@
+// Unit tests for human-like strength levels
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gomoku-backend/internal/model"
+)
+
+func TestStrengthLevels(t *testing.T) {
+	levels := StrengthLevels()
+	if len(levels) < 10 {
+		t.Fatalf("Expected at least 10 levels, got %d", len(levels))
+	}
+	for i, level := range levels {
+		if err := level.Validate(); err != nil {
+			t.Errorf("Level %d: %v", level.Level, err)
+		}
+		if level.Level != i+1 {
+			t.Errorf("Expected level %d at index %d, got %d", i+1, i, level.Level)
+		}
+		if i > 0 && level.Elo <= levels[i-1].Elo {
+			t.Errorf("Expected level %d (%d Elo) to be rated above level %d (%d Elo)", level.Level, level.Elo, i, levels[i-1].Elo)
+		}
+	}
+	if _, ok := GetStrengthLevel(len(levels) + 1); ok {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestSoftmaxChoice(t *testing.T) {
+	candidates := []CandidateMove{{Score: 500}, {Score: 400}, {Score: 0}}
+	if softmaxChoice(candidates, 0, 0.99) != 0 {
+		t.Error("Expected a zero temperature to play the best move")
+	}
+	// With a huge temperature the weights are almost equal
+	if softmaxChoice(candidates, 1e9, 0.2) != 0 || softmaxChoice(candidates, 1e9, 0.5) != 1 || softmaxChoice(candidates, 1e9, 0.9) != 2 {
+		t.Error("Expected an even choice at a huge temperature")
+	}
+	// At a temperature of 100 the second move is e^-1 as likely as the first
+	if softmaxChoice(candidates, 100, 0.7) != 0 || softmaxChoice(candidates, 100, 0.8) != 1 {
+		t.Error("Expected weights proportional to exp(score difference / temperature)")
+	}
+
+	winning := []CandidateMove{{Score: mateScore - 3}, {Score: 900}}
+	if softmaxChoice(winning, 1e9, 0.99) != 0 {
+		t.Error("Expected a proven win never to be given up")
+	}
+}
+
+func TestHumanEngineOversight(t *testing.T) {
+	// White has a four on the top row, far from black's last move in the centre
+	board := createEmptyBoard()
+	for x := 1; x <= 4; x++ {
+		board[0][x] = 2
+	}
+	board[0][0] = 1
+	board[7][7], board[7][8], board[8][7] = 1, 1, 2
+	board[9][9] = 2
+	position := Position{Board: board, LastMove: model.Move{X: 9, Y: 9, Player: 2}, ToMove: 1}
+
+	level := StrengthLevel{Level: 1, Name: "test", Depth: 1, Candidates: 3, Oversight: 1, Vision: 2}
+	careless, err := NewHumanEngine(NewEnhancedAIService(), level, 1)
+	if err != nil {
+		t.Fatalf("NewHumanEngine failed: %v", err)
+	}
+	move, err := careless.BestMove(context.Background(), position, SearchLimits{})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if move.X == 5 && move.Y == 0 || board[move.Y][move.X] != 0 {
+		t.Errorf("Expected the distant four to be overlooked with a legal move, got (%d,%d)", move.X, move.Y)
+	}
+
+	// The same four next to the last move is always seen
+	position.LastMove = model.Move{X: 4, Y: 0, Player: 2}
+	move, _ = careless.BestMove(context.Background(), position, SearchLimits{})
+	if move.X != 5 || move.Y != 0 {
+		t.Errorf("Expected the four to be blocked at (5,0), got (%d,%d)", move.X, move.Y)
+	}
+
+	if _, err := careless.BestMove(context.Background(), position, SearchLimits{Depth: 3}); err == nil {
+		t.Error("Expected search limits to be rejected")
+	}
+	level.Candidates = 0
+	if _, err := NewHumanEngine(NewEnhancedAIService(), level, 1); err == nil {
+		t.Error("Expected an invalid level to be rejected")
+	}
+}
+
+func TestHumanEngineTakesWin(t *testing.T) {
+	strongest := StrengthLevels()[len(StrengthLevels())-1]
+	engine, _ := NewHumanEngine(NewEnhancedAIService(), strongest, 1)
+	board := lineBoard("_XXXX_", 1)
+	board[10][10], board[11][11], board[12][12] = 2, 2, 2
+	position := Position{Board: board, LastMove: model.Move{X: 12, Y: 12, Player: 2}, ToMove: 1}
+
+	move, err := engine.BestMove(context.Background(), position, SearchLimits{})
+	if err != nil {
+		t.Fatalf("BestMove failed: %v", err)
+	}
+	if move.Y != 7 || (move.X != 3 && move.X != 8) {
+		t.Errorf("Expected the five on row 7, got (%d,%d)", move.X, move.Y)
+	}
+}
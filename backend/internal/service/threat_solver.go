@@ -0,0 +1,239 @@
+// Package service contains the threat-space solver
+// This file proves forced wins made only of fours (VCF, victory by continuous fours) or of
+// fours and open threes (VCT, victory by continuous threats). The attacker plays only
+// threats; the defender answers a four with its block, and an open three with every move
+// that stops it or with a four of its own.
+package service
+
+import (
+	"sort"
+
+	"gomoku-backend/internal/model"
+)
+
+// Kinds of forced win
+const (
+	ThreatVCF = "vcf"
+	ThreatVCT = "vct"
+)
+
+// threatSolver searches one position for a forced win of the attacker
+type threatSolver struct {
+	pb       *patternBoard
+	attacker int
+	threes   bool // Open threes are threats as well as fours (VCT)
+	nodes    int
+	maxNodes int
+	aborted  bool // The node budget ran out, so a failed search proves nothing
+}
+
+// newThreatSolver prepares a search of the board for the attacker within maxNodes nodes
+func newThreatSolver(board [][]int, attacker int, threes bool, maxNodes int) *threatSolver {
+	return &threatSolver{
+		pb:       newPatternBoard(board),
+		attacker: attacker,
+		threes:   threes,
+		maxNodes: maxNodes,
+	}
+}
+
+// solve returns the shortest forced win of at most maxLength attacker moves, the five
+// included, with the attacker to move. The line alternates attacker moves and defender
+// replies and ends with the five.
+func (s *threatSolver) solve(maxLength int) ([]model.Move, bool) {
+	for length := 1; length <= maxLength; length++ {
+		if line, ok := s.attack(length); ok {
+			return line, true
+		}
+		if s.aborted {
+			break
+		}
+	}
+	return nil, false
+}
+
+// attack tries every threat of the attacker, who has at most depth moves left to make five
+func (s *threatSolver) attack(depth int) ([]model.Move, bool) {
+	if s.nodes++; s.nodes > s.maxNodes {
+		s.aborted = true
+		return nil, false
+	}
+	if depth == 0 {
+		return nil, false
+	}
+	if fives := s.points(s.attacker, ShapeFive); len(fives) > 0 {
+		return fives[:1], true
+	}
+	if depth == 1 {
+		return nil, false
+	}
+
+	for _, move := range s.threats() {
+		s.pb.place(move.X, move.Y, s.attacker)
+		line, ok := s.defend(depth - 1)
+		s.pb.remove(move.X, move.Y)
+		if ok {
+			return append([]model.Move{move}, line...), true
+		}
+		if s.aborted {
+			break
+		}
+	}
+	return nil, false
+}
+
+// defend tries every reply of the defender; the attacker wins only if no reply holds. The
+// line follows the reply that resists longest.
+func (s *threatSolver) defend(depth int) ([]model.Move, bool) {
+	if s.nodes++; s.nodes > s.maxNodes {
+		s.aborted = true
+		return nil, false
+	}
+	replies, forcing := s.replies()
+	if !forcing {
+		return nil, false
+	}
+
+	var longest []model.Move
+	for _, reply := range replies {
+		s.pb.place(reply.X, reply.Y, reply.Player)
+		line, ok := s.attack(depth)
+		s.pb.remove(reply.X, reply.Y)
+		if !ok {
+			return nil, false
+		}
+		if longest == nil || len(line)+1 > len(longest) {
+			longest = append([]model.Move{reply}, line...)
+		}
+	}
+	return longest, true
+}
+
+// replies lists the defender's answers to the attacker's threats. forcing is false when the
+// attacker threatens nothing or the defender can make five first.
+func (s *threatSolver) replies() ([]model.Move, bool) {
+	defender := 3 - s.attacker
+	if len(s.points(defender, ShapeFive)) > 0 {
+		return nil, false
+	}
+	if fives := s.points(s.attacker, ShapeFive); len(fives) > 0 {
+		// Against two fives blocking one loses to the other
+		return []model.Move{{X: fives[0].X, Y: fives[0].Y, Player: defender}}, true
+	}
+	if !s.threes {
+		return nil, false
+	}
+	opens := s.points(s.attacker, ShapeOpenFour)
+	if len(opens) == 0 {
+		return nil, false
+	}
+
+	// The moves that stop an open three lie on its line: its completion points, its gaps and
+	// the cells beyond its ends
+	seen := make(map[int]bool)
+	var replies []model.Move
+	add := func(x, y int) {
+		idx := y*s.pb.size + x
+		if !s.pb.inside(x, y) || s.pb.at(x, y) != 0 || seen[idx] {
+			return
+		}
+		seen[idx] = true
+		replies = append(replies, model.Move{X: x, Y: y, Player: defender})
+	}
+	for _, point := range opens {
+		for _, dir := range directions {
+			for offset := -patternReach; offset <= patternReach; offset++ {
+				add(point.X+dir[0]*offset, point.Y+dir[1]*offset)
+			}
+		}
+	}
+	defences := replies[:0]
+	for _, reply := range replies {
+		s.pb.place(reply.X, reply.Y, defender)
+		if len(s.points(s.attacker, ShapeOpenFour)) == 0 {
+			defences = append(defences, reply)
+		}
+		s.pb.remove(reply.X, reply.Y)
+	}
+
+	// A four of the defender's own gains a tempo and may break the three
+	for _, four := range s.threatsOf(defender, false) {
+		if !containsMove(defences, four) {
+			defences = append(defences, four)
+		}
+	}
+	if len(defences) == 0 {
+		// Nothing stops the three: it becomes an open four whatever the defender does
+		return []model.Move{{X: opens[0].X, Y: opens[0].Y, Player: defender}}, true
+	}
+	return defences, true
+}
+
+// threats lists the attacker's candidate moves. When the defender has a four the only move
+// is its block, which must keep the attack going.
+func (s *threatSolver) threats() []model.Move {
+	if blocks := s.points(3-s.attacker, ShapeFive); len(blocks) > 0 {
+		if len(blocks) > 1 {
+			return nil
+		}
+		return []model.Move{{X: blocks[0].X, Y: blocks[0].Y, Player: s.attacker}}
+	}
+	return s.threatsOf(s.attacker, s.threes)
+}
+
+// threatsOf lists the moves that make a four for the player, or an open three as well when
+// threes is set, strongest first
+func (s *threatSolver) threatsOf(player int, threes bool) []model.Move {
+	var moves []scoredMove
+	for y := 0; y < s.pb.size; y++ {
+		for x := 0; x < s.pb.size; x++ {
+			if s.pb.at(x, y) != 0 {
+				continue
+			}
+			mt := analyzeMove(s.pb, x, y, player)
+			if mt.own.IsFour() || (threes && mt.own.IsOpenThree()) {
+				score := mt.attack
+				if mt.own.IsFour() {
+					score += orderThreat
+				}
+				moves = append(moves, scoredMove{move: model.Move{X: x, Y: y, Player: player}, score: score})
+			}
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].score > moves[j].score })
+
+	result := make([]model.Move, len(moves))
+	for i, scored := range moves {
+		result[i] = scored.move
+	}
+	return result
+}
+
+// points lists the empty cells where the player forms the target shape or better
+func (s *threatSolver) points(player int, target Shape) []model.Move {
+	var points []model.Move
+	for y := 0; y < s.pb.size; y++ {
+		for x := 0; x < s.pb.size; x++ {
+			if s.pb.at(x, y) != 0 {
+				continue
+			}
+			for d := range directions {
+				if s.pb.shape(x, y, d, player) >= target {
+					points = append(points, model.Move{X: x, Y: y, Player: player})
+					break
+				}
+			}
+		}
+	}
+	return points
+}
+
+// containsMove reports whether the moves include a stone on the same cell
+func containsMove(moves []model.Move, move model.Move) bool {
+	for _, m := range moves {
+		if m.X == move.X && m.Y == move.Y {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Package service contains the transposition table used by the enhanced AI
+// This file implements Zobrist hashing and a fixed-size, lock-free, two-way bucketed table
+package service
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"unsafe"
+
+	"gomoku-backend/internal/model"
+)
+
+const (
+	// defaultTTMemory is the transposition table size of a new engine, in bytes
+	defaultTTMemory = 128 << 10
+
+	// SharedTTMemory is the default transposition table size of the registered minimax
+	// engine, which every stateless request searches with
+	SharedTTMemory = 32 << 20
+)
+
+// TranspositionTableEntry stores evaluated board positions
+type TranspositionTableEntry struct {
+	Score    int
+	Depth    int
+	Flag     EntryFlag
+	BestMove model.Move
+}
+
+// EntryFlag indicates the type of score stored
+type EntryFlag int
+
+const (
+	Exact EntryFlag = iota
+	LowerBound
+	UpperBound
+)
+
+// zobristKeys holds a random key per [player][cell]; the fixed seed keeps hashes reproducible
+var zobristKeys = newZobristKeys(15*15, 20250101)
+
+// zobristSide keys the side to move: equal stone counts allow either side to move, and the
+// two are different positions with scores of opposite sign
+var zobristSide = [3]uint64{0, 0x9e3779b97f4a7c15, 0xc2b2ae3d27d4eb4f}
+
+// newZobristKeys generates Zobrist keys for a board with the given number of cells
+func newZobristKeys(cells int, seed int64) [3][]uint64 {
+	r := rand.New(rand.NewSource(seed))
+	var keys [3][]uint64
+	for player := 1; player <= 2; player++ {
+		keys[player] = make([]uint64, cells)
+		for i := range keys[player] {
+			keys[player][i] = r.Uint64()
+		}
+	}
+	return keys
+}
+
+// ttSlot is one transposition table slot. The key is stored XORed with the packed data,
+// so a slot torn by concurrent writers fails verification instead of returning bad data.
+type ttSlot struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+// transpositionTable is a fixed-size hash table shared lock-free between search workers.
+// Each bucket holds a depth-preferred slot and an always-replace slot, so memory stays
+// bounded however long the server runs.
+type transpositionTable struct {
+	slots   []ttSlot
+	buckets uint64
+	entries atomic.Int64
+}
+
+// newTranspositionTable creates a table using at most the given number of bytes
+func newTranspositionTable(bytes int64) *transpositionTable {
+	entries := bytes / int64(unsafe.Sizeof(ttSlot{}))
+	if entries > math.MaxInt32 {
+		entries = math.MaxInt32
+	}
+	buckets := uint64(1)
+	for buckets*4 <= uint64(entries) {
+		buckets *= 2
+	}
+	return &transpositionTable{
+		slots:   make([]ttSlot, buckets*2),
+		buckets: buckets,
+	}
+}
+
+// Packed entry layout: score (32 bits) | depth (8) | flag (2) | move x (4) | move y (4) | has move (1) | used (1)
+const (
+	ttDepthShift   = 32
+	ttFlagShift    = 40
+	ttMoveXShift   = 42
+	ttMoveYShift   = 46
+	ttHasMoveShift = 50
+	ttUsedShift    = 51
+)
+
+// packEntry encodes an entry into a single word
+func packEntry(entry TranspositionTableEntry) uint64 {
+	data := uint64(uint32(int32(entry.Score))) |
+		uint64(min(max(entry.Depth, 0), 255))<<ttDepthShift |
+		uint64(entry.Flag&3)<<ttFlagShift |
+		1<<ttUsedShift
+	if move := entry.BestMove; move.X >= 0 && move.X < 16 && move.Y >= 0 && move.Y < 16 {
+		data |= uint64(move.X)<<ttMoveXShift | uint64(move.Y)<<ttMoveYShift | 1<<ttHasMoveShift
+	}
+	return data
+}
+
+// unpackEntry decodes a word written by packEntry
+func unpackEntry(data uint64) TranspositionTableEntry {
+	entry := TranspositionTableEntry{
+		Score:    int(int32(uint32(data))),
+		Depth:    int(data >> ttDepthShift & 0xFF),
+		Flag:     EntryFlag(data >> ttFlagShift & 3),
+		BestMove: model.Move{X: -1, Y: -1},
+	}
+	if data>>ttHasMoveShift&1 == 1 {
+		entry.BestMove = model.Move{X: int(data >> ttMoveXShift & 0xF), Y: int(data >> ttMoveYShift & 0xF)}
+	}
+	return entry
+}
+
+// probe looks up a position by hash
+func (tt *transpositionTable) probe(key uint64) (TranspositionTableEntry, bool) {
+	bucket := (key & (tt.buckets - 1)) * 2
+	for i := bucket; i < bucket+2; i++ {
+		data := tt.slots[i].data.Load()
+		if data != 0 && tt.slots[i].check.Load()^data == key {
+			return unpackEntry(data), true
+		}
+	}
+	return TranspositionTableEntry{}, false
+}
+
+// store saves a search result, keeping the deeper result in the first slot of the bucket
+func (tt *transpositionTable) store(key uint64, entry TranspositionTableEntry) {
+	bucket := (key & (tt.buckets - 1)) * 2
+	slot := &tt.slots[bucket]
+
+	// Keep a deeper entry for another position; overwrite the always-replace slot instead
+	if data := slot.data.Load(); data != 0 && slot.check.Load()^data != key &&
+		entry.Depth < int(data>>ttDepthShift&0xFF) {
+		slot = &tt.slots[bucket+1]
+	}
+
+	data := packEntry(entry)
+	if slot.data.Swap(data) == 0 {
+		tt.entries.Add(1)
+	}
+	slot.check.Store(key ^ data)
+}
+
+// len returns the number of occupied slots
+func (tt *transpositionTable) len() int {
+	return int(tt.entries.Load())
+}
+
+// clear empties the table
+func (tt *transpositionTable) clear() {
+	for i := range tt.slots {
+		tt.slots[i].data.Store(0)
+		tt.slots[i].check.Store(0)
+	}
+	tt.entries.Store(0)
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
@@ -43,6 +45,9 @@ type Hub struct {
 
 	// Game service reference
 	gameService *GameService
+
+	// Rooms with an AI loop running, and whether it was asked to run again when done
+	aiLoops map[string]bool
 }
 
 // WebSocket upgrader
@@ -64,6 +69,7 @@ func NewHub(gameService *GameService) *Hub {
 		unregister:  make(chan *Client),
 		rooms:       make(map[string]map[*Client]bool),
 		gameService: gameService,
+		aiLoops:     make(map[string]bool),
 	}
 }
 
@@ -314,6 +320,69 @@ func (h *Hub) GetRoomClients(roomID string) int {
 	return 0
 }
 
+// PlayAIMoves plays the room's AI seats for as long as it is their turn and broadcasts each move.
+// A room runs one loop at a time; a call while it runs makes it check the turn again when done.
+func (h *Hub) PlayAIMoves(roomID string) {
+	h.mutex.Lock()
+	if _, running := h.aiLoops[roomID]; running {
+		h.aiLoops[roomID] = true
+		h.mutex.Unlock()
+		return
+	}
+	h.aiLoops[roomID] = false
+	h.mutex.Unlock()
+
+	for {
+		h.playAIMoves(roomID)
+
+		h.mutex.Lock()
+		again := h.aiLoops[roomID]
+		if !again {
+			delete(h.aiLoops, roomID)
+		} else {
+			h.aiLoops[roomID] = false
+		}
+		h.mutex.Unlock()
+		if !again {
+			return
+		}
+	}
+}
+
+// playAIMoves runs one AI loop for a room
+func (h *Hub) playAIMoves(roomID string) {
+	for {
+		room, move, err := h.gameService.PlayAIMove(context.Background(), roomID)
+		if errors.Is(err, ErrNotYourTurn) || errors.Is(err, ErrGameNotInProgress) {
+			// The game moved on while the AI was thinking
+			return
+		}
+		if err != nil {
+			log.Printf("Error playing AI move in room %s: %v", roomID, err)
+			return
+		}
+		if move == nil || room == nil || room.Game == nil {
+			return
+		}
+
+		updateData := model.GameUpdateData{
+			Game:     room.Game,
+			LastMove: move,
+		}
+		h.BroadcastToRoom(roomID, model.WSMessage{
+			Type: "move_made",
+			Data: updateData,
+		})
+		if room.Game.Status == "finished" {
+			h.BroadcastToRoom(roomID, model.WSMessage{
+				Type: "game_ended",
+				Data: updateData,
+			})
+			return
+		}
+	}
+}
+
 // ServeWS handles websocket requests from the peer
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request, roomID, playerID string) {
 	log.Printf("WebSocket连接请求: roomID=%s, playerID=%s", roomID, playerID)
@@ -561,6 +630,8 @@ func (c *Client) handleMoveMessage(wsMessage *model.WSMessage) {
 				Type: "game_ended",
 				Data: updateData,
 			})
+		} else {
+			go c.Hub.PlayAIMoves(c.RoomID)
 		}
 	}
 }
@@ -648,6 +719,7 @@ func (c *Client) handleReadyMessage(wsMessage *model.WSMessage) {
 					Type: "game_started",
 					Data: gameData,
 				})
+				go c.Hub.PlayAIMoves(c.RoomID)
 			}
 		}
 	}
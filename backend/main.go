@@ -5,6 +5,10 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,6 +17,9 @@ import (
 	"gomoku-backend/internal/service"
 )
 
+// puzzleSelfPlayGames is how many self-play games seed the puzzle collection at startup
+const puzzleSelfPlayGames = 16
+
 func main() {
 	// Initialize Gin router
 	r := gin.Default()
@@ -30,23 +37,59 @@ func main() {
 
 	// Initialize services
 	llmService := service.NewLLMService()
+	gameService := service.NewGameService()
+	aiGameService := service.NewAIGameService()
+	// Finished games are analysed in the background at medium strength, 2 seconds a position at most
+	reviewService := service.NewGameReviewService(service.SearchLimits{Difficulty: service.Medium, Time: 2 * time.Second}, llmService, gameService, aiGameService)
+	// Puzzles are cut from LLM and PVP games as they finish, and from a few medium-strength
+	// self-play games at startup
+	puzzleService := service.NewPuzzleService(service.SearchLimits{Difficulty: service.Medium})
+	llmService.OnGameFinished(puzzleService.GameFinished)
+	gameService.OnGameFinished(puzzleService.GameFinished)
+	puzzleService.SelfPlay(puzzleSelfPlayGames)
+	registerExternalEngines(os.Getenv("EXTERNAL_ENGINES"))
+	registerEvalWeights(os.Getenv("EVAL_WEIGHTS"))
+	configureNeuralEval(os.Getenv("NNUE_NETWORK"), os.Getenv("NNUE_DIFFICULTIES"))
+	configureTranspositionTable(os.Getenv("MINIMAX_TABLE_MB"))
 
 	// Initialize controllers
-	aiController := controller.NewAIController()
-	gameController := controller.NewGameController()
+	aiController := controller.NewAIController(llmService, aiGameService)
+	aiGameController := controller.NewAIGameController(aiGameService)
+	gameController := controller.NewGameController(gameService)
 	llmController := controller.NewLLMController(llmService)
+	reviewController := controller.NewReviewController(reviewService)
+	puzzleController := controller.NewPuzzleController(puzzleService)
 
 	// Setup routes
 	api := r.Group("/api")
 	{
 		// AI endpoints
 		api.POST("/ai/move", aiController.GetAIMove)
-		api.GET("/ai/status", aiController.GetGameStatus)
-		api.POST("/ai/reset", aiController.ResetGame)
+		api.POST("/ai/analyze", aiController.AnalyzePosition)
+		api.POST("/ai/hint", aiController.GetHint)
 		api.GET("/ai/stats", aiController.GetAIStats)
 		api.POST("/ai/cache/clear", aiController.ClearCache)
 		api.GET("/ai/difficulties", aiController.GetDifficultyLevels)
 		api.POST("/ai/benchmark", aiController.BenchmarkAI)
+		api.GET("/ai/engines", aiController.GetEngines)
+		api.GET("/ai/weights", aiController.GetEvalWeights)
+		api.GET("/ai/personalities", aiController.GetPersonalities)
+		api.GET("/ai/book", aiController.GetOpeningBook)
+		api.POST("/ai/book", aiController.AddOpeningBookMove)
+		api.DELETE("/ai/book", aiController.DeleteOpeningBookMove)
+		api.POST("/ai/book/lookup", aiController.LookupOpeningBook)
+		api.POST("/ai/book/import", aiController.ImportOpeningBook)
+
+		// AI game session endpoints
+		api.POST("/ai/games", aiGameController.CreateGame)
+		api.GET("/ai/games/:id", aiGameController.GetGame)
+		api.POST("/ai/games/:id/move", aiGameController.MakeMove)
+		api.DELETE("/ai/games/:id", aiGameController.DeleteGame)
+		api.POST("/ai/games/:id/undo", aiGameController.Undo)
+		api.POST("/ai/games/:id/resign", aiGameController.Resign)
+		api.GET("/ai/games/:id/history", aiGameController.GetGameHistory)
+		api.GET("/ai/status", aiGameController.GetGameStatus)
+		api.POST("/ai/reset", aiGameController.ResetGame)
 
 		// LLM endpoints
 		api.POST("/llm/start", llmController.StartGame)
@@ -54,6 +97,7 @@ func main() {
 		api.GET("/llm/game/:id", llmController.GetGame)
 		api.DELETE("/llm/game/:id", llmController.DeleteGame)
 		api.GET("/llm/game/:id/history", llmController.GetGameHistory)
+		api.GET("/llm/game/:id/stream", llmController.StreamGame)
 		api.GET("/llm/models", llmController.GetModels)
 		api.PUT("/llm/config/:model", llmController.UpdateConfig)
 		api.GET("/llm/config/:model", llmController.GetConfig)
@@ -65,11 +109,21 @@ func main() {
 		api.GET("/rooms", gameController.GetActiveRooms)
 		api.GET("/rooms/:id", gameController.GetRoom)
 		api.POST("/rooms/:id/join", gameController.JoinRoom)
+		api.POST("/rooms/:id/ai", gameController.AddAIPlayer)
 		api.POST("/rooms/:id/start", gameController.StartGame)
 		api.POST("/rooms/:id/move", gameController.MakeMove)
 		api.POST("/rooms/:id/leave", gameController.LeaveRoom)
 		api.POST("/rooms/:id/ready", gameController.SetPlayerReady)
 
+		// Post-game analysis endpoints
+		api.POST("/games/analysis", reviewController.SubmitGame)
+		api.GET("/games/:id/analysis", reviewController.GetGameAnalysis)
+
+		// Puzzle endpoints
+		api.GET("/puzzles/next", puzzleController.GetNextPuzzle)
+		api.GET("/puzzles/:id", puzzleController.GetPuzzle)
+		api.POST("/puzzles/:id/attempt", puzzleController.AttemptPuzzle)
+
 		// WebSocket endpoint
 		api.GET("/ws", gameController.HandleWebSocket)
 	}
@@ -80,3 +134,89 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// registerExternalEngines registers the Gomocup brains listed as "name=path" pairs
+// separated by semicolons, e.g. "rapfi=/opt/brains/pbrain-rapfi"
+func registerExternalEngines(spec string) {
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, path, found := strings.Cut(entry, "=")
+		if !found {
+			name, path = "", entry
+		}
+		engine, err := service.RegisterExternalEngine(service.ExternalEngineConfig{Name: name, Path: path})
+		if err != nil {
+			log.Printf("Skipping external engine %q: %v", entry, err)
+			continue
+		}
+		log.Printf("Registered external engine %s (%s)", engine.Name(), path)
+	}
+}
+
+// registerEvalWeights registers the JSON weight sets listed as paths separated by semicolons
+func registerEvalWeights(spec string) {
+	for _, path := range strings.Split(spec, ";") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		weights, err := service.LoadEvalWeights(path)
+		if err == nil {
+			err = service.RegisterEvalWeights(weights)
+		}
+		if err != nil {
+			log.Printf("Skipping weight set %q: %v", path, err)
+			continue
+		}
+		log.Printf("Registered weight set %s (%s)", weights.Name, path)
+	}
+}
+
+// configureTranspositionTable sizes the minimax engine's shared transposition table, in
+// megabytes; it keeps service.SharedTTMemory when unset
+func configureTranspositionTable(megabytes string) {
+	if megabytes == "" {
+		return
+	}
+	size, err := strconv.Atoi(megabytes)
+	if err != nil || size < 1 || size > 1<<16 {
+		log.Printf("Keeping the %dMB transposition table: invalid size %q", service.SharedTTMemory>>20, megabytes)
+		return
+	}
+	engine, _ := service.GetEngine("minimax")
+	engine.(*service.EnhancedAIService).SetTranspositionTableMemory(int64(size) << 20)
+	log.Printf("Minimax transposition table set to %dMB", size)
+}
+
+// configureNeuralEval makes the minimax engine evaluate with the network file at the
+// comma-separated difficulties (expert by default); the others keep the pattern evaluation
+func configureNeuralEval(path, difficulties string) {
+	if path == "" {
+		return
+	}
+	network, err := service.LoadNeuralNetwork(path)
+	if err != nil {
+		log.Printf("Keeping the pattern evaluation: %v", err)
+		return
+	}
+	if difficulties == "" {
+		difficulties = "expert"
+	}
+
+	engine, _ := service.GetEngine("minimax")
+	ai := engine.(*service.EnhancedAIService)
+	levels := map[string]service.Difficulty{"easy": service.Easy, "medium": service.Medium, "hard": service.Hard, "expert": service.Expert}
+	for _, name := range strings.Split(difficulties, ",") {
+		name = strings.TrimSpace(name)
+		difficulty, ok := levels[name]
+		if !ok {
+			log.Printf("Skipping unknown difficulty %q for the neural evaluation", name)
+			continue
+		}
+		ai.SetNeuralNetwork(difficulty, network)
+		log.Printf("Neural evaluation enabled at %s (%s)", name, path)
+	}
+}
@@ -1,5 +1,5 @@
 import axios from 'axios'
-import type { AIRequest, AIResponse, ApiResponse } from '../types/game'
+import type { AIGame, AIGameMove, AIGameOptions, AIGameTurn, AIRequest, AIResponse, AnalysisRequest, ApiResponse, GameReview, Hint, HintRequest, Move, Personality, PositionAnalysis, Puzzle, PuzzleAttempt } from '../types/game'
 
 // 创建axios实例
 const api = axios.create({
@@ -36,13 +36,19 @@ api.interceptors.response.use(
 
 // AI相关API
 export const aiApi = {
-  // 获取AI移动
-  async getMove(request: AIRequest, difficulty: 'easy' | 'medium' | 'hard' | 'expert' = 'medium', useEnhanced: boolean = true): Promise<AIResponse> {
+  // 获取AI移动；level 为拟人化强度等级（1-12），设置后代替难度；personality 为棋风
+  async getMove(request: AIRequest, difficulty: 'easy' | 'medium' | 'hard' | 'expert' = 'medium', useEnhanced: boolean = true, level?: number, personality?: string): Promise<AIResponse> {
     try {
       const params = new URLSearchParams({
         difficulty: difficulty,
         enhanced: useEnhanced.toString()
       })
+      if (level !== undefined) {
+        params.set('level', level.toString())
+      }
+      if (personality) {
+        params.set('personality', personality)
+      }
 
       const response = await api.post<AIResponse>(`/ai/move?${params}`, request)
       return response.data
@@ -52,10 +58,34 @@ export const aiApi = {
     }
   },
 
-  // 获取游戏状态
-  async getStatus(): Promise<ApiResponse> {
+  // 分析局面：候选着法、胜率和双方威胁
+  async analyze(request: AnalysisRequest, difficulty: 'easy' | 'medium' | 'hard' | 'expert' = 'medium'): Promise<PositionAnalysis> {
     try {
-      const response = await api.get('/ai/status')
+      const params = new URLSearchParams({ difficulty })
+      const response = await api.post<{ analysis: PositionAnalysis }>(`/ai/analyze?${params}`, request)
+      return response.data.analysis
+    } catch (error: any) {
+      console.error('局面分析失败:', error)
+      throw new Error(error.response?.data?.error || '局面分析失败')
+    }
+  },
+
+  // 获取提示：推荐着法和文字解释
+  async hint(request: HintRequest, difficulty: 'easy' | 'medium' | 'hard' | 'expert' = 'medium'): Promise<{ hint: Hint; hintsUsed?: number }> {
+    try {
+      const params = new URLSearchParams({ difficulty })
+      const response = await api.post<{ hint: Hint; hintsUsed?: number }>(`/ai/hint?${params}`, request)
+      return response.data
+    } catch (error: any) {
+      console.error('获取提示失败:', error)
+      throw new Error(error.response?.data?.error || '获取提示失败')
+    }
+  },
+
+  // 获取AI服务状态；传入 gameId 时返回该对局的状态
+  async getStatus(gameId?: string): Promise<ApiResponse> {
+    try {
+      const response = await api.get('/ai/status', { params: gameId ? { gameId } : {} })
       return response.data
     } catch (error: any) {
       console.error('获取游戏状态失败:', error)
@@ -63,10 +93,10 @@ export const aiApi = {
     }
   },
 
-  // 重置游戏
-  async resetGame(): Promise<ApiResponse> {
+  // 以相同设置重新开始对局
+  async resetGame(gameId: string): Promise<AIGameTurn> {
     try {
-      const response = await api.post('/ai/reset')
+      const response = await api.post<AIGameTurn>('/ai/reset', { gameId })
       return response.data
     } catch (error: any) {
       console.error('重置游戏失败:', error)
@@ -107,6 +137,17 @@ export const aiApi = {
     }
   },
 
+  // 获取棋风列表
+  async getPersonalities(): Promise<{ personalities: Personality[] }> {
+    try {
+      const response = await api.get('/ai/personalities')
+      return response.data
+    } catch (error: any) {
+      console.error('获取棋风失败:', error)
+      throw new Error(error.response?.data?.error || '获取棋风失败')
+    }
+  },
+
   // AI性能基准测试
   async benchmarkAI(difficulty: string = 'medium', moveCount: number = 10): Promise<ApiResponse> {
     try {
@@ -191,4 +232,132 @@ export const matchApi = {
   }
 }
 
-export default api
\ No newline at end of file
+export default api
+// 赛后复盘API
+export const reviewApi = {
+  // 获取对局复盘；首次请求会排队分析，进行中时 status 为 queued 或 running
+  async getAnalysis(gameId: string): Promise<GameReview> {
+    try {
+      const response = await api.get<{ analysis: GameReview }>(`/games/${gameId}/analysis`)
+      return response.data.analysis
+    } catch (error: any) {
+      console.error('获取复盘失败:', error)
+      throw new Error(error.response?.data?.error || '获取复盘失败')
+    }
+  },
+
+  // 提交浏览器中进行的人机对局进行复盘
+  async submitGame(moves: Move[], winner: number): Promise<GameReview> {
+    try {
+      const response = await api.post<{ analysis: GameReview }>('/games/analysis', { moves, winner })
+      return response.data.analysis
+    } catch (error: any) {
+      console.error('提交复盘失败:', error)
+      throw new Error(error.response?.data?.error || '提交复盘失败')
+    }
+  }
+}
+
+// 服务端人机对局API（AI 会在玩家思考时预先计算）
+export const aiGameApi = {
+  // 创建对局；aiPlayer 为 1 时 AI 执黑先行
+  async create(options: AIGameOptions = {}): Promise<AIGameTurn> {
+    try {
+      const response = await api.post<AIGameTurn>('/ai/games', options)
+      return response.data
+    } catch (error: any) {
+      console.error('创建人机对局失败:', error)
+      throw new Error(error.response?.data?.error || '创建人机对局失败')
+    }
+  },
+
+  // 落子并获取AI应着
+  async move(gameId: string, x: number, y: number): Promise<AIGameTurn> {
+    try {
+      const response = await api.post<AIGameTurn>(`/ai/games/${gameId}/move`, { x, y })
+      return response.data
+    } catch (error: any) {
+      console.error('人机对局落子失败:', error)
+      throw new Error(error.response?.data?.error || '人机对局落子失败')
+    }
+  },
+
+  // 获取对局
+  async get(gameId: string): Promise<AIGame> {
+    try {
+      const response = await api.get<{ game: AIGame }>(`/ai/games/${gameId}`)
+      return response.data.game
+    } catch (error: any) {
+      console.error('获取人机对局失败:', error)
+      throw new Error(error.response?.data?.error || '获取人机对局失败')
+    }
+  },
+
+  // 悔棋：撤回玩家上一手及AI的应着
+  async undo(gameId: string): Promise<AIGame> {
+    try {
+      const response = await api.post<{ game: AIGame }>(`/ai/games/${gameId}/undo`)
+      return response.data.game
+    } catch (error: any) {
+      console.error('悔棋失败:', error)
+      throw new Error(error.response?.data?.error || '悔棋失败')
+    }
+  },
+
+  // 认输
+  async resign(gameId: string): Promise<AIGame> {
+    try {
+      const response = await api.post<{ game: AIGame }>(`/ai/games/${gameId}/resign`)
+      return response.data.game
+    } catch (error: any) {
+      console.error('认输失败:', error)
+      throw new Error(error.response?.data?.error || '认输失败')
+    }
+  },
+
+  // 获取棋谱，limit 为返回的最近手数
+  async history(gameId: string, limit = 50): Promise<{ moves: AIGameMove[]; total: number }> {
+    try {
+      const response = await api.get(`/ai/games/${gameId}/history`, { params: { limit } })
+      return response.data
+    } catch (error: any) {
+      console.error('获取棋谱失败:', error)
+      throw new Error(error.response?.data?.error || '获取棋谱失败')
+    }
+  },
+
+  // 删除对局，停止AI的后台计算
+  async remove(gameId: string): Promise<void> {
+    try {
+      await api.delete(`/ai/games/${gameId}`)
+    } catch (error: any) {
+      console.error('删除人机对局失败:', error)
+      throw new Error(error.response?.data?.error || '删除人机对局失败')
+    }
+  }
+}
+
+// 杀棋题API
+export const puzzleApi = {
+  // 按难度顺序获取下一题；after 为上一题的ID
+  async next(options: { after?: string; kind?: 'vcf' | 'vct'; minLength?: number; maxLength?: number } = {}): Promise<Puzzle> {
+    try {
+      const response = await api.get<{ puzzle: Puzzle }>('/puzzles/next', { params: options })
+      return response.data.puzzle
+    } catch (error: any) {
+      console.error('获取题目失败:', error)
+      throw new Error(error.response?.data?.error || '获取题目失败')
+    }
+  },
+
+  // 提交己方着法（不含AI应着），逐手验证
+  async attempt(puzzleId: string, moves: Move[]): Promise<PuzzleAttempt> {
+    try {
+      const response = await api.post<{ attempt: PuzzleAttempt }>(`/puzzles/${puzzleId}/attempt`, { moves })
+      return response.data.attempt
+    } catch (error: any) {
+      console.error('提交解答失败:', error)
+      throw new Error(error.response?.data?.error || '提交解答失败')
+    }
+  }
+}
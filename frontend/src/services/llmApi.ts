@@ -14,7 +14,8 @@ import type {
   LLMStartGameRequest,
   LLMGameHistoryResponse,
   LLMStats,
-  LLMHealthResponse
+  LLMHealthResponse,
+  LLMStreamEvent
 } from '../types/game'
 
 export const llmApi = {
@@ -43,6 +44,23 @@ export const llmApi = {
     return response.data
   },
 
+  // 订阅模型的实时输出，返回关闭连接的函数
+  streamThinking(gameId: string, onEvent: (event: LLMStreamEvent) => void): () => void {
+    const source = new EventSource(`/api/llm/game/${gameId}/stream`)
+    const handle = (message: MessageEvent) => {
+      const event: LLMStreamEvent = JSON.parse(message.data)
+      onEvent(event)
+      // 着法或错误是本回合的最后一个事件
+      if (event.type === 'move' || event.type === 'error') {
+        source.close()
+      }
+    }
+    for (const type of ['start', 'token', 'move', 'error']) {
+      source.addEventListener(type, handle as EventListener)
+    }
+    return () => source.close()
+  },
+
   // 获取游戏信息
   async getGame(gameId: string): Promise<ApiResponse<LLMGame>> {
     const response = await api.get(`/llm/game/${gameId}`)
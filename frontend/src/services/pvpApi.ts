@@ -4,6 +4,7 @@ import type {
   CreateRoomResponse,
   JoinRoomRequest,
   JoinRoomResponse,
+  AddAIPlayerRequest,
   RoomListResponse,
   RoomResponse,
   GameResponse
@@ -29,6 +30,12 @@ export const pvpApi = {
     return data
   },
 
+  // 添加指定棋风的 AI 座位
+  async addAIPlayer(roomId: string, payload: AddAIPlayerRequest): Promise<JoinRoomResponse> {
+    const { data } = await apiClient.post(`/rooms/${roomId}/ai`, payload)
+    return data
+  },
+
   async getRoom(roomId: string): Promise<RoomResponse> {
     const { data } = await apiClient.get(`/rooms/${roomId}`)
     return data
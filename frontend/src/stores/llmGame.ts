@@ -43,6 +43,8 @@ export const useLLMGameStore = defineStore('llmGame', () => {
   // 状态标志
   const isLoading = ref(false)
   const isAiThinking = ref(false)
+  const aiReasoning = ref('')  // 模型本回合的实时输出
+  const aiAttempt = ref(0)     // 本回合第几次请求模型
   const error = ref<string | null>(null)
   const moveHistory = ref<Position[]>([])
   
@@ -171,6 +173,22 @@ export const useLLMGameStore = defineStore('llmGame', () => {
   async function getAIMove() {
     if (!currentSession.value) return
 
+    // 在请求落子之前订阅，以免错过模型最先输出的内容
+    aiReasoning.value = ''
+    aiAttempt.value = 0
+    const closeStream = llmApi.streamThinking(currentSession.value.id, (event) => {
+      switch (event.type) {
+        case 'start':
+          // 纠错重问时模型从头回答
+          aiReasoning.value = ''
+          aiAttempt.value = event.attempt || 0
+          break
+        case 'token':
+          aiReasoning.value += event.text || ''
+          break
+      }
+    })
+
     try {
       isAiThinking.value = true
 
@@ -242,6 +260,7 @@ export const useLLMGameStore = defineStore('llmGame', () => {
       // 如果AI移动失败，切换回玩家回合
       gameState.currentPlayer = Player.HUMAN
     } finally {
+      closeStream()
       isAiThinking.value = false
     }
   }
@@ -324,6 +343,8 @@ export const useLLMGameStore = defineStore('llmGame', () => {
     modelConfig,
     isLoading,
     isAiThinking,
+    aiReasoning,
+    aiAttempt,
     error,
     moveHistory,
     statistics,
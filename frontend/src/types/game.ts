@@ -50,9 +50,211 @@ export interface AIResponse {
     x: number
     y: number
     score: number
+    fromBook?: boolean // 是否来自开局库
   }
   gameStatus: string
   winner: number
+  pv?: Move[] // 预期的主要变化，从AI这一步开始
+}
+
+// 局面分析请求，player 为轮到走棋的一方
+export interface AnalysisRequest extends AIRequest {
+  multiPV?: number // 候选着法数量，默认 3
+}
+
+// 候选着法
+export interface CandidateMove {
+  x: number
+  y: number
+  score: number
+  winProbability: number
+  pv: Move[]
+}
+
+// 棋型威胁：冲四/活四的成五点，或活三的成活四点
+export interface Threat {
+  shape: string
+  stones: Move[]
+  points: Move[]
+}
+
+// 一方的威胁
+export interface SideThreats {
+  player: number
+  fours: Threat[]
+  openThrees: Threat[]
+}
+
+// 局面分析结果
+export interface PositionAnalysis {
+  toMove: number
+  score: number
+  winProbability: number
+  depth: number
+  nodes: number
+  timeMs: number
+  candidates: CandidateMove[]
+  threats: SideThreats[] // 黑方、白方
+  gameOver: boolean
+}
+
+// 提示请求：传入对局 ID 或棋盘
+export interface HintRequest extends Partial<AIRequest> {
+  gameId?: string
+}
+
+// 提示着法及其解释
+export interface Hint {
+  x: number
+  y: number
+  player: number
+  score: number
+  winProbability: number
+  reasons: string[]
+  explanation: string
+  pv?: Move[]
+}
+
+// 复盘中单步着法的评价
+export interface MoveReview {
+  number: number
+  x: number
+  y: number
+  player: number
+  score: number
+  bestScore: number
+  swing: number
+  winProbability: number
+  loss: number
+  best: Move
+  bestLine: Move[]
+  classification: 'best' | 'good' | 'inaccuracy' | 'mistake' | 'blunder'
+}
+
+// 单方着法统计
+export interface PlayerReview {
+  player: number
+  moves: number
+  best: number
+  inaccuracies: number
+  mistakes: number
+  blunders: number
+  averageLoss: number
+}
+
+// 赛后复盘
+export interface GameReview {
+  gameId: string
+  mode: 'ai' | 'llm' | 'pvp'
+  status: 'queued' | 'running' | 'done' | 'failed'
+  error?: string
+  progress: number
+  total: number
+  winner: number
+  decidedAt: number // 胜负已定的手数，0 表示未分胜负
+  moves: MoveReview[]
+  players: PlayerReview[] // 黑方、白方
+  createdAt: string
+  completedAt?: string
+}
+
+// 拟人化强度等级
+export interface StrengthLevel {
+  level: number
+  name: string
+  elo: number // 实测等级分，1 级固定为 600
+  depth: number
+  nodes: number
+  candidates: number
+  temperature: number
+  oversight: number
+  vision: number
+}
+
+// AI 棋风
+export interface Personality {
+  name: string
+  description: string
+  attack: number // 己方棋形权重（百分比）
+  defence: number // 对方棋形权重（百分比）
+  orderAttack: number
+  orderDefence: number
+  orderBroken: number
+  weights: Record<string, number | string>
+  repertoire: [number, number][][] // 开局谱，相对天元的坐标
+}
+
+// 服务端人机对局
+export interface AIGame {
+  id: string
+  engine: string
+  personality?: string
+  difficulty: string
+  aiPlayer: number // AI 执子方：1 黑 2 白
+  status: 'playing' | 'human_win' | 'ai_win' | 'draw'
+  winner: number
+  endReason?: 'five' | 'resign' | 'board_full'
+  board: { grid: number[][]; size: number; currentPlayer: number; moveCount: number }
+  moves: AIGameMove[]
+  undos: number // 悔棋次数
+  hints: number // 使用提示次数
+  ponderMove?: Move // AI 预测的玩家下一手
+  ponderHits: number
+  ponderMisses: number
+  createdAt: string
+  updatedAt: string
+  endedAt?: string
+}
+
+// 人机对局中的一手；AI 的着法带有搜索结果
+export interface AIGameMove extends Move {
+  number: number
+  score?: number
+  pv?: Move[]
+  fromBook?: boolean
+  ponderHit?: boolean
+  timestamp: string
+}
+
+// 创建人机对局的参数
+export interface AIGameOptions {
+  engine?: string // 默认 minimax
+  personality?: string
+  difficulty?: 'easy' | 'medium' | 'hard' | 'expert'
+  aiPlayer?: 1 | 2 // 为 1 时 AI 执黑先行
+}
+
+// 杀棋题：执子方有必胜的连续冲四（vcf）或冲四活三（vct）
+export interface Puzzle {
+  id: string
+  board: number[][]
+  toMove: number // 取胜方
+  lastMove: Move
+  kind: 'vcf' | 'vct'
+  length: number // 最短解的己方手数（含成五）
+  rating: number
+  source: 'selfplay' | 'llm' | 'pvp'
+  gameId?: string
+  moveNumber: number
+  createdAt: string
+}
+
+// 解题结果
+export interface PuzzleAttempt {
+  puzzleId: string
+  status: 'continue' | 'solved' | 'failed' | 'undecided' // undecided: 搜索预算内未能判定
+  moves: Move[] // 玩家着法与AI应着，按顺序
+  reply?: Move // AI 对最后一手的应着
+  failedAt: number // 第一手错着的序号，0 表示没有
+  message: string
+  solution?: Move[] // 解完或失败后给出
+}
+
+// 人机对局落子结果
+export interface AIGameTurn {
+  game: AIGame
+  aiMove?: AIResponse['aiMove'] & { pv?: Move[] }
+  ponderHit: boolean // AI 应着是否在玩家思考时已算出
 }
 
 // API响应基础类型
@@ -82,9 +284,39 @@ export interface LLMMove {
   player: number
   reasoning: string
   confidence: number
+  usage?: LLMUsage
+  attempts?: number   // 本回合向模型请求的次数（含纠错重问）
+  fallback?: boolean  // 模型始终未给出合法着法，由引擎代为落子
   timestamp: string
 }
 
+// LLM用量：请求次数及计费的token数
+export interface LLMUsage {
+  requests: number
+  promptTokens: number
+  completionTokens: number
+  totalTokens: number
+}
+
+// LLM着法质量统计
+export interface LLMMoveStats {
+  moves: number
+  attempts: number
+  illegalMoves: number
+  parseFailures: number
+  fallbacks: number
+}
+
+// LLM实时输出事件：开始请求、模型输出片段，以及最终的着法或错误
+export interface LLMStreamEvent {
+  type: 'start' | 'token' | 'move' | 'error'
+  attempt?: number     // 本回合第几次请求（start）
+  text?: string        // 模型输出的片段（token）
+  move?: LLMMove       // 最终落子（move）
+  gameStatus?: string  // 本回合后的游戏状态（move）
+  error?: string       // 失败原因（error）
+}
+
 // LLM游戏信息
 export interface LLMGame {
   id: string
@@ -94,6 +326,8 @@ export interface LLMGame {
   currentPlayer: number
   board: number[][]
   moves: LLMMove[]
+  hints: number
+  usage: LLMUsage
   createdAt: string
   updatedAt: string
 }
@@ -173,6 +407,8 @@ export interface LLMStats {
   aiWins: number
   draws: number
   popularModel: string
+  usage: Record<string, LLMUsage>
+  moveStats: Record<string, LLMMoveStats>
 }
 
 // LLM健康检查响应
@@ -5,6 +5,8 @@ export interface Player {
   name: string
   isReady: boolean
   color?: 'black' | 'white'
+  isAI?: boolean
+  personality?: string // AI 座位的棋风
 }
 
 export interface Room {
@@ -48,6 +50,11 @@ export interface JoinRoomRequest {
   playerName: string
 }
 
+export interface AddAIPlayerRequest {
+  personality: string
+  difficulty?: 'easy' | 'medium' | 'hard' | 'expert'
+}
+
 export interface MakeMoveRequest {
   x: number
   y: number